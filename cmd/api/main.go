@@ -4,10 +4,13 @@ import (
 	_ "movie_app/docs"
 	"movie_app/internal/config"
 	"movie_app/internal/handler"
+	"movie_app/internal/mail"
 	"movie_app/internal/middleware"
+	"movie_app/internal/oidc"
 	"movie_app/internal/repository"
 	"movie_app/internal/router"
 	"movie_app/internal/service"
+	"movie_app/internal/storage"
 
 	"context"
 	"errors"
@@ -20,6 +23,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	uberfx "go.uber.org/fx"
+	"gorm.io/gorm"
 )
 
 const (
@@ -48,6 +52,10 @@ const (
 // @in header
 // @name Authorization
 
+// @securityDefinitions.apikey PersonalAPIKey
+// @in header
+// @name X-API-Key
+
 func ProvideRepositories() uberfx.Option {
 	return uberfx.Provide(
 		uberfx.Annotate(
@@ -58,21 +66,292 @@ func ProvideRepositories() uberfx.Option {
 			repository.NewUserRepository,
 			uberfx.As(new(repository.UserRepository)),
 		),
+		uberfx.Annotate(
+			repository.NewGenreRepository,
+			uberfx.As(new(repository.GenreRepository)),
+		),
+		uberfx.Annotate(
+			repository.NewPersonRepository,
+			uberfx.As(new(repository.PersonRepository)),
+		),
+		uberfx.Annotate(
+			repository.NewCreditRepository,
+			uberfx.As(new(repository.CreditRepository)),
+		),
+		uberfx.Annotate(
+			repository.NewSessionRepository,
+			uberfx.As(new(repository.SessionRepository)),
+		),
+		uberfx.Annotate(
+			repository.NewSigningKeyRepository,
+			uberfx.As(new(repository.SigningKeyRepository)),
+		),
+		uberfx.Annotate(
+			repository.NewPasswordResetRepository,
+			uberfx.As(new(repository.PasswordResetRepository)),
+		),
+		uberfx.Annotate(
+			repository.NewEmailVerificationRepository,
+			uberfx.As(new(repository.EmailVerificationRepository)),
+		),
+		uberfx.Annotate(
+			repository.NewMFARepository,
+			uberfx.As(new(repository.MFARepository)),
+		),
+		uberfx.Annotate(
+			repository.NewAuditRepository,
+			uberfx.As(new(repository.AuditRepository)),
+		),
+		// Lockouts are shared through the database unless a single instance
+		// keeps them in memory
+		func(db *gorm.DB, cfg *config.Config) repository.LoginThrottleStore {
+			if cfg.Login.ThrottleStore == "memory" {
+				return repository.NewMemoryLoginThrottleStore()
+			}
+
+			return repository.NewLoginThrottleRepository(db)
+		},
+		uberfx.Annotate(
+			repository.NewAPIKeyRepository,
+			uberfx.As(new(repository.APIKeyRepository)),
+		),
+		uberfx.Annotate(
+			repository.NewOIDCRepository,
+			uberfx.As(new(repository.OIDCRepository)),
+		),
+		uberfx.Annotate(
+			repository.NewDataExportRepository,
+			uberfx.As(new(repository.DataExportRepository)),
+		),
+		uberfx.Annotate(
+			repository.NewRatingRepository,
+			uberfx.As(new(repository.RatingRepository)),
+		),
+		uberfx.Annotate(
+			repository.NewReviewRepository,
+			uberfx.As(new(repository.ReviewRepository)),
+		),
+		uberfx.Annotate(
+			repository.NewWatchlistRepository,
+			uberfx.As(new(repository.WatchlistRepository)),
+		),
+		uberfx.Annotate(
+			repository.NewDiaryRepository,
+			uberfx.As(new(repository.DiaryRepository)),
+		),
+		uberfx.Annotate(
+			repository.NewListRepository,
+			uberfx.As(new(repository.ListRepository)),
+		),
 	)
 }
 
 func ProvideServices() uberfx.Option {
 	return uberfx.Provide(
 		uberfx.Annotate(
-			service.NewMovieService,
+			func(
+				repo repository.MovieRepository,
+				genres repository.GenreRepository,
+				people repository.PersonRepository,
+				cfg *config.Config,
+			) service.MovieService {
+				return service.NewMovieService(repo, genres, people, service.MovieOptions{
+					CursorSecret:        cfg.Pagination.CursorSecret,
+					SimilarityThreshold: cfg.Search.SimilarityThreshold,
+					SuggestLimit:        cfg.Search.SuggestLimit,
+					TrashRetention:      cfg.Movie.TrashRetention,
+				})
+			},
 			uberfx.As(new(service.MovieService)),
 		),
 		uberfx.Annotate(
-			func(repo repository.UserRepository, cfg *config.Config) service.UserService {
-				return service.NewUserService(repo, cfg.JWT.Secret)
+			func(
+				repo repository.UserRepository,
+				tokens service.TokenService,
+				sessions service.SessionService,
+				verification service.VerificationService,
+				mfa service.MFAService,
+				throttle service.LoginThrottle,
+				cfg *config.Config,
+			) service.UserService {
+				return service.NewUserService(
+					repo, tokens, sessions, verification, mfa, throttle, cfg.Account.VerificationPolicy,
+				)
 			},
 			uberfx.As(new(service.UserService)),
 		),
+		uberfx.Annotate(
+			func(
+				store repository.LoginThrottleStore,
+				audit repository.AuditRepository,
+				cfg *config.Config,
+			) service.LoginThrottle {
+				return service.NewLoginThrottle(store, audit, service.LoginThrottleOptions{
+					MaxAttempts:      cfg.Login.MaxAttempts,
+					MaxAttemptsPerIP: cfg.Login.MaxAttemptsPerIP,
+					Window:           cfg.Login.AttemptWindow,
+					LockoutBase:      cfg.Login.LockoutBase,
+					LockoutMax:       cfg.Login.LockoutMax,
+				})
+			},
+			uberfx.As(new(service.LoginThrottle)),
+		),
+		uberfx.Annotate(
+			func(
+				repo repository.MFARepository,
+				users repository.UserRepository,
+				throttle service.LoginThrottle,
+				cfg *config.Config,
+			) service.MFAService {
+				return service.NewMFAService(repo, users, throttle, service.MFAOptions{
+					Issuer:                   cfg.Account.MFAIssuer,
+					EncryptionSecret:         cfg.Encryption.Secret,
+					PreviousEncryptionSecret: cfg.Encryption.PreviousSecret,
+				})
+			},
+			uberfx.As(new(service.MFAService)),
+		),
+		uberfx.Annotate(
+			func(
+				repo repository.EmailVerificationRepository,
+				users repository.UserRepository,
+				mailer mail.Mailer,
+				templates *mail.Templates,
+				cfg *config.Config,
+			) service.VerificationService {
+				return service.NewVerificationService(repo, users, mailer, templates, service.VerificationOptions{
+					TTL:            cfg.Account.VerificationTTL,
+					ResendInterval: cfg.Account.VerificationResendInterval,
+					AppURL:         cfg.Mail.AppURL,
+				})
+			},
+			uberfx.As(new(service.VerificationService)),
+		),
+		uberfx.Annotate(
+			func(
+				sessions repository.SessionRepository,
+				users repository.UserRepository,
+				denylist *service.SessionDenylist,
+				keys *service.KeySet,
+				cfg *config.Config,
+			) service.TokenService {
+				return service.NewTokenService(sessions, users, denylist, keys, service.TokenOptions{
+					Issuer:     cfg.JWT.Issuer,
+					Audience:   cfg.JWT.Audience,
+					AccessTTL:  cfg.JWT.AccessTTL,
+					RefreshTTL: cfg.JWT.RefreshTTL,
+				})
+			},
+			uberfx.As(new(service.TokenService)),
+		),
+		func(repo repository.SessionRepository, cfg *config.Config) *service.SessionDenylist {
+			return service.NewSessionDenylist(repo, cfg.JWT.AccessTTL)
+		},
+		uberfx.Annotate(
+			func(
+				repo repository.OIDCRepository,
+				users repository.UserRepository,
+				audit repository.AuditRepository,
+				tokens service.TokenService,
+				mfa service.MFAService,
+				provider oidc.Provider,
+				cfg *config.Config,
+			) service.OIDCService {
+				return service.NewOIDCService(repo, users, audit, tokens, mfa, provider, service.OIDCOptions{
+					AutoRegister: cfg.OIDC.AutoRegister,
+					StateTTL:     cfg.OIDC.StateTTL,
+				})
+			},
+			uberfx.As(new(service.OIDCService)),
+		),
+		uberfx.Annotate(
+			service.NewAPIKeyService,
+			uberfx.As(new(service.APIKeyService)),
+		),
+		uberfx.Annotate(
+			service.NewDataExportService,
+			uberfx.As(new(service.DataExportService)),
+		),
+		func(repo repository.DataExportRepository, files storage.Storage, cfg *config.Config) *service.DataExportWorker {
+			return service.NewDataExportWorker(repo, files, cfg.Export.TTL)
+		},
+		func(users repository.UserRepository, files storage.Storage, cfg *config.Config) *service.AccountPurger {
+			return service.NewAccountPurger(users, files, service.AccountPurgerOptions{
+				GracePeriod: cfg.Account.DeletionGracePeriod,
+				MinVotes:    cfg.Rating.MinVotes,
+			})
+		},
+		func(movies repository.MovieRepository, cfg *config.Config) *service.MoviePurger {
+			return service.NewMoviePurger(movies, service.MoviePurgerOptions{
+				Retention: cfg.Movie.TrashRetention,
+			})
+		},
+		func(repo repository.SigningKeyRepository, cfg *config.Config) *service.KeySet {
+			return service.NewKeySet(repo, service.KeySetOptions{
+				Algorithm:                cfg.JWT.Algorithm,
+				RotationInterval:         cfg.JWT.KeyRotationInterval,
+				GracePeriod:              cfg.JWT.KeyGracePeriod,
+				EncryptionSecret:         cfg.Encryption.Secret,
+				PreviousEncryptionSecret: cfg.Encryption.PreviousSecret,
+			})
+		},
+		uberfx.Annotate(
+			service.NewSessionService,
+			uberfx.As(new(service.SessionService)),
+		),
+		uberfx.Annotate(
+			func(
+				repo repository.PasswordResetRepository,
+				users repository.UserRepository,
+				sessions service.SessionService,
+				mailer mail.Mailer,
+				templates *mail.Templates,
+				cfg *config.Config,
+			) service.PasswordService {
+				return service.NewPasswordService(repo, users, sessions, mailer, templates, service.PasswordOptions{
+					ResetTTL:            cfg.Account.PasswordResetTTL,
+					ResetResendInterval: cfg.Account.PasswordResetResendInterval,
+					AppURL:              cfg.Mail.AppURL,
+				})
+			},
+			uberfx.As(new(service.PasswordService)),
+		),
+		uberfx.Annotate(
+			service.NewGenreService,
+			uberfx.As(new(service.GenreService)),
+		),
+		uberfx.Annotate(
+			service.NewPersonService,
+			uberfx.As(new(service.PersonService)),
+		),
+		uberfx.Annotate(
+			func(
+				repo repository.RatingRepository, movies repository.MovieRepository, cfg *config.Config,
+			) service.RatingService {
+				return service.NewRatingService(repo, movies, cfg.Rating.MinVotes)
+			},
+			uberfx.As(new(service.RatingService)),
+		),
+		uberfx.Annotate(
+			func(
+				repo repository.ReviewRepository, movies repository.MovieRepository, cfg *config.Config,
+			) service.ReviewService {
+				return service.NewReviewService(repo, movies, cfg.Review.RequireApproval)
+			},
+			uberfx.As(new(service.ReviewService)),
+		),
+		uberfx.Annotate(
+			service.NewWatchlistService,
+			uberfx.As(new(service.WatchlistService)),
+		),
+		uberfx.Annotate(
+			service.NewDiaryService,
+			uberfx.As(new(service.DiaryService)),
+		),
+		uberfx.Annotate(
+			service.NewListService,
+			uberfx.As(new(service.ListService)),
+		),
 	)
 }
 
@@ -80,11 +359,76 @@ func ProvideHandlers() uberfx.Option {
 	return uberfx.Provide(
 		handler.NewMovieHandler,
 		handler.NewUserHandler,
+		handler.NewGenreHandler,
+		handler.NewPersonHandler,
+		handler.NewRatingHandler,
+		handler.NewReviewHandler,
+		handler.NewWatchlistHandler,
+		handler.NewDiaryHandler,
+		handler.NewListHandler,
+		handler.NewSessionHandler,
+		handler.NewJWKSHandler,
+		handler.NewPasswordHandler,
+		handler.NewVerificationHandler,
+		handler.NewMFAHandler,
+		handler.NewDataExportHandler,
+		handler.NewAPIKeyHandler,
+		handler.NewOIDCHandler,
 	)
 }
 
-func NewAuthMiddleware(cfg *config.Config) *middleware.AuthMiddleware {
-	return middleware.NewAuthMiddleware(cfg.JWT.Secret)
+func NewAuthMiddleware(
+	cfg *config.Config, keys *service.KeySet, denylist *service.SessionDenylist, apiKeys service.APIKeyService,
+) *middleware.AuthMiddleware {
+	return middleware.NewAuthMiddleware(keys.VerificationKey, denylist, apiKeys, middleware.AuthOptions{
+		Algorithms: service.SigningAlgorithms,
+		Issuer:     cfg.JWT.Issuer,
+		Audience:   cfg.JWT.Audience,
+
+		VerificationPolicy: cfg.Account.VerificationPolicy,
+	})
+}
+
+// StartKeySet loads the signing keys, creating the first one if needed, and
+// rotates them in the background.
+func StartKeySet(keys *service.KeySet, cfg *config.Config) error {
+	if err := keys.Sync(context.Background()); err != nil {
+		return err
+	}
+
+	go keys.Run(context.Background(), cfg.JWT.KeySyncInterval)
+
+	return nil
+}
+
+// StartSessionDenylist loads the recently revoked sessions and keeps them in
+// sync in the background.
+func StartSessionDenylist(denylist *service.SessionDenylist, cfg *config.Config) error {
+	if err := denylist.Sync(context.Background()); err != nil {
+		return err
+	}
+
+	go denylist.Run(context.Background(), cfg.JWT.DenylistSyncInterval)
+
+	return nil
+}
+
+// StartDataExportWorker builds requested data exports and deletes expired
+// ones in the background.
+func StartDataExportWorker(worker *service.DataExportWorker, cfg *config.Config) {
+	go worker.Run(context.Background(), cfg.Export.WorkerInterval)
+}
+
+// StartAccountPurger purges deleted accounts in the background once their
+// grace period is over.
+func StartAccountPurger(purger *service.AccountPurger, cfg *config.Config) {
+	go purger.Run(context.Background(), cfg.Account.PurgeInterval)
+}
+
+// StartMoviePurger purges deleted movies in the background once their
+// retention period is over.
+func StartMoviePurger(purger *service.MoviePurger, cfg *config.Config) {
+	go purger.Run(context.Background(), cfg.Movie.PurgeInterval)
 }
 
 func main() {
@@ -95,6 +439,14 @@ func main() {
 
 		uberfx.Provide(NewAuthMiddleware),
 
+		uberfx.Provide(mail.NewMailer, mail.NewTemplates),
+
+		uberfx.Provide(oidc.NewProvider),
+
+		uberfx.Provide(func(cfg *config.Config) storage.Storage {
+			return storage.NewLocalStorage(cfg.Export.Dir)
+		}),
+
 		// Provide all dependencies
 		ProvideRepositories(),
 		ProvideServices(),
@@ -103,6 +455,12 @@ func main() {
 		// Provide HTTP server
 		uberfx.Provide(router.NewRouter),
 
+		uberfx.Invoke(StartKeySet),
+		uberfx.Invoke(StartSessionDenylist),
+		uberfx.Invoke(StartAccountPurger),
+		uberfx.Invoke(StartMoviePurger),
+		uberfx.Invoke(StartDataExportWorker),
+
 		// Invoke server start
 		uberfx.Invoke(func(router *gin.Engine, cfg *config.Config) {
 			srv := &http.Server{
@@ -1,16 +1,34 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"movie_app/internal/domain"
+	"net"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Database DatabaseConfig
-	Server   ServerConfig
-	JWT      JWTConfig
+	Database   DatabaseConfig
+	Server     ServerConfig
+	JWT        JWTConfig
+	Encryption EncryptionConfig
+	Pagination PaginationConfig
+	Search     SearchConfig
+	Rating     RatingConfig
+	Review     ReviewConfig
+	Movie      MovieConfig
+	Mail       MailConfig
+	Account    AccountConfig
+	Login      LoginConfig
+	Export     ExportConfig
+	OIDC       OIDCConfig
 }
 
 type DatabaseConfig struct {
@@ -24,10 +42,109 @@ type DatabaseConfig struct {
 
 type ServerConfig struct {
 	Port string
+	// Reverse proxies whose X-Forwarded-For headers are trusted for client IP
+	// addresses, none by default
+	TrustedProxies []string
 }
 
 type JWTConfig struct {
-	Secret string
+	Secret     string // Signs listing cursors unless they have a secret of their own
+	Issuer     string
+	Audience   string
+	Algorithm  string        // EdDSA or RS256, for new signing keys
+	AccessTTL  time.Duration // Lifetime of access tokens
+	RefreshTTL time.Duration // Lifetime of refresh tokens, renewed on every refresh
+	// How often revoked sessions are loaded from the database, so that
+	// revocations made by other instances take effect
+	DenylistSyncInterval time.Duration
+	KeyRotationInterval  time.Duration // How long each signing key signs tokens
+	// How long a signing key is published before it signs, and verifies
+	// tokens after it retires
+	KeyGracePeriod  time.Duration
+	KeySyncInterval time.Duration // How often signing keys are rotated and reloaded
+}
+
+// EncryptionConfig is the secret that encrypts the token signing keys and
+// TOTP secrets stored in the database.
+type EncryptionConfig struct {
+	Secret         string
+	PreviousSecret string // Still decrypts what was encrypted before Secret changed
+}
+
+type PaginationConfig struct {
+	CursorSecret string
+}
+
+type SearchConfig struct {
+	SimilarityThreshold float64 // Minimum pg_trgm similarity for fuzzy title matches
+	SuggestLimit        int     // Default number of autocomplete suggestions
+}
+
+type RatingConfig struct {
+	MinVotes int // Votes a movie needs before its own average outweighs the global one
+}
+
+type ReviewConfig struct {
+	RequireApproval bool // Keep new and edited reviews pending until a moderator publishes them
+}
+
+type MailConfig struct {
+	Driver string // smtp, or file or log for local development
+	From   string
+	Dir    string // Where the file driver writes emails
+	AppURL string // Base URL of the web app that links in emails point to
+	SMTP   SMTPConfig
+}
+
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string // Leave empty to send without authenticating
+	Password string
+}
+
+type MovieConfig struct {
+	TrashRetention time.Duration // How long deleted movies are kept in the trash before they are purged
+	PurgeInterval  time.Duration // How often movies past their retention period are purged
+}
+
+type AccountConfig struct {
+	PasswordResetTTL            time.Duration // How long password reset links stay valid
+	PasswordResetResendInterval time.Duration // Minimum time between two password reset emails to a user
+	// What users cannot do until they verify their email: none, write or login
+	VerificationPolicy         domain.VerificationPolicy
+	VerificationTTL            time.Duration // How long email verification links stay valid
+	VerificationResendInterval time.Duration // Minimum time between two verification emails to a user
+	MFAIssuer                  string        // Name authenticator apps show for accounts
+	DeletionGracePeriod        time.Duration // How long deleted accounts are kept before they are purged
+	PurgeInterval              time.Duration // How often accounts past their grace period are purged
+}
+
+type LoginConfig struct {
+	ThrottleStore    string        // postgres, or memory for a single instance
+	MaxAttempts      int           // Failed logins to an account before it is locked out
+	MaxAttemptsPerIP int           // Failed logins from an IP address before it is locked out
+	AttemptWindow    time.Duration // How long failed logins count after the last one
+	LockoutBase      time.Duration // First lockout, doubled on every further failed login
+	LockoutMax       time.Duration
+}
+
+// OIDCConfig is the OpenID Connect identity provider users can log in with,
+// if any.
+type OIDCConfig struct {
+	Issuer       string // Leave empty to disable logging in with an identity provider
+	ClientID     string
+	ClientSecret string   // Leave empty for public clients, which rely on PKCE alone
+	RedirectURL  string   // Web app page the provider sends users back to
+	Scopes       []string // Requested along with openid
+	AutoRegister bool     // Create accounts for users whose email is not registered yet
+	StateTTL     time.Duration
+}
+
+type ExportConfig struct {
+	Dir            string        // Where data export archives are stored
+	TTL            time.Duration // How long data export archives can be downloaded
+	WorkerInterval time.Duration // How often pending and expired data exports are looked for
 }
 
 func LoadConfig() (*Config, error) {
@@ -48,10 +165,351 @@ func LoadConfig() (*Config, error) {
 			Port: getEnvOrDefault("SERVER_PORT", "8080"),
 		},
 		JWT: JWTConfig{
-			Secret: getEnvOrDefault("JWT_SECRET", "your-secret-key"),
+			Issuer:    getEnvOrDefault("JWT_ISSUER", "movie_app"),
+			Audience:  getEnvOrDefault("JWT_AUDIENCE", "movie_app"),
+			Algorithm: getEnvOrDefault("JWT_SIGNING_ALGORITHM", "EdDSA"),
+		},
+	}
+
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				return nil, fmt.Errorf("invalid TRUSTED_PROXIES: %s is not an IP address or CIDR range", proxy)
+			}
+		}
+
+		config.Server.TrustedProxies = append(config.Server.TrustedProxies, proxy)
+	}
+
+	jwtSecret, err := getEnvSecret("JWT_SECRET")
+	if err != nil {
+		return nil, err
+	}
+
+	encryptionSecret, err := getEnvSecret("KEY_ENCRYPTION_SECRET")
+	if err != nil {
+		return nil, err
+	}
+
+	// Encryption keys must not double as HMAC secrets
+	if encryptionSecret == jwtSecret {
+		return nil, errors.New("invalid KEY_ENCRYPTION_SECRET: must differ from JWT_SECRET")
+	}
+
+	config.JWT.Secret = jwtSecret
+	config.Encryption = EncryptionConfig{
+		Secret:         encryptionSecret,
+		PreviousSecret: os.Getenv("KEY_ENCRYPTION_PREVIOUS_SECRET"),
+	}
+
+	accessTTL, err := getEnvDurationOrDefault("JWT_ACCESS_TTL", 15*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshTTL, err := getEnvDurationOrDefault("JWT_REFRESH_TTL", 30*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	denylistSyncInterval, err := getEnvDurationOrDefault("JWT_DENYLIST_SYNC_INTERVAL", 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	keyRotationInterval, err := getEnvDurationOrDefault("JWT_KEY_ROTATION_INTERVAL", 30*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	keyGracePeriod, err := getEnvDurationOrDefault("JWT_KEY_GRACE_PERIOD", 24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	keySyncInterval, err := getEnvDurationOrDefault("JWT_KEY_SYNC_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.JWT.Algorithm != "EdDSA" && config.JWT.Algorithm != "RS256" {
+		return nil, errors.New("invalid JWT_SIGNING_ALGORITHM: must be EdDSA or RS256")
+	}
+
+	// Tokens must stay verifiable until they expire, and every instance must
+	// load a new key before it starts signing
+	if keyGracePeriod < accessTTL {
+		return nil, errors.New("invalid JWT_KEY_GRACE_PERIOD: must be at least JWT_ACCESS_TTL")
+	}
+
+	if keyRotationInterval <= keyGracePeriod {
+		return nil, errors.New("invalid JWT_KEY_ROTATION_INTERVAL: must be longer than JWT_KEY_GRACE_PERIOD")
+	}
+
+	if keySyncInterval >= keyGracePeriod {
+		return nil, errors.New("invalid JWT_KEY_SYNC_INTERVAL: must be shorter than JWT_KEY_GRACE_PERIOD")
+	}
+
+	config.JWT.AccessTTL = accessTTL
+	config.JWT.RefreshTTL = refreshTTL
+	config.JWT.DenylistSyncInterval = denylistSyncInterval
+	config.JWT.KeyRotationInterval = keyRotationInterval
+	config.JWT.KeyGracePeriod = keyGracePeriod
+	config.JWT.KeySyncInterval = keySyncInterval
+
+	cursorSecret := config.JWT.Secret
+	if os.Getenv("CURSOR_SECRET") != "" {
+		if cursorSecret, err = getEnvSecret("CURSOR_SECRET"); err != nil {
+			return nil, err
+		}
+	}
+
+	if cursorSecret == encryptionSecret {
+		return nil, errors.New("invalid KEY_ENCRYPTION_SECRET: must differ from CURSOR_SECRET")
+	}
+
+	config.Pagination = PaginationConfig{
+		CursorSecret: cursorSecret,
+	}
+
+	threshold, err := getEnvFloatOrDefault("SEARCH_SIMILARITY_THRESHOLD", 0.3)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestLimit, err := getEnvIntOrDefault("SEARCH_SUGGEST_LIMIT", 10)
+	if err != nil {
+		return nil, err
+	}
+
+	// Thresholds outside the range match either nothing or everything, and
+	// suggest requests above the maximum limit are refused
+	if threshold < 0 || threshold > 1 {
+		return nil, errors.New("invalid SEARCH_SIMILARITY_THRESHOLD: must be between 0 and 1")
+	}
+
+	if suggestLimit < 1 || suggestLimit > domain.MaxSuggestions {
+		return nil, fmt.Errorf("invalid SEARCH_SUGGEST_LIMIT: must be between 1 and %d", domain.MaxSuggestions)
+	}
+
+	config.Search = SearchConfig{
+		SimilarityThreshold: threshold,
+		SuggestLimit:        suggestLimit,
+	}
+
+	minVotes, err := getEnvIntOrDefault("RATING_MIN_VOTES", 10)
+	if err != nil {
+		return nil, err
+	}
+
+	config.Rating = RatingConfig{
+		MinVotes: minVotes,
+	}
+
+	requireApproval, err := getEnvBoolOrDefault("REVIEW_REQUIRE_APPROVAL", true)
+	if err != nil {
+		return nil, err
+	}
+
+	config.Review = ReviewConfig{
+		RequireApproval: requireApproval,
+	}
+
+	trashRetention, err := getEnvDurationOrDefault("MOVIE_TRASH_RETENTION", 30*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	moviePurgeInterval, err := getEnvDurationOrDefault("MOVIE_PURGE_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	if trashRetention < 0 {
+		return nil, errors.New("invalid MOVIE_TRASH_RETENTION: must not be negative")
+	}
+
+	if moviePurgeInterval <= 0 {
+		return nil, errors.New("invalid MOVIE_PURGE_INTERVAL: must be positive")
+	}
+
+	config.Movie = MovieConfig{
+		TrashRetention: trashRetention,
+		PurgeInterval:  moviePurgeInterval,
+	}
+
+	config.Mail = MailConfig{
+		Driver: getEnvOrDefault("MAIL_DRIVER", "log"),
+		From:   getEnvOrDefault("MAIL_FROM", "Movie App <no-reply@localhost>"),
+		Dir:    getEnvOrDefault("MAIL_DIR", "tmp/mail"),
+		AppURL: strings.TrimSuffix(getEnvOrDefault("APP_URL", "http://localhost:3000"), "/"),
+		SMTP: SMTPConfig{
+			Host:     getEnvOrDefault("SMTP_HOST", "localhost"),
+			Port:     getEnvOrDefault("SMTP_PORT", "587"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
 		},
 	}
 
+	passwordResetTTL, err := getEnvDurationOrDefault("PASSWORD_RESET_TTL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	passwordResetResendInterval, err := getEnvDurationOrDefault("PASSWORD_RESET_RESEND_INTERVAL", 5*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	verificationTTL, err := getEnvDurationOrDefault("EMAIL_VERIFICATION_TTL", 48*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	verificationResendInterval, err := getEnvDurationOrDefault("EMAIL_VERIFICATION_RESEND_INTERVAL", 5*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	verificationPolicy := domain.VerificationPolicy(getEnvOrDefault("EMAIL_VERIFICATION_POLICY", "write"))
+	if !verificationPolicy.Valid() {
+		return nil, errors.New("invalid EMAIL_VERIFICATION_POLICY: must be none, write or login")
+	}
+
+	deletionGracePeriod, err := getEnvDurationOrDefault("ACCOUNT_DELETION_GRACE_PERIOD", 30*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	purgeInterval, err := getEnvDurationOrDefault("ACCOUNT_PURGE_INTERVAL", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	if purgeInterval <= 0 {
+		return nil, errors.New("invalid ACCOUNT_PURGE_INTERVAL: must be positive")
+	}
+
+	config.Account = AccountConfig{
+		PasswordResetTTL:            passwordResetTTL,
+		PasswordResetResendInterval: passwordResetResendInterval,
+		VerificationPolicy:          verificationPolicy,
+		VerificationTTL:             verificationTTL,
+		VerificationResendInterval:  verificationResendInterval,
+		MFAIssuer:                   getEnvOrDefault("MFA_ISSUER", "Movie App"),
+		DeletionGracePeriod:         deletionGracePeriod,
+		PurgeInterval:               purgeInterval,
+	}
+
+	maxAttempts, err := getEnvIntOrDefault("LOGIN_MAX_ATTEMPTS", 5)
+	if err != nil {
+		return nil, err
+	}
+
+	maxAttemptsPerIP, err := getEnvIntOrDefault("LOGIN_MAX_ATTEMPTS_PER_IP", 20)
+	if err != nil {
+		return nil, err
+	}
+
+	attemptWindow, err := getEnvDurationOrDefault("LOGIN_ATTEMPT_WINDOW", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	lockoutBase, err := getEnvDurationOrDefault("LOGIN_LOCKOUT_BASE", time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	lockoutMax, err := getEnvDurationOrDefault("LOGIN_LOCKOUT_MAX", time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	throttleStore := getEnvOrDefault("LOGIN_THROTTLE_STORE", "postgres")
+	if throttleStore != "postgres" && throttleStore != "memory" {
+		return nil, errors.New("invalid LOGIN_THROTTLE_STORE: must be postgres or memory")
+	}
+
+	if maxAttempts < 1 || maxAttemptsPerIP < 1 {
+		return nil, errors.New("invalid LOGIN_MAX_ATTEMPTS: must be at least 1, as must LOGIN_MAX_ATTEMPTS_PER_IP")
+	}
+
+	if lockoutBase <= 0 || lockoutMax < lockoutBase {
+		return nil, errors.New("invalid LOGIN_LOCKOUT_MAX: must be at least LOGIN_LOCKOUT_BASE, which must be positive")
+	}
+
+	config.Login = LoginConfig{
+		ThrottleStore:    throttleStore,
+		MaxAttempts:      maxAttempts,
+		MaxAttemptsPerIP: maxAttemptsPerIP,
+		AttemptWindow:    attemptWindow,
+		LockoutBase:      lockoutBase,
+		LockoutMax:       lockoutMax,
+	}
+
+	exportTTL, err := getEnvDurationOrDefault("DATA_EXPORT_TTL", 7*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	exportWorkerInterval, err := getEnvDurationOrDefault("DATA_EXPORT_WORKER_INTERVAL", time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	// Archives must be deleted before purging the account removes the record
+	// of them
+	if exportTTL >= config.Account.DeletionGracePeriod {
+		return nil, errors.New("invalid DATA_EXPORT_TTL: must be shorter than ACCOUNT_DELETION_GRACE_PERIOD")
+	}
+
+	if exportWorkerInterval <= 0 {
+		return nil, errors.New("invalid DATA_EXPORT_WORKER_INTERVAL: must be positive")
+	}
+
+	config.Export = ExportConfig{
+		Dir:            getEnvOrDefault("DATA_EXPORT_DIR", "tmp/exports"),
+		TTL:            exportTTL,
+		WorkerInterval: exportWorkerInterval,
+	}
+
+	oidcAutoRegister, err := getEnvBoolOrDefault("OIDC_AUTO_REGISTER", true)
+	if err != nil {
+		return nil, err
+	}
+
+	oidcStateTTL, err := getEnvDurationOrDefault("OIDC_STATE_TTL", 10*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	oidcIssuer := getEnvOrDefault("OIDC_ISSUER", "")
+	oidcClientID := getEnvOrDefault("OIDC_CLIENT_ID", "")
+
+	if oidcIssuer != "" && oidcClientID == "" {
+		return nil, errors.New("invalid OIDC_CLIENT_ID: must be set along with OIDC_ISSUER")
+	}
+
+	if oidcStateTTL <= 0 {
+		return nil, errors.New("invalid OIDC_STATE_TTL: must be positive")
+	}
+
+	config.OIDC = OIDCConfig{
+		Issuer:       oidcIssuer,
+		ClientID:     oidcClientID,
+		ClientSecret: getEnvOrDefault("OIDC_CLIENT_SECRET", ""),
+		RedirectURL:  getEnvOrDefault("OIDC_REDIRECT_URL", config.Mail.AppURL+"/oidc/callback"),
+		Scopes:       strings.Fields(getEnvOrDefault("OIDC_SCOPES", "email profile")),
+		AutoRegister: oidcAutoRegister,
+		StateTTL:     oidcStateTTL,
+	}
+
 	return config, nil
 }
 
@@ -60,6 +518,30 @@ func (c *DatabaseConfig) GetDSN() string {
 		c.Host, c.Port, c.User, c.Password, c.DBName, c.SSLMode)
 }
 
+// exampleSecrets are the placeholder secrets of the example configurations,
+// which anyone can read and so must never be used.
+var exampleSecrets = []string{
+	"your-secret-key",
+	"your-secret-key-change-this-in-production",
+	"your-cursor-secret-change-this-in-production",
+	"your-key-encryption-secret-change-this-in-production",
+}
+
+// getEnvSecret returns a secret that has no default, refusing the example
+// secrets.
+func getEnvSecret(key string) (string, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return "", fmt.Errorf("invalid %s: must be set", key)
+	}
+
+	if slices.Contains(exampleSecrets, value) {
+		return "", fmt.Errorf("invalid %s: must be changed from the example value", key)
+	}
+
+	return value, nil
+}
+
 func getEnvOrDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -67,3 +549,59 @@ func getEnvOrDefault(key, defaultValue string) string {
 
 	return defaultValue
 }
+
+func getEnvIntOrDefault(key string, defaultValue int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+
+	return parsed, nil
+}
+
+func getEnvFloatOrDefault(key string, defaultValue float64) (float64, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+
+	return parsed, nil
+}
+
+func getEnvBoolOrDefault(key string, defaultValue bool) (bool, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: %w", key, err)
+	}
+
+	return parsed, nil
+}
+
+func getEnvDurationOrDefault(key string, defaultValue time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+
+	return parsed, nil
+}
@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"time"
+)
+
+// APIKeyPrefix starts every API key, so that leaked keys are easy to spot.
+const APIKeyPrefix = "mva_"
+
+// APIKeyShownLength is how many of a key's first characters are stored for
+// the user to tell keys apart.
+const APIKeyShownLength = len(APIKeyPrefix) + 8
+
+// APIKey lets scripts make requests on behalf of a user without logging in.
+// Its scopes are permissions, which it only has while the user's role grants
+// them too. Only a hash of the key is stored, along with its first
+// characters.
+type APIKey struct {
+	ID         uint         `json:"id" gorm:"primaryKey"`
+	UserID     uint         `json:"-" gorm:"not null;index"`
+	User       User         `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Name       string       `json:"name" gorm:"type:varchar(100);not null"`
+	Prefix     string       `json:"prefix" gorm:"type:varchar(16);not null"`
+	KeyHash    string       `json:"-" gorm:"type:char(64);uniqueIndex;not null"`
+	Scopes     []Permission `json:"scopes" gorm:"type:jsonb;serializer:json;not null"`
+	ExpiresAt  *time.Time   `json:"expiresAt,omitempty"` // Never expires when unset
+	LastUsedAt *time.Time   `json:"lastUsedAt,omitempty"`
+	RevokedAt  *time.Time   `json:"-" gorm:"index"`
+	CreatedAt  time.Time    `json:"createdAt"`
+}
+
+// ActiveAt reports whether the key can be used at the time.
+func (k *APIKey) ActiveAt(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || k.ExpiresAt.After(now))
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string       `json:"name" binding:"required,max=100"`
+	Scopes    []Permission `json:"scopes" binding:"required,min=1,dive,required"`
+	ExpiresAt *time.Time   `json:"expiresAt"` // Never expires when unset
+}
+
+// CreatedAPIKey is a new API key along with the key itself, which is not
+// shown again.
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"time"
+)
+
+// AuditAction is a security-relevant event recorded in the audit log.
+type AuditAction string
+
+const (
+	AuditLoginLocked         AuditAction = "login.locked"
+	AuditLoginUnlocked       AuditAction = "login.unlocked"
+	AuditDataExportRequested AuditAction = "data_export.requested"
+	AuditAPIKeyCreated       AuditAction = "api_key.created"
+	AuditAPIKeyRevoked       AuditAction = "api_key.revoked"
+	AuditIdentityLinked      AuditAction = "identity.linked"
+)
+
+// AuditEntry records an action, who took it and whom it concerned.
+type AuditEntry struct {
+	ID        uint        `json:"id" gorm:"primaryKey"`
+	Action    AuditAction `json:"action" gorm:"type:varchar(50);not null;index"`
+	ActorID   *uint       `json:"actorId,omitempty" gorm:"index"` // Unset for actions the system took
+	UserID    *uint       `json:"userId,omitempty" gorm:"index"`  // The user the action concerned
+	IPAddress string      `json:"ipAddress,omitempty" gorm:"type:varchar(45)"`
+	Details   string      `json:"details" gorm:"type:text"`
+	CreatedAt time.Time   `json:"createdAt" gorm:"index"`
+}
@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"time"
+)
+
+type DataExportStatus string
+
+const (
+	DataExportPending    DataExportStatus = "pending"
+	DataExportProcessing DataExportStatus = "processing"
+	DataExportReady      DataExportStatus = "ready" // The archive can be downloaded until it expires
+	DataExportFailed     DataExportStatus = "failed"
+	DataExportExpired    DataExportStatus = "expired" // The archive has been deleted
+)
+
+// DataExport is a user's request for an archive of their personal data,
+// which a background worker builds.
+type DataExport struct {
+	ID          uint             `json:"id" gorm:"primaryKey"`
+	UserID      uint             `json:"-" gorm:"not null;index"`
+	User        User             `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Status      DataExportStatus `json:"status" gorm:"type:varchar(20);not null;index"`
+	FileName    string           `json:"-" gorm:"type:varchar(255)"` // Name of the archive in storage
+	Size        int64            `json:"size,omitempty"`             // Of the archive, in bytes
+	Error       string           `json:"error,omitempty" gorm:"type:text"`
+	StartedAt   *time.Time       `json:"-"`
+	CompletedAt *time.Time       `json:"completedAt,omitempty"`
+	ExpiresAt   *time.Time       `json:"expiresAt,omitempty" gorm:"index"`
+	CreatedAt   time.Time        `json:"createdAt"`
+}
+
+// InProgress reports whether the archive is still being built.
+func (e *DataExport) InProgress() bool {
+	return e.Status == DataExportPending || e.Status == DataExportProcessing
+}
+
+// UserData is everything stored about a user, as included in their data
+// export.
+type UserData struct {
+	User         User
+	Ratings      []UserRating
+	Reviews      []Review
+	ReviewVotes  []ReviewVote
+	Lists        []CuratedList // Owned by the user
+	ListEntries  []ListEntry   // Of the user's lists
+	Watchlist    []WatchlistEntry
+	Diary        []DiaryEntry
+	Sessions     []Session
+	Identities   []UserIdentity  // Linked at identity providers
+	APIKeys      []APIKey        // Revoked ones included
+	AuditEntries []AuditEntry    // Concerning the user or taken by them
+	MovieTitles  map[uint]string // Of the movies the rest refers to
+}
@@ -0,0 +1,23 @@
+package domain
+
+import (
+	"time"
+)
+
+// EmailVerificationToken is a single-use token proving that a user received
+// an email at the address. Only a hash of the token is stored.
+type EmailVerificationToken struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	UserID      uint       `json:"userId" gorm:"not null;index"`
+	User        User       `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Email       string     `json:"email" gorm:"not null"`                     // The address being verified
+	EmailChange bool       `json:"emailChange" gorm:"not null;default:false"` // Replaces the user's email once verified
+	TokenHash   string     `json:"-" gorm:"type:char(64);uniqueIndex;not null"`
+	ExpiresAt   time.Time  `json:"expiresAt" gorm:"not null"`
+	UsedAt      *time.Time `json:"usedAt,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+type Genre struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Slug      string    `json:"slug" gorm:"uniqueIndex;not null"`
+	Name      string    `json:"name" gorm:"not null"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type CreateGenreRequest struct {
+	Name string `json:"name" binding:"required"`
+	Slug string `json:"slug"` // Derived from the name when empty
+}
+
+type UpdateGenreRequest struct {
+	Name *string `json:"name"`
+	Slug *string `json:"slug"`
+}
+
+// Slugify lowercases the value and joins its words with hyphens, so that
+// "Sci Fi" and "sci-fi" both become "sci-fi".
+func Slugify(value string) string {
+	var builder strings.Builder
+
+	pendingHyphen := false
+
+	for _, r := range strings.ToLower(value) {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			pendingHyphen = builder.Len() > 0
+
+			continue
+		}
+
+		if pendingHyphen {
+			builder.WriteByte('-')
+
+			pendingHyphen = false
+		}
+
+		builder.WriteRune(r)
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,159 @@
+package domain
+
+import (
+	"time"
+)
+
+type ListVisibility string
+
+const (
+	ListPublic   ListVisibility = "public"   // Shown in list browsing and accessible by anyone
+	ListUnlisted ListVisibility = "unlisted" // Accessible by anyone who has the slug
+	ListPrivate  ListVisibility = "private"  // Accessible by the owner and collaborators only
+)
+
+// CuratedList is a user's named, ordered list of movies. Version is bumped
+// on every change to its entries so that clients can detect concurrent edits.
+type CuratedList struct {
+	ID            uint               `json:"id" gorm:"primaryKey"`
+	OwnerID       uint               `json:"ownerId" gorm:"not null;index"`
+	Name          string             `json:"name" gorm:"not null"`
+	Slug          string             `json:"slug" gorm:"uniqueIndex;not null"`
+	Description   string             `json:"description" gorm:"type:text"`
+	Visibility    ListVisibility     `json:"visibility" gorm:"type:varchar(20);not null;index"`
+	Version       int                `json:"version" gorm:"not null;default:1"`
+	EntryCount    int64              `json:"entryCount" gorm:"->;-:migration"`
+	Collaborators []ListCollaborator `json:"collaborators,omitempty" gorm:"foreignKey:ListID;constraint:OnDelete:CASCADE"`
+	CreatedAt     time.Time          `json:"createdAt"`
+	UpdatedAt     time.Time          `json:"updatedAt"`
+}
+
+// ListEntry is a movie in a curated list. Positions are 1-based and
+// contiguous within the list.
+type ListEntry struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	ListID    uint      `json:"listId" gorm:"not null;uniqueIndex:idx_list_entries_list_movie;index:idx_list_entries_list_position,priority:1"`
+	MovieID   uint      `json:"movieId" gorm:"not null;uniqueIndex:idx_list_entries_list_movie"`
+	Movie     *Movie    `json:"movie,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	Position  int       `json:"position" gorm:"not null;index:idx_list_entries_list_position,priority:2"`
+	Note      string    `json:"note,omitempty" gorm:"type:text"`
+	AddedByID uint      `json:"addedById" gorm:"not null"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// ListCollaborator is a user other than the owner who may edit a list's entries.
+type ListCollaborator struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	ListID    uint      `json:"listId" gorm:"not null;uniqueIndex:idx_list_collaborators_list_user"`
+	UserID    uint      `json:"userId" gorm:"not null;uniqueIndex:idx_list_collaborators_list_user;index"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// CanEdit reports whether the user owns the list or collaborates on it.
+func (l *CuratedList) CanEdit(userID uint) bool {
+	if l.OwnerID == userID {
+		return true
+	}
+
+	for _, collaborator := range l.Collaborators {
+		if collaborator.UserID == userID {
+			return true
+		}
+	}
+
+	return false
+}
+
+type CreateListRequest struct {
+	Name        string         `json:"name" binding:"required,max=100"`
+	Description string         `json:"description"`
+	Visibility  ListVisibility `json:"visibility" binding:"omitempty,oneof=public unlisted private"` // Defaults to private
+}
+
+type UpdateListRequest struct {
+	Name        *string         `json:"name" binding:"omitempty,max=100"`
+	Description *string         `json:"description"`
+	Visibility  *ListVisibility `json:"visibility" binding:"omitempty,oneof=public unlisted private"`
+}
+
+// AddListEntryRequest adds a movie to a list. As with the other entry
+// requests, Version is optional; when given, the change is rejected if the
+// list has been modified since the client read that version.
+type AddListEntryRequest struct {
+	MovieID  uint   `json:"movieId" binding:"required"`
+	Note     string `json:"note"`
+	Position int    `json:"position" binding:"omitempty,min=1"` // Defaults to the end of the list
+	Version  *int   `json:"version"`
+}
+
+type UpdateListEntryRequest struct {
+	Note    *string `json:"note" binding:"required"`
+	Version *int    `json:"version"`
+}
+
+type MoveListEntryRequest struct {
+	Position int  `json:"position" binding:"required,min=1"`
+	Version  *int `json:"version"`
+}
+
+// ReorderListRequest gives the new order of every entry of a list. Unlike
+// other entry requests, it always requires the version it was based on.
+type ReorderListRequest struct {
+	EntryIDs []uint `json:"entryIds" binding:"required"`
+	Version  int    `json:"version" binding:"required"`
+}
+
+type AddCollaboratorRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ListEntrySortableFields lists the fields list entries can be sorted by:
+// their position in the list, or any movie sort field.
+var ListEntrySortableFields = append([]string{"position"}, MovieSortableFields...)
+
+type ListQuery struct {
+	Page  int `form:"page"`
+	Limit int `form:"limit"`
+}
+
+type ListPage struct {
+	Lists []CuratedList
+	Total int64
+}
+
+type ListsResponse struct {
+	Items []CuratedList `json:"items"`
+	Total int64         `json:"total"`
+	Page  int           `json:"page"`
+	Limit int           `json:"limit"`
+}
+
+type ListEntryPage struct {
+	Entries []ListEntry
+	Total   int64
+	Version int
+}
+
+// ListRef identifies a list by ID or, for shared links, by slug.
+type ListRef struct {
+	ID   uint
+	Slug string
+}
+
+// ListEntryChange is the result of a change to a list's entries, carrying
+// the list's new version for the client's next change.
+type ListEntryChange struct {
+	Entry   *ListEntry `json:"entry,omitempty"`
+	Version int        `json:"version"`
+}
+
+type ListEntriesResponse struct {
+	Items   []ListEntry `json:"items"`
+	Total   int64       `json:"total"`
+	Page    int         `json:"page"`
+	Limit   int         `json:"limit"`
+	Next    string      `json:"next,omitempty"`
+	Prev    string      `json:"prev,omitempty"`
+	Version int         `json:"version"`
+}
@@ -0,0 +1,19 @@
+package domain
+
+import (
+	"time"
+)
+
+// LoginThrottle tracks the failed logins for an account or an IP address,
+// identified by its key.
+type LoginThrottle struct {
+	Key           string     `json:"key" gorm:"type:varchar(320);primaryKey"`
+	Failures      int        `json:"failures" gorm:"not null;default:0"`
+	LastFailureAt time.Time  `json:"lastFailureAt" gorm:"not null"`
+	LockedUntil   *time.Time `json:"lockedUntil,omitempty"`
+}
+
+// LockedAt reports whether logins are locked out at the time.
+func (t LoginThrottle) LockedAt(now time.Time) bool {
+	return t.LockedUntil != nil && t.LockedUntil.After(now)
+}
@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"time"
+)
+
+// TOTPSecret is a user's authenticator app secret. It only guards logins once
+// confirmed with a code, which proves the app was set up.
+type TOTPSecret struct {
+	UserID       uint       `json:"userId" gorm:"primaryKey;autoIncrement:false"`
+	User         User       `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Secret       []byte     `json:"-" gorm:"not null"` // Encrypted
+	ConfirmedAt  *time.Time `json:"confirmedAt,omitempty"`
+	LastUsedStep int64      `json:"-" gorm:"not null;default:0"` // Keeps codes from being replayed
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+}
+
+// RecoveryCode is a one-time code that stands in for an authenticator app
+// code. Only a hash of the code is stored.
+type RecoveryCode struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"userId" gorm:"not null;index"`
+	User      User       `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	CodeHash  string     `json:"-" gorm:"type:char(64);not null"`
+	UsedAt    *time.Time `json:"usedAt,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+type MFAStatus struct {
+	Enabled           bool  `json:"enabled"`
+	RecoveryCodesLeft int64 `json:"recoveryCodesLeft"`
+}
+
+// TOTPEnrollment is what an authenticator app needs to be set up.
+type TOTPEnrollment struct {
+	Secret     string `json:"secret"`     // Base32, for entering by hand
+	OTPAuthURI string `json:"otpauthUri"` // otpauth:// URI the QR code encodes
+	QRCodePNG  []byte `json:"qrCodePng" swaggertype:"string" format:"base64"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"` // Authenticator app code or recovery code
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type MFAVerifyRequest struct {
+	MFAToken string `json:"mfaToken" binding:"required"`
+	Code     string `json:"code" binding:"required"` // Authenticator app code or recovery code
+}
+
+// MFAChallenge is a login waiting for a second factor.
+type MFAChallenge struct {
+	UserID uint
+	Device string
+}
@@ -1,38 +1,269 @@
 package domain
 
 import (
+	"slices"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type Movie struct {
-	ID          uint      `json:"id" gorm:"primaryKey"`
-	Title       string    `json:"title" gorm:"not null"`
-	Director    string    `json:"director" gorm:"not null"`
-	Year        int       `json:"year" gorm:"not null"`
-	Plot        string    `json:"plot" gorm:"type:text"`
-	Genre       string    `json:"genre" gorm:"not null"`
-	Rating      float64   `json:"rating" gorm:"type:decimal(2,1)"`
-	Duration    int       `json:"duration" gorm:"not null"` // Duration in minutes
+	ID        uint            `json:"id" gorm:"primaryKey"`
+	Title     string          `json:"title" gorm:"not null"`
+	Director  string          `json:"director" gorm:"not null"` // Display name of the main director
+	Year      int             `json:"year" gorm:"not null"`
+	Plot      string          `json:"plot" gorm:"type:text"`
+	Genres    []Genre         `json:"genres" gorm:"many2many:movie_genres"`
+	Rating    float64         `json:"rating" gorm:"type:decimal(2,1)"` // Editorial rating
+	Community CommunityRating `json:"communityRating" gorm:"embedded;embeddedPrefix:community_rating_"`
+	Duration  int             `json:"duration" gorm:"not null"` // Duration in minutes
+	Credits   []Credit        `json:"credits,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	// Who added the movie and who last changed it, unset for movies added
+	// before this was recorded and once the user's account is purged
+	CreatedByID *uint     `json:"createdById,omitempty" gorm:"index"`
+	CreatedBy   *User     `json:"-" gorm:"foreignKey:CreatedByID;constraint:OnDelete:SET NULL"`
+	UpdatedByID *uint     `json:"updatedById,omitempty"`
+	UpdatedBy   *User     `json:"-" gorm:"foreignKey:UpdatedByID;constraint:OnDelete:SET NULL"`
 	CreatedAt   time.Time `json:"createdAt"`
 	UpdatedAt   time.Time `json:"updatedAt"`
+	// Deleted movies stay in the trash until they are restored or purged
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// CanModify reports whether the actor may update or delete the movie with
+// the permission: editors and admins may change any movie, and other users
+// the movies they added. API keys need the permission among their scopes
+// either way.
+func (m *Movie) CanModify(actor Actor, permission Permission) bool {
+	if actor.Can(permission) {
+		return true
+	}
+
+	if actor.Scopes != nil && !slices.Contains(actor.Scopes, permission) {
+		return false
+	}
+
+	return m.CreatedByID != nil && *m.CreatedByID == actor.UserID
+}
+
+// TrashedMovie is a deleted movie, which is purged permanently at PurgeAt
+// unless it is restored before.
+type TrashedMovie struct {
+	Movie
+	TrashedAt time.Time `json:"trashedAt"`
+	PurgeAt   time.Time `json:"purgeAt"`
+}
+
+type MovieTrashQuery struct {
+	Page  int `form:"page"`
+	Limit int `form:"limit"`
+}
+
+type MovieTrashPage struct {
+	Movies []TrashedMovie
+	Total  int64
+}
+
+type MovieTrashResponse struct {
+	Items []TrashedMovie `json:"items"`
+	Total int64          `json:"total"`
+	Page  int            `json:"page"`
+	Limit int            `json:"limit"`
 }
 
 type CreateMovieRequest struct {
-	Title       string    `json:"title" binding:"required"`
-	Director    string    `json:"director" binding:"required"`
-	Year        int       `json:"year" binding:"required"`
-	Plot        string    `json:"plot"`
-	Genre       string    `json:"genre" binding:"required"`
-	Rating      float64   `json:"rating" binding:"required"`
-	Duration    int       `json:"duration" binding:"required"`
+	Title      string          `json:"title" binding:"required"`
+	Director   string          `json:"director" binding:"required"`
+	Year       int             `json:"year" binding:"required"`
+	Plot       string          `json:"plot"`
+	GenreIDs   []uint          `json:"genreIds"`   // Genres by ID, and/or
+	GenreSlugs []string        `json:"genreSlugs"` // genres by slug; at least one is required
+	Rating     float64         `json:"rating" binding:"required"`
+	Duration   int             `json:"duration" binding:"required"`
+	Credits    []CreditRequest `json:"credits" binding:"dive"` // Cast and crew besides the director
 }
 
 type UpdateMovieRequest struct {
-	Title       *string    `json:"title"`
-	Director    *string    `json:"director"`
-	Year        *int       `json:"year"`
-	Plot        *string    `json:"plot"`
-	Genre       *string    `json:"genre"`
-	Rating      *float64   `json:"rating"`
-	Duration    *int       `json:"duration"`
+	Title      *string  `json:"title"`
+	Director   *string  `json:"director"`
+	Year       *int     `json:"year"`
+	Plot       *string  `json:"plot"`
+	GenreIDs   []uint   `json:"genreIds"`   // Replaces the genres when set
+	GenreSlugs []string `json:"genreSlugs"` // Replaces the genres when set
+	Rating     *float64 `json:"rating"`
+	Duration   *int     `json:"duration"`
+}
+
+// SortValue returns the value of the given sortable field, used to build
+// keyset pagination cursors.
+func (m *Movie) SortValue(field string) any {
+	switch field {
+	case "title":
+		return m.Title
+	case "director":
+		return m.Director
+	case "year":
+		return m.Year
+	case "rating":
+		return m.Rating
+	case "duration":
+		return m.Duration
+	case "communityRating":
+		return m.Community.Weighted
+	case "ratingCount":
+		return m.Community.Count
+	case "createdAt":
+		return m.CreatedAt
+	default:
+		return m.ID
+	}
+}
+
+const (
+	PaginationOffset = "offset"
+	PaginationCursor = "cursor"
+)
+
+// SortField is a single key of a movie listing sort, e.g. "-rating".
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// MovieQuery describes the paging, filtering and sorting of a movie listing.
+type MovieQuery struct {
+	Page        int      `form:"page"`
+	Limit       int      `form:"limit"`
+	Genre       string   `form:"genre"` // Genre slug
+	Director    string   `form:"director"`
+	YearFrom    *int     `form:"yearFrom"`
+	YearTo      *int     `form:"yearTo"`
+	RatingMin   *float64 `form:"ratingMin"`
+	RatingMax   *float64 `form:"ratingMax"`
+	DurationMin *int     `form:"durationMin"`
+	DurationMax *int     `form:"durationMax"`
+	Sort        string   `form:"sort"`       // Comma separated, "-" prefix for descending
+	Pagination  string   `form:"pagination"` // "offset" (default) or "cursor"
+	Cursor      string   `form:"cursor"`
+	Facets      bool     `form:"facets"` // Also aggregate facet counts over all matches
+
+	SortFields []SortField     `form:"-"`
+	After      *CursorPosition `form:"-"`
+}
+
+// CursorPosition is the last row of the previous page in keyset pagination:
+// its values for each sort field followed by its ID.
+type CursorPosition struct {
+	Values []any
+	ID     uint
+}
+
+// IsCursorMode reports whether the query pages by keyset instead of offset.
+func (q MovieQuery) IsCursorMode() bool {
+	return q.Pagination == PaginationCursor
+}
+
+// Offset returns the number of rows to skip for the requested page.
+func (q MovieQuery) Offset() int {
+	if q.IsCursorMode() {
+		return 0
+	}
+
+	return (q.Page - 1) * q.Limit
+}
+
+// MoviePage is a single page of a movie listing. Total is only counted in
+// offset mode, NextCursor is only set in cursor mode.
+type MoviePage struct {
+	Movies     []Movie
+	Total      int64
+	NextCursor string
+	Facets     *MovieFacets
+}
+
+type MovieListResponse struct {
+	Items      []Movie      `json:"items"`
+	Total      *int64       `json:"total,omitempty"`
+	Page       int          `json:"page,omitempty"`
+	Limit      int          `json:"limit"`
+	Next       string       `json:"next,omitempty"`
+	Prev       string       `json:"prev,omitempty"`
+	NextCursor string       `json:"nextCursor,omitempty"`
+	Facets     *MovieFacets `json:"facets,omitempty"`
+}
+
+// FacetBucket is the number of matching movies sharing one facet value.
+type FacetBucket struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// MovieFacets aggregates the full set of movies matching a query, regardless
+// of the page being returned.
+type MovieFacets struct {
+	Genres    []FacetBucket `json:"genres"`
+	Decades   []FacetBucket `json:"decades"`
+	Ratings   []FacetBucket `json:"ratings"`
+	Durations []FacetBucket `json:"durations"`
+}
+
+// MovieSortableFields lists the fields a movie listing can be sorted by.
+var MovieSortableFields = []string{
+	"title", "director", "year", "rating", "communityRating", "ratingCount", "duration", "createdAt", "id",
+}
+
+// MovieSearchQuery is a full-text search over movies, combined with the
+// regular listing filters.
+type MovieSearchQuery struct {
+	MovieQuery
+	Q string `form:"q" binding:"required"`
+
+	TSQuery string `form:"-"` // Prefix-matching tsquery built from Q
+}
+
+type MovieHighlights struct {
+	Title string `json:"title"`
+	Plot  string `json:"plot,omitempty"`
+}
+
+// MovieSearchHit is a movie matching a search along with its relevance and
+// the matched terms highlighted with <mark> tags. The highlights are
+// HTML-escaped otherwise.
+type MovieSearchHit struct {
+	Movie
+	Rank       float64         `json:"rank"`
+	Highlights MovieHighlights `json:"highlights"`
+}
+
+type MovieSearchPage struct {
+	Hits   []MovieSearchHit
+	Total  int64
+	Facets *MovieFacets
+}
+
+type MovieSearchResponse struct {
+	Items  []MovieSearchHit `json:"items"`
+	Total  int64            `json:"total"`
+	Page   int              `json:"page"`
+	Limit  int              `json:"limit"`
+	Next   string           `json:"next,omitempty"`
+	Prev   string           `json:"prev,omitempty"`
+	Facets *MovieFacets     `json:"facets,omitempty"`
+}
+
+// MaxSuggestions bounds the limit of a MovieSuggestQuery.
+const MaxSuggestions = 50
+
+type MovieSuggestQuery struct {
+	Prefix string `form:"prefix" binding:"required"`
+	Limit  int    `form:"limit"`
+}
+
+// MovieSuggestion is a title matching an autocomplete prefix, either
+// literally or by trigram similarity.
+type MovieSuggestion struct {
+	ID         uint    `json:"id"`
+	Title      string  `json:"title"`
+	Year       int     `json:"year"`
+	Similarity float64 `json:"similarity"`
 }
@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"time"
+)
+
+// UserIdentity links a user to their account at an OpenID Connect identity
+// provider, which is identified by its issuer and the subject it assigned.
+type UserIdentity struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	UserID      uint      `json:"-" gorm:"not null;index"`
+	User        User      `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Issuer      string    `json:"issuer" gorm:"type:varchar(255);not null;uniqueIndex:idx_user_identities_subject"`
+	Subject     string    `json:"subject" gorm:"type:varchar(255);not null;uniqueIndex:idx_user_identities_subject"`
+	Email       string    `json:"email" gorm:"not null"` // As of the last login
+	LastLoginAt time.Time `json:"lastLoginAt" gorm:"not null"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// OIDCLoginState is a login started at the identity provider that has yet
+// to come back. Only a hash of the state is stored; the nonce and PKCE code
+// verifier are needed as they are to finish the login.
+type OIDCLoginState struct {
+	ID           uint      `gorm:"primaryKey"`
+	StateHash    string    `gorm:"type:char(64);uniqueIndex;not null"`
+	Nonce        string    `gorm:"type:varchar(64);not null"`
+	CodeVerifier string    `gorm:"type:varchar(128);not null"`
+	ExpiresAt    time.Time `gorm:"not null;index"`
+	CreatedAt    time.Time
+}
+
+// TableName keeps gorm from splitting the ID out of OIDC.
+func (OIDCLoginState) TableName() string {
+	return "oidc_login_states"
+}
+
+type OIDCCallbackRequest struct {
+	Code   string `json:"code" binding:"required"`
+	State  string `json:"state" binding:"required"`
+	Device string `json:"device" binding:"max=100"` // Optional name to tell the session apart
+}
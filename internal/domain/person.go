@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"time"
+)
+
+// DateLayout is the format of calendar dates such as birth dates.
+const DateLayout = "2006-01-02"
+
+const (
+	DepartmentDirecting = "Directing"
+	JobDirector         = "Director"
+)
+
+// CreditDepartments lists the departments a credit can belong to.
+var CreditDepartments = []string{
+	DepartmentDirecting, "Writing", "Acting", "Production", "Camera",
+	"Editing", "Sound", "Art", "Costume & Make-Up", "Visual Effects", "Crew",
+}
+
+type Person struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	Name      string     `json:"name" gorm:"not null;index"`
+	BirthDate *time.Time `json:"birthDate,omitempty" gorm:"type:date"`
+	Bio       string     `json:"bio" gorm:"type:text"`
+	PhotoURL  string     `json:"photoUrl"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+// Credit is a person's part in a movie: a crew job within a department, or
+// for the Acting department, the character played.
+type Credit struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	MovieID      uint      `json:"movieId" gorm:"not null;index"`
+	Movie        *Movie    `json:"movie,omitempty"`
+	PersonID     uint      `json:"personId" gorm:"not null;index"`
+	Person       *Person   `json:"person,omitempty" gorm:"constraint:OnDelete:RESTRICT"`
+	Department   string    `json:"department" gorm:"not null"`
+	Job          string    `json:"job"`
+	Character    string    `json:"character,omitempty"`
+	BillingOrder int       `json:"billingOrder" gorm:"not null;default:0"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+type CreatePersonRequest struct {
+	Name      string `json:"name" binding:"required"`
+	BirthDate string `json:"birthDate" binding:"omitempty,datetime=2006-01-02"`
+	Bio       string `json:"bio"`
+	PhotoURL  string `json:"photoUrl" binding:"omitempty,url"`
+}
+
+type UpdatePersonRequest struct {
+	Name      *string `json:"name"`
+	BirthDate *string `json:"birthDate" binding:"omitempty,datetime=2006-01-02"`
+	Bio       *string `json:"bio"`
+	PhotoURL  *string `json:"photoUrl" binding:"omitempty,url"`
+}
+
+type CreditRequest struct {
+	PersonID     uint   `json:"personId" binding:"required"`
+	Department   string `json:"department" binding:"required"`
+	Job          string `json:"job"`
+	Character    string `json:"character"`
+	BillingOrder int    `json:"billingOrder"`
+}
+
+type UpdateCreditRequest struct {
+	Department   *string `json:"department"`
+	Job          *string `json:"job"`
+	Character    *string `json:"character"`
+	BillingOrder *int    `json:"billingOrder"`
+}
+
+type PersonQuery struct {
+	Page  int    `form:"page"`
+	Limit int    `form:"limit"`
+	Name  string `form:"name"` // Partial, case-insensitive match
+}
+
+type PersonPage struct {
+	People []Person
+	Total  int64
+}
+
+type PersonListResponse struct {
+	Items []Person `json:"items"`
+	Total int64    `json:"total"`
+	Page  int      `json:"page"`
+	Limit int      `json:"limit"`
+}
+
+// Filmography is every credit of a person, each with its movie.
+type Filmography struct {
+	Person  Person   `json:"person"`
+	Credits []Credit `json:"credits"`
+}
@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"time"
+)
+
+// UserRating is a user's own score for a movie; each user rates a movie once.
+type UserRating struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"userId" gorm:"not null;uniqueIndex:idx_user_ratings_user_movie"`
+	MovieID   uint      `json:"movieId" gorm:"not null;uniqueIndex:idx_user_ratings_user_movie;index"`
+	Score     float64   `json:"score" gorm:"type:decimal(3,1);not null"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// CommunityRating is the aggregate of a movie's user ratings, kept on the
+// movie row so that listings can show and sort by it without joins.
+type CommunityRating struct {
+	Average  float64 `json:"average" gorm:"type:decimal(4,2);not null;default:0"`
+	Count    int64   `json:"count" gorm:"not null;default:0"`
+	Weighted float64 `json:"weighted" gorm:"type:decimal(4,2);not null;default:0"` // Bayesian average
+}
+
+// CommunityRatingColumns are the movie columns maintained by rating writes,
+// which movie updates must leave alone.
+var CommunityRatingColumns = []string{
+	"community_rating_average",
+	"community_rating_count",
+	"community_rating_weighted",
+}
+
+type RateMovieRequest struct {
+	Score float64 `json:"score" binding:"required"`
+}
+
+// RatingBucket counts the ratings rounded to a whole score.
+type RatingBucket struct {
+	Score int   `json:"score"`
+	Count int64 `json:"count"`
+}
+
+type RatingSummary struct {
+	MovieID uint `json:"movieId"`
+	CommunityRating
+	Histogram []RatingBucket `json:"histogram"`
+}
+
+type MyRatingResponse struct {
+	Rating  *UserRating    `json:"rating"`
+	Summary *RatingSummary `json:"summary"`
+}
@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"time"
+)
+
+type ReviewStatus string
+
+const (
+	ReviewPending   ReviewStatus = "pending"
+	ReviewPublished ReviewStatus = "published"
+	ReviewRejected  ReviewStatus = "rejected"
+)
+
+const (
+	ReviewSortHelpful = "helpful"
+	ReviewSortRecent  = "recent"
+)
+
+// Review is a user's written review of a movie; each user reviews a movie once.
+type Review struct {
+	ID             uint         `json:"id" gorm:"primaryKey"`
+	MovieID        uint         `json:"movieId" gorm:"not null;uniqueIndex:idx_reviews_movie_user;index:idx_reviews_movie_status,priority:1"`
+	UserID         uint         `json:"userId" gorm:"not null;uniqueIndex:idx_reviews_movie_user"`
+	Title          string       `json:"title" gorm:"type:varchar(200)"`
+	Body           string       `json:"body" gorm:"type:text;not null"`
+	Spoiler        bool         `json:"spoiler" gorm:"not null;default:false"`
+	Status         ReviewStatus `json:"status" gorm:"type:varchar(20);not null;index:idx_reviews_movie_status,priority:2"`
+	ModerationNote string       `json:"moderationNote,omitempty"`
+	HelpfulCount   int64        `json:"helpfulCount" gorm:"not null;default:0"`
+	UnhelpfulCount int64        `json:"unhelpfulCount" gorm:"not null;default:0"`
+	AuthorRating   *float64     `json:"authorRating" gorm:"->;-:migration"` // The author's own rating of the movie, if any
+	CreatedAt      time.Time    `json:"createdAt"`
+	UpdatedAt      time.Time    `json:"updatedAt"`
+}
+
+// ReviewVote records whether a user found a review helpful.
+type ReviewVote struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	ReviewID  uint      `json:"reviewId" gorm:"not null;uniqueIndex:idx_review_votes_review_user"`
+	UserID    uint      `json:"userId" gorm:"not null;uniqueIndex:idx_review_votes_review_user"`
+	Helpful   bool      `json:"helpful" gorm:"not null"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type CreateReviewRequest struct {
+	Title   string `json:"title" binding:"max=200"`
+	Body    string `json:"body" binding:"required,min=10"`
+	Spoiler bool   `json:"spoiler"`
+}
+
+type UpdateReviewRequest struct {
+	Title   *string `json:"title" binding:"omitempty,max=200"`
+	Body    *string `json:"body" binding:"omitempty,min=10"`
+	Spoiler *bool   `json:"spoiler"`
+}
+
+type VoteReviewRequest struct {
+	Helpful *bool `json:"helpful" binding:"required"`
+}
+
+type ModerateReviewRequest struct {
+	Status ReviewStatus `json:"status" binding:"required,oneof=pending published rejected"`
+	Note   string       `json:"note"`
+}
+
+type ReviewQuery struct {
+	Page   int          `form:"page"`
+	Limit  int          `form:"limit"`
+	Sort   string       `form:"sort"`   // helpful (default) or recent
+	Status ReviewStatus `form:"status"` // Moderation listings only
+}
+
+type ReviewPage struct {
+	Reviews []Review
+	Total   int64
+}
+
+type ReviewListResponse struct {
+	Items []Review `json:"items"`
+	Total int64    `json:"total"`
+	Page  int      `json:"page"`
+	Limit int      `json:"limit"`
+}
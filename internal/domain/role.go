@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"slices"
+)
+
+type Role string
+
+const (
+	RoleAdmin  Role = "admin"
+	RoleEditor Role = "editor"
+	RoleViewer Role = "viewer"
+)
+
+// Roles lists the assignable roles, most privileged first.
+var Roles = []Role{RoleAdmin, RoleEditor, RoleViewer}
+
+// Permission is an action that routes and services can require of a user.
+type Permission string
+
+const (
+	PermRead            Permission = "read"  // Read the catalogue and the user's own data
+	PermWrite           Permission = "write" // Change the user's own ratings, reviews, lists, watchlist and diary
+	PermMoviesWrite     Permission = "movies:write"
+	PermMoviesDelete    Permission = "movies:delete"
+	PermMoviesTrash     Permission = "movies:trash" // Restore and purge deleted movies
+	PermPeopleWrite     Permission = "people:write"
+	PermGenresWrite     Permission = "genres:write"
+	PermReviewsModerate Permission = "reviews:moderate"
+	PermListsModerate   Permission = "lists:moderate"
+	PermUsersAdmin      Permission = "users:admin"
+)
+
+// rolePermissions grants each role its permissions. Viewers can only use
+// the catalogue and manage their own ratings, reviews and lists. Genres are
+// shared by every movie, so only admins manage them.
+var rolePermissions = map[Role][]Permission{
+	RoleAdmin: {
+		PermRead, PermWrite, PermMoviesWrite, PermMoviesDelete, PermMoviesTrash, PermPeopleWrite,
+		PermGenresWrite, PermReviewsModerate, PermListsModerate, PermUsersAdmin,
+	},
+	RoleEditor: {
+		PermRead, PermWrite, PermMoviesWrite, PermMoviesDelete, PermPeopleWrite, PermReviewsModerate,
+	},
+	RoleViewer: {PermRead, PermWrite},
+}
+
+// Valid reports whether the role is one of Roles.
+func (r Role) Valid() bool {
+	return slices.Contains(Roles, r)
+}
+
+// Can reports whether the role grants the permission.
+func (r Role) Can(permission Permission) bool {
+	return slices.Contains(rolePermissions[r], permission)
+}
+
+// Permissions returns the permissions the role grants.
+func (r Role) Permissions() []Permission {
+	return slices.Clone(rolePermissions[r])
+}
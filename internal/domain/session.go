@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"time"
+)
+
+// Session is one login of a user on a device. It lives as long as its chain
+// of refresh tokens, which share the session's family ID, and revoking it
+// rejects the access tokens issued for it.
+type Session struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"-" gorm:"not null;index"`
+	FamilyID   string     `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"`
+	Device     string     `json:"device" gorm:"type:varchar(100)"`
+	UserAgent  string     `json:"userAgent" gorm:"type:varchar(512)"`
+	IPAddress  string     `json:"ipAddress" gorm:"type:varchar(45)"`
+	LastSeenAt time.Time  `json:"lastSeenAt" gorm:"not null"` // Last login or token refresh
+	ExpiresAt  time.Time  `json:"expiresAt" gorm:"not null"`
+	RevokedAt  *time.Time `json:"-" gorm:"index"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	Current    bool       `json:"current" gorm:"-"` // Whether the request was made with this session
+}
+
+// ClientInfo describes the client a login or token refresh comes from.
+type ClientInfo struct {
+	Device    string
+	UserAgent string
+	IPAddress string
+}
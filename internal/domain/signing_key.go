@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"time"
+)
+
+// SigningKey is a key pair that signs access tokens. Keys are published
+// before they start signing and kept for verification for a grace period
+// after their successor takes over, so that rotating them never invalidates a
+// token or surprises a service that caches the key set.
+type SigningKey struct {
+	ID          string    `json:"kid" gorm:"type:varchar(32);primaryKey"`
+	Algorithm   string    `json:"alg" gorm:"type:varchar(10);not null"`
+	PrivateKey  []byte    `json:"-" gorm:"not null"` // Encrypted PKCS #8 DER
+	PublicKey   []byte    `json:"-" gorm:"not null"` // PKIX DER
+	ActivatesAt time.Time `json:"activatesAt" gorm:"not null;uniqueIndex"`
+	RetiresAt   time.Time `json:"retiresAt" gorm:"not null"`       // Stops signing
+	ExpiresAt   time.Time `json:"expiresAt" gorm:"not null;index"` // Stops verifying
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// JWK is a public key in JSON Web Key format.
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Crv string `json:"crv,omitempty"` // OKP keys
+	X   string `json:"x,omitempty"`   // OKP keys
+	N   string `json:"n,omitempty"`   // RSA keys
+	E   string `json:"e,omitempty"`   // RSA keys
+}
+
+// JWKS is the set of public keys that verify access tokens.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
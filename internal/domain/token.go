@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"time"
+)
+
+// RefreshToken is a server-side record of an issued refresh token. Only a
+// hash of the token is stored. Every refresh rotates the token: the used one
+// is marked and replaced by a new one in the same family, so that presenting
+// a used token again reveals that it was stolen.
+type RefreshToken struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	UserID       uint       `json:"userId" gorm:"not null;index"`
+	FamilyID     string     `json:"familyId" gorm:"type:varchar(64);not null;index"`
+	TokenHash    string     `json:"-" gorm:"type:char(64);uniqueIndex;not null"`
+	ExpiresAt    time.Time  `json:"expiresAt" gorm:"not null"`
+	UsedAt       *time.Time `json:"usedAt,omitempty"`
+	RevokedAt    *time.Time `json:"revokedAt,omitempty"`
+	ReplacedByID *uint      `json:"replacedById,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
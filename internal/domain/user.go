@@ -1,15 +1,56 @@
 package domain
 
 import (
+	"slices"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type User struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	Email     string    `json:"email" gorm:"unique;not null"`
-	Password  string    `json:"-" gorm:"not null"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	ID              uint            `json:"id" gorm:"primaryKey"`
+	Email           string          `json:"email" gorm:"unique;not null"`
+	EmailVerifiedAt *time.Time      `json:"emailVerifiedAt,omitempty"`
+	Password        string          `json:"-" gorm:"not null"`
+	Role            Role            `json:"role" gorm:"type:varchar(20);not null;default:viewer;index"`
+	DisplayName     string          `json:"displayName" gorm:"size:100;not null;default:''"`
+	AvatarURL       string          `json:"avatarUrl" gorm:"size:2048;not null;default:''"`
+	Preferences     UserPreferences `json:"preferences" gorm:"type:jsonb;serializer:json;not null;default:'{}'"`
+	CreatedAt       time.Time       `json:"createdAt"`
+	UpdatedAt       time.Time       `json:"updatedAt"`
+	// Set when the user deletes their account, which is purged along with
+	// their data after a grace period
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// UserPreferences are settings the web app keeps for the user.
+type UserPreferences struct {
+	Language     string `json:"language,omitempty" binding:"omitempty,bcp47_language_tag"`
+	Theme        string `json:"theme,omitempty" binding:"omitempty,oneof=light dark system"`
+	HideSpoilers bool   `json:"hideSpoilers"`
+}
+
+// EmailVerified reports whether the user has confirmed they own their email.
+func (u *User) EmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// VerificationPolicy is what users cannot do until they verify their email.
+type VerificationPolicy string
+
+const (
+	VerifyForNothing VerificationPolicy = "none"
+	VerifyForWrite   VerificationPolicy = "write" // Unverified users can only read
+	VerifyForLogin   VerificationPolicy = "login" // Unverified users cannot log in
+)
+
+func (p VerificationPolicy) Valid() bool {
+	switch p {
+	case VerifyForNothing, VerifyForWrite, VerifyForLogin:
+		return true
+	}
+
+	return false
 }
 
 type RegisterRequest struct {
@@ -20,9 +61,80 @@ type RegisterRequest struct {
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
+	Device   string `json:"device" binding:"max=100"` // Optional name to tell the session apart
 }
 
 type LoginResponse struct {
-	Token string `json:"token"`
-	User  *User  `json:"user"`
+	Token          string     `json:"token,omitempty"` // Short-lived access token
+	TokenExpiresAt *time.Time `json:"tokenExpiresAt,omitempty"`
+	RefreshToken   string     `json:"refreshToken,omitempty"`
+	User           *User      `json:"user,omitempty"`
+
+	// Set instead of the tokens when the user has two-factor authentication
+	// enabled. The MFA token is exchanged for them along with a code.
+	MFARequired       bool       `json:"mfaRequired,omitempty"`
+	MFAToken          string     `json:"mfaToken,omitempty"`
+	MFATokenExpiresAt *time.Time `json:"mfaTokenExpiresAt,omitempty"`
+}
+
+// Actor is the authenticated user on whose behalf a request is made.
+type Actor struct {
+	UserID uint
+	Role   Role
+	Scopes []Permission // Of the API key the request was made with, if any
+}
+
+// Can reports whether the actor's role grants the permission, and the API
+// key the request was made with, if any, is scoped to it.
+func (a Actor) Can(permission Permission) bool {
+	if a.Scopes != nil && !slices.Contains(a.Scopes, permission) {
+		return false
+	}
+
+	return a.Role.Can(permission)
+}
+
+// UpdateProfileRequest changes the fields that are set. An empty avatar URL
+// removes the avatar, and preferences are replaced as a whole.
+type UpdateProfileRequest struct {
+	DisplayName *string          `json:"displayName" binding:"omitempty,max=100"`
+	AvatarURL   *string          `json:"avatarUrl" binding:"omitempty,max=2048,http_url|len=0"`
+	Preferences *UserPreferences `json:"preferences"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" binding:"required"`
+	NewPassword     string `json:"newPassword" binding:"required,min=6"`
+}
+
+type ChangeEmailRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+type UpdateRoleRequest struct {
+	Role Role `json:"role" binding:"required,oneof=admin editor viewer"`
+}
+
+type UserQuery struct {
+	Page  int    `form:"page"`
+	Limit int    `form:"limit"`
+	Role  Role   `form:"role"`
+	Email string `form:"email"` // Partial, case-insensitive match
+}
+
+type UserPage struct {
+	Users []User
+	Total int64
+}
+
+type UserListResponse struct {
+	Items []User `json:"items"`
+	Total int64  `json:"total"`
+	Page  int    `json:"page"`
+	Limit int    `json:"limit"`
 }
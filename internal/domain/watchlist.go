@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"time"
+)
+
+// WatchlistEntry is a movie a user plans to watch.
+type WatchlistEntry struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"userId" gorm:"not null;uniqueIndex:idx_watchlist_entries_user_movie"`
+	MovieID   uint      `json:"movieId" gorm:"not null;uniqueIndex:idx_watchlist_entries_user_movie"`
+	Movie     *Movie    `json:"movie,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// DiaryEntry records one viewing of a movie by a user.
+type DiaryEntry struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"userId" gorm:"not null;index:idx_diary_entries_user_watched,priority:1"`
+	MovieID   uint      `json:"movieId" gorm:"not null;index"`
+	Movie     *Movie    `json:"movie,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	WatchedOn time.Time `json:"watchedOn" gorm:"type:date;not null;index:idx_diary_entries_user_watched,priority:2"`
+	Rewatch   bool      `json:"rewatch" gorm:"not null;default:false"`
+	Rating    *float64  `json:"rating,omitempty" gorm:"type:decimal(3,1)"`
+	Note      string    `json:"note,omitempty" gorm:"type:text"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type AddToWatchlistRequest struct {
+	MovieID uint `json:"movieId" binding:"required"`
+}
+
+type CreateDiaryEntryRequest struct {
+	MovieID   uint     `json:"movieId" binding:"required"`
+	WatchedOn string   `json:"watchedOn" binding:"omitempty,datetime=2006-01-02"` // Defaults to today
+	Rewatch   *bool    `json:"rewatch"`                                           // Defaults to whether the movie was logged before
+	Rating    *float64 `json:"rating"`
+	Note      string   `json:"note"`
+}
+
+type UpdateDiaryEntryRequest struct {
+	WatchedOn *string  `json:"watchedOn" binding:"omitempty,datetime=2006-01-02"`
+	Rewatch   *bool    `json:"rewatch"`
+	Rating    *float64 `json:"rating"`
+	Note      *string  `json:"note"`
+}
+
+type WatchlistQuery struct {
+	Page  int `form:"page"`
+	Limit int `form:"limit"`
+}
+
+type DiaryQuery struct {
+	Page    int    `form:"page"`
+	Limit   int    `form:"limit"`
+	MovieID uint   `form:"movieId"`
+	From    string `form:"from" binding:"omitempty,datetime=2006-01-02"`
+	To      string `form:"to" binding:"omitempty,datetime=2006-01-02"`
+}
+
+type WatchlistPage struct {
+	Entries []WatchlistEntry
+	Total   int64
+}
+
+type WatchlistResponse struct {
+	Items []WatchlistEntry `json:"items"`
+	Total int64            `json:"total"`
+	Page  int              `json:"page"`
+	Limit int              `json:"limit"`
+}
+
+type DiaryPage struct {
+	Entries []DiaryEntry
+	Total   int64
+}
+
+type DiaryResponse struct {
+	Items []DiaryEntry `json:"items"`
+	Total int64        `json:"total"`
+	Page  int          `json:"page"`
+	Limit int          `json:"limit"`
+}
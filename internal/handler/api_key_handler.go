@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"errors"
+	"movie_app/internal/domain"
+	"movie_app/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyHandler struct {
+	service service.APIKeyService
+}
+
+func NewAPIKeyHandler(svc service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{service: svc}
+}
+
+// CreateAPIKey godoc
+// @Summary Create an API key
+// @Description Create a long-lived key for scripts to make requests as the current user, by sending it in
+// @Description the X-API-Key header. Scopes are permissions granted by the user's role: "read" allows
+// @Description GET requests, "write" allows the others, and the rest allow what they do for the role.
+// @Description The key is only shown in this response.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param apiKey body domain.CreateAPIKeyRequest true "API key"
+// @Success 201 {object} domain.CreatedAPIKey
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/me/api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(ctx *gin.Context) {
+	actor, ok := currentActor(ctx)
+	if !ok {
+		return
+	}
+
+	var req domain.CreateAPIKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	apiKey, err := h.service.CreateAPIKey(ctx.Request.Context(), actor, req, clientInfo(ctx))
+	if err != nil {
+		writeAPIKeyError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, apiKey)
+}
+
+// GetAPIKeys godoc
+// @Summary List my API keys
+// @Description List the current user's API keys that are neither revoked nor expired, newest first
+// @Tags api-keys
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} domain.APIKey
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/me/api-keys [get]
+func (h *APIKeyHandler) GetAPIKeys(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	keys, err := h.service.ListAPIKeys(ctx.Request.Context(), userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	if keys == nil {
+		keys = []domain.APIKey{}
+	}
+
+	ctx.JSON(http.StatusOK, keys)
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke an API key
+// @Description Revoke one of the current user's API keys. Requests made with it fail immediately.
+// @Tags api-keys
+// @Security ApiKeyAuth
+// @Param id path int true "API key ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/me/api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+
+		return
+	}
+
+	if err := h.service.RevokeAPIKey(ctx.Request.Context(), userID, uint(id), clientInfo(ctx)); err != nil {
+		writeAPIKeyError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+func writeAPIKeyError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrAPIKeyNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidScope):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidExpiry):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
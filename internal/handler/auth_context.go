@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"movie_app/internal/domain"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// currentUserID returns the authenticated user's ID, writing a 401 response
+// when the request is not authenticated.
+func currentUserID(ctx *gin.Context) (uint, bool) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+
+		return 0, false
+	}
+
+	return userID.(uint), true
+}
+
+// currentActor returns the authenticated user making the request, writing a
+// 401 response when the request is not authenticated.
+func currentActor(ctx *gin.Context) (domain.Actor, bool) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return domain.Actor{}, false
+	}
+
+	role, _ := ctx.Get("role")
+	scopes, _ := ctx.Get("scopes")
+	actorRole, _ := role.(domain.Role)
+	keyScopes, _ := scopes.([]domain.Permission)
+
+	return domain.Actor{UserID: userID, Role: actorRole, Scopes: keyScopes}, true
+}
+
+// currentSessionID returns the session the request was authenticated with,
+// writing a 401 response when the request is not authenticated.
+func currentSessionID(ctx *gin.Context) (uint, bool) {
+	sessionID, exists := ctx.Get("session_id")
+	if !exists {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+
+		return 0, false
+	}
+
+	return sessionID.(uint), true
+}
+
+// clientInfo describes the client making the request.
+func clientInfo(ctx *gin.Context) domain.ClientInfo {
+	return domain.ClientInfo{
+		UserAgent: truncate(ctx.Request.UserAgent(), 512),
+		IPAddress: ctx.ClientIP(),
+	}
+}
+
+func truncate(value string, length int) string {
+	if len(value) <= length {
+		return value
+	}
+
+	return strings.ToValidUTF8(value[:length], "")
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"movie_app/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DataExportHandler struct {
+	service service.DataExportService
+}
+
+func NewDataExportHandler(svc service.DataExportService) *DataExportHandler {
+	return &DataExportHandler{service: svc}
+}
+
+// RequestExport godoc
+// @Summary Request a data export
+// @Description Start building a ZIP archive of everything stored about the current user: their profile,
+// @Description ratings, reviews, votes, lists, watchlist, diary, sessions and audit events. Poll the
+// @Description export until it is ready, then download it before it expires.
+// @Tags users
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 202 {object} domain.DataExport
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/me/export [post]
+func (h *DataExportHandler) RequestExport(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	export, err := h.service.RequestExport(ctx.Request.Context(), userID, clientInfo(ctx))
+	if err != nil {
+		writeDataExportError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, export)
+}
+
+// GetExport godoc
+// @Summary Get my data export
+// @Description Get the status of the current user's most recently requested data export
+// @Tags users
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} domain.DataExport
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/me/export [get]
+func (h *DataExportHandler) GetExport(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	export, err := h.service.GetExport(ctx.Request.Context(), userID)
+	if err != nil {
+		writeDataExportError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, export)
+}
+
+// DownloadExport godoc
+// @Summary Download my data export
+// @Description Download the ZIP archive of the current user's most recently requested data export
+// @Tags users
+// @Produce application/zip
+// @Security ApiKeyAuth
+// @Success 200 {file} file
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 410 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/me/export/download [get]
+func (h *DataExportHandler) DownloadExport(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	export, file, err := h.service.OpenExport(ctx.Request.Context(), userID)
+	if err != nil {
+		writeDataExportError(ctx, err)
+
+		return
+	}
+	defer file.Close()
+
+	fileName := fmt.Sprintf("movie-app-data-%s.zip", export.CompletedAt.Format("2006-01-02"))
+
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	ctx.Header("Cache-Control", "private, no-store")
+	http.ServeContent(ctx.Writer, ctx.Request, fileName, *export.CompletedAt, file)
+}
+
+func writeDataExportError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrExportNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrExportInProgress), errors.Is(err, service.ErrExportNotReady),
+		errors.Is(err, service.ErrExportFailed):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrExportExpired):
+		ctx.JSON(http.StatusGone, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
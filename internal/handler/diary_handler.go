@@ -0,0 +1,241 @@
+package handler
+
+import (
+	"errors"
+	"movie_app/internal/domain"
+	"movie_app/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DiaryHandler struct {
+	service service.DiaryService
+}
+
+func NewDiaryHandler(svc service.DiaryService) *DiaryHandler {
+	return &DiaryHandler{service: svc}
+}
+
+// @Summary Get my diary
+// @Description Get a paginated list of the current user's watched movies, most recent viewing first
+// @Tags diary
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param page query int false "Page number (default 1, max 10000)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param movieId query int false "Only viewings of this movie"
+// @Param from query string false "Watched on or after (YYYY-MM-DD)"
+// @Param to query string false "Watched on or before (YYYY-MM-DD)"
+// @Success 200 {object} domain.DiaryResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/me/diary [get]
+func (h *DiaryHandler) GetDiary(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var query domain.DiaryQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	page, err := h.service.List(ctx.Request.Context(), userID, query)
+	if err != nil {
+		writeDiaryError(ctx, err)
+
+		return
+	}
+
+	response := domain.DiaryResponse{
+		Items: page.Entries,
+		Total: page.Total,
+		Page:  max(query.Page, 1),
+		Limit: query.Limit,
+	}
+
+	if response.Items == nil {
+		response.Items = []domain.DiaryEntry{}
+	}
+
+	if response.Limit == 0 {
+		response.Limit = service.DefaultPageSize
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// @Summary Log a watched movie
+// @Description Record a viewing in the current user's diary and take the movie off their watchlist
+// @Tags diary
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param entry body domain.CreateDiaryEntryRequest true "Viewing"
+// @Success 201 {object} domain.DiaryEntry
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/me/diary [post]
+func (h *DiaryHandler) LogDiaryEntry(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req domain.CreateDiaryEntryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	entry, err := h.service.Log(ctx.Request.Context(), userID, req)
+	if err != nil {
+		writeDiaryError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, entry)
+}
+
+// @Summary Get a diary entry
+// @Description Get one of the current user's diary entries
+// @Tags diary
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param id path int true "Diary entry ID"
+// @Success 200 {object} domain.DiaryEntry
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /users/me/diary/{id} [get]
+func (h *DiaryHandler) GetDiaryEntry(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+
+		return
+	}
+
+	entry, err := h.service.GetByID(ctx.Request.Context(), userID, uint(id))
+	if err != nil {
+		writeDiaryError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, entry)
+}
+
+// @Summary Update a diary entry
+// @Description Update one of the current user's diary entries
+// @Tags diary
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param id path int true "Diary entry ID"
+// @Param entry body domain.UpdateDiaryEntryRequest true "Fields to update"
+// @Success 200 {object} domain.DiaryEntry
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/me/diary/{id} [put]
+func (h *DiaryHandler) UpdateDiaryEntry(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+
+		return
+	}
+
+	var req domain.UpdateDiaryEntryRequest
+	if bindErr := ctx.ShouldBindJSON(&req); bindErr != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": bindErr.Error()})
+
+		return
+	}
+
+	entry, err := h.service.Update(ctx.Request.Context(), userID, uint(id), req)
+	if err != nil {
+		writeDiaryError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, entry)
+}
+
+// @Summary Delete a diary entry
+// @Description Delete one of the current user's diary entries
+// @Tags diary
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param id path int true "Diary entry ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/me/diary/{id} [delete]
+func (h *DiaryHandler) DeleteDiaryEntry(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+
+		return
+	}
+
+	if err := h.service.Delete(ctx.Request.Context(), userID, uint(id)); err != nil {
+		writeDiaryError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+func writeDiaryError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrMovieNotFound), errors.Is(err, service.ErrDiaryEntryNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidWatchDate), errors.Is(err, service.ErrInvalidRating),
+		errors.Is(err, service.ErrInvalidDateRange), errors.Is(err, service.ErrInvalidPage),
+		errors.Is(err, service.ErrInvalidLimit):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
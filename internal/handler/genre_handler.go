@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"errors"
+	"movie_app/internal/domain"
+	"movie_app/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type GenreHandler struct {
+	service service.GenreService
+}
+
+func NewGenreHandler(svc service.GenreService) *GenreHandler {
+	return &GenreHandler{service: svc}
+}
+
+// @Summary Create a genre
+// @Description Create a new genre (requires genres:write)
+// @Tags genres
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param genre body domain.CreateGenreRequest true "Genre object"
+// @Success 201 {object} domain.Genre
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /genres [post]
+func (h *GenreHandler) CreateGenre(ctx *gin.Context) {
+	var req domain.CreateGenreRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	genre, err := h.service.Create(ctx.Request.Context(), req)
+	if err != nil {
+		writeGenreError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, genre)
+}
+
+// @Summary Get all genres
+// @Description Get the list of all genres
+// @Tags genres
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Success 200 {array} domain.Genre
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /genres [get]
+func (h *GenreHandler) GetAllGenres(ctx *gin.Context) {
+	genres, err := h.service.GetAll(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	if genres == nil {
+		genres = []domain.Genre{}
+	}
+
+	ctx.JSON(http.StatusOK, genres)
+}
+
+// @Summary Get a genre by ID
+// @Description Get a genre's details by its ID
+// @Tags genres
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param id path int true "Genre ID"
+// @Success 200 {object} domain.Genre
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /genres/{id} [get]
+func (h *GenreHandler) GetGenre(ctx *gin.Context) {
+	genreID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+
+		return
+	}
+
+	genre, err := h.service.GetByID(ctx.Request.Context(), uint(genreID))
+	if err != nil {
+		writeGenreError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, genre)
+}
+
+// @Summary Update a genre
+// @Description Update an existing genre's name or slug (requires genres:write)
+// @Tags genres
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param id path int true "Genre ID"
+// @Param genre body domain.UpdateGenreRequest true "Genre object"
+// @Success 200 {object} domain.Genre
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /genres/{id} [put]
+func (h *GenreHandler) UpdateGenre(ctx *gin.Context) {
+	genreID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+
+		return
+	}
+
+	var req domain.UpdateGenreRequest
+	if bindErr := ctx.ShouldBindJSON(&req); bindErr != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": bindErr.Error()})
+
+		return
+	}
+
+	genre, err := h.service.Update(ctx.Request.Context(), uint(genreID), req)
+	if err != nil {
+		writeGenreError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, genre)
+}
+
+// @Summary Delete a genre
+// @Description Delete a genre that is no longer assigned to any movie (requires genres:write)
+// @Tags genres
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param id path int true "Genre ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /genres/{id} [delete]
+func (h *GenreHandler) DeleteGenre(ctx *gin.Context) {
+	genreID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+
+		return
+	}
+
+	if err := h.service.Delete(ctx.Request.Context(), uint(genreID)); err != nil {
+		writeGenreError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+func writeGenreError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrGenreNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "genre not found"})
+	case errors.Is(err, service.ErrGenreExists), errors.Is(err, service.ErrGenreInUse):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidSlug):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
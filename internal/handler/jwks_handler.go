@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"movie_app/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// jwksMaxAge is how long clients may cache the key set. New keys are
+// published a grace period before they sign, which is far longer.
+const jwksMaxAge = "public, max-age=300"
+
+type JWKSHandler struct {
+	keys *service.KeySet
+}
+
+func NewJWKSHandler(keys *service.KeySet) *JWKSHandler {
+	return &JWKSHandler{keys: keys}
+}
+
+// GetJWKS serves the public keys that verify access tokens as a JSON Web Key
+// Set, for other services. It lives outside the API base path, so it is not
+// part of the Swagger documentation.
+func (h *JWKSHandler) GetJWKS(ctx *gin.Context) {
+	ctx.Header("Cache-Control", jwksMaxAge)
+	ctx.JSON(http.StatusOK, h.keys.JWKS())
+}
@@ -0,0 +1,761 @@
+package handler
+
+import (
+	"errors"
+	"movie_app/internal/domain"
+	"movie_app/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ListHandler struct {
+	service service.ListService
+}
+
+func NewListHandler(svc service.ListService) *ListHandler {
+	return &ListHandler{service: svc}
+}
+
+// @Summary Create a list
+// @Description Create a named list of movies owned by the current user
+// @Tags lists
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param list body domain.CreateListRequest true "List"
+// @Success 201 {object} domain.CuratedList
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /lists [post]
+func (h *ListHandler) CreateList(ctx *gin.Context) {
+	actor, ok := currentActor(ctx)
+	if !ok {
+		return
+	}
+
+	var req domain.CreateListRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	list, err := h.service.Create(ctx.Request.Context(), actor, req)
+	if err != nil {
+		writeListError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, list)
+}
+
+// @Summary Get public lists
+// @Description Get a paginated list of public lists, most recently updated first
+// @Tags lists
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param page query int false "Page number (default 1, max 10000)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {object} domain.ListsResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /lists [get]
+func (h *ListHandler) GetPublicLists(ctx *gin.Context) {
+	var query domain.ListQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	page, err := h.service.ListPublic(ctx.Request.Context(), query)
+	if err != nil {
+		writeListError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newListsResponse(query, page))
+}
+
+// @Summary Get my lists
+// @Description Get a paginated list of the lists the current user owns or collaborates on
+// @Tags lists
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param page query int false "Page number (default 1, max 10000)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {object} domain.ListsResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/me/lists [get]
+func (h *ListHandler) GetMyLists(ctx *gin.Context) {
+	actor, ok := currentActor(ctx)
+	if !ok {
+		return
+	}
+
+	var query domain.ListQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	page, err := h.service.ListMine(ctx.Request.Context(), actor, query)
+	if err != nil {
+		writeListError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newListsResponse(query, page))
+}
+
+// @Summary Get a list
+// @Description Get a public list, or a private or unlisted one the current user owns or collaborates on
+// @Tags lists
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param id path int true "List ID"
+// @Success 200 {object} domain.CuratedList
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /lists/{id} [get]
+func (h *ListHandler) GetList(ctx *gin.Context) {
+	h.getList(ctx, false)
+}
+
+// @Summary Get a shared list
+// @Description Get a public or unlisted list by its shareable slug
+// @Tags lists
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param slug path string true "List slug"
+// @Success 200 {object} domain.CuratedList
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /lists/by-slug/{slug} [get]
+func (h *ListHandler) GetListBySlug(ctx *gin.Context) {
+	h.getList(ctx, true)
+}
+
+func (h *ListHandler) getList(ctx *gin.Context, bySlug bool) {
+	actor, ok := currentActor(ctx)
+	if !ok {
+		return
+	}
+
+	ref, ok := listRef(ctx, bySlug)
+	if !ok {
+		return
+	}
+
+	list, err := h.service.Get(ctx.Request.Context(), actor, ref)
+	if err != nil {
+		writeListError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, list)
+}
+
+// @Summary Update a list
+// @Description Update the name, description or visibility of a list the current user owns
+// @Tags lists
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param id path int true "List ID"
+// @Param list body domain.UpdateListRequest true "Fields to update"
+// @Success 200 {object} domain.CuratedList
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /lists/{id} [put]
+func (h *ListHandler) UpdateList(ctx *gin.Context) {
+	actor, ok := currentActor(ctx)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+
+		return
+	}
+
+	var req domain.UpdateListRequest
+	if bindErr := ctx.ShouldBindJSON(&req); bindErr != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": bindErr.Error()})
+
+		return
+	}
+
+	list, err := h.service.Update(ctx.Request.Context(), actor, uint(id), req)
+	if err != nil {
+		writeListError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, list)
+}
+
+// @Summary Delete a list
+// @Description Delete a list the current user owns, with all its entries
+// @Tags lists
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param id path int true "List ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /lists/{id} [delete]
+func (h *ListHandler) DeleteList(ctx *gin.Context) {
+	actor, ok := currentActor(ctx)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+
+		return
+	}
+
+	if err := h.service.Delete(ctx.Request.Context(), actor, uint(id)); err != nil {
+		writeListError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+// @Summary Get a list's entries
+// @Description Get a paginated list of a list's movies, filtered and sorted like movie listings
+// @Tags lists
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param id path int true "List ID"
+// @Param page query int false "Page number (default 1, max 10000)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param genre query string false "Genre"
+// @Param director query string false "Director name (partial match)"
+// @Param yearFrom query int false "Minimum release year"
+// @Param yearTo query int false "Maximum release year"
+// @Param ratingMin query number false "Minimum rating"
+// @Param ratingMax query number false "Maximum rating"
+// @Param durationMin query int false "Minimum duration in minutes"
+// @Param durationMax query int false "Maximum duration in minutes"
+// @Param sort query string false "Comma separated sort fields, prefix with - for descending (default position)"
+// @Success 200 {object} domain.ListEntriesResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /lists/{id}/entries [get]
+func (h *ListHandler) GetListEntries(ctx *gin.Context) {
+	h.getEntries(ctx, false)
+}
+
+// @Summary Get a shared list's entries
+// @Description Get a paginated list of the movies of a public or unlisted list by its shareable slug
+// @Tags lists
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param slug path string true "List slug"
+// @Param page query int false "Page number (default 1, max 10000)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param genre query string false "Genre"
+// @Param director query string false "Director name (partial match)"
+// @Param yearFrom query int false "Minimum release year"
+// @Param yearTo query int false "Maximum release year"
+// @Param ratingMin query number false "Minimum rating"
+// @Param ratingMax query number false "Maximum rating"
+// @Param durationMin query int false "Minimum duration in minutes"
+// @Param durationMax query int false "Maximum duration in minutes"
+// @Param sort query string false "Comma separated sort fields, prefix with - for descending (default position)"
+// @Success 200 {object} domain.ListEntriesResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /lists/by-slug/{slug}/entries [get]
+func (h *ListHandler) GetListEntriesBySlug(ctx *gin.Context) {
+	h.getEntries(ctx, true)
+}
+
+func (h *ListHandler) getEntries(ctx *gin.Context, bySlug bool) {
+	actor, ok := currentActor(ctx)
+	if !ok {
+		return
+	}
+
+	ref, ok := listRef(ctx, bySlug)
+	if !ok {
+		return
+	}
+
+	var query domain.MovieQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	page, err := h.service.Entries(ctx.Request.Context(), actor, ref, query)
+	if err != nil {
+		writeListError(ctx, err)
+
+		return
+	}
+
+	response := domain.ListEntriesResponse{
+		Items:   page.Entries,
+		Total:   page.Total,
+		Version: page.Version,
+	}
+
+	if response.Items == nil {
+		response.Items = []domain.ListEntry{}
+	}
+
+	response.Page, response.Limit, response.Next, response.Prev = pageLinks(ctx, query, page.Total)
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// @Summary Add a movie to a list
+// @Description Add a movie to a list the current user owns or collaborates on, at the end unless a position is given
+// @Tags lists
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param id path int true "List ID"
+// @Param entry body domain.AddListEntryRequest true "Entry"
+// @Success 201 {object} domain.ListEntryChange
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /lists/{id}/entries [post]
+func (h *ListHandler) AddListEntry(ctx *gin.Context) {
+	actor, ok := currentActor(ctx)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+
+		return
+	}
+
+	var req domain.AddListEntryRequest
+	if bindErr := ctx.ShouldBindJSON(&req); bindErr != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": bindErr.Error()})
+
+		return
+	}
+
+	change, err := h.service.AddEntry(ctx.Request.Context(), actor, uint(id), req)
+	if err != nil {
+		writeListError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, change)
+}
+
+// @Summary Update a list entry
+// @Description Change the note of an entry of a list the current user owns or collaborates on
+// @Tags lists
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param id path int true "List ID"
+// @Param entryId path int true "Entry ID"
+// @Param entry body domain.UpdateListEntryRequest true "Entry"
+// @Success 200 {object} domain.ListEntryChange
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /lists/{id}/entries/{entryId} [put]
+func (h *ListHandler) UpdateListEntry(ctx *gin.Context) {
+	actor, ok := currentActor(ctx)
+	if !ok {
+		return
+	}
+
+	listID, entryID, ok := listEntryIDs(ctx)
+	if !ok {
+		return
+	}
+
+	var req domain.UpdateListEntryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	change, err := h.service.UpdateEntry(ctx.Request.Context(), actor, listID, entryID, req)
+	if err != nil {
+		writeListError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, change)
+}
+
+// @Summary Remove a movie from a list
+// @Description Remove an entry from a list the current user owns or collaborates on
+// @Tags lists
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param id path int true "List ID"
+// @Param entryId path int true "Entry ID"
+// @Param version query int false "Reject the change if the list's version differs"
+// @Success 200 {object} domain.ListEntryChange
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /lists/{id}/entries/{entryId} [delete]
+func (h *ListHandler) RemoveListEntry(ctx *gin.Context) {
+	actor, ok := currentActor(ctx)
+	if !ok {
+		return
+	}
+
+	listID, entryID, ok := listEntryIDs(ctx)
+	if !ok {
+		return
+	}
+
+	var version *int
+
+	if value := ctx.Query("version"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid version"})
+
+			return
+		}
+
+		version = &parsed
+	}
+
+	change, err := h.service.RemoveEntry(ctx.Request.Context(), actor, listID, entryID, version)
+	if err != nil {
+		writeListError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, change)
+}
+
+// @Summary Move a list entry
+// @Description Move an entry of a list the current user owns or collaborates on to another position
+// @Tags lists
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param id path int true "List ID"
+// @Param entryId path int true "Entry ID"
+// @Param move body domain.MoveListEntryRequest true "New position"
+// @Success 200 {object} domain.ListEntryChange
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /lists/{id}/entries/{entryId}/move [post]
+func (h *ListHandler) MoveListEntry(ctx *gin.Context) {
+	actor, ok := currentActor(ctx)
+	if !ok {
+		return
+	}
+
+	listID, entryID, ok := listEntryIDs(ctx)
+	if !ok {
+		return
+	}
+
+	var req domain.MoveListEntryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	change, err := h.service.MoveEntry(ctx.Request.Context(), actor, listID, entryID, req)
+	if err != nil {
+		writeListError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, change)
+}
+
+// @Summary Reorder a list
+// @Description Set the order of every entry of a list the current user owns or collaborates on
+// @Tags lists
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param id path int true "List ID"
+// @Param order body domain.ReorderListRequest true "Entry IDs in their new order"
+// @Success 200 {object} domain.ListEntryChange
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /lists/{id}/order [put]
+func (h *ListHandler) ReorderList(ctx *gin.Context) {
+	actor, ok := currentActor(ctx)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+
+		return
+	}
+
+	var req domain.ReorderListRequest
+	if bindErr := ctx.ShouldBindJSON(&req); bindErr != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": bindErr.Error()})
+
+		return
+	}
+
+	change, err := h.service.Reorder(ctx.Request.Context(), actor, uint(id), req)
+	if err != nil {
+		writeListError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, change)
+}
+
+// @Summary Add a collaborator to a list
+// @Description Let another user edit the entries of a list the current user owns
+// @Tags lists
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param id path int true "List ID"
+// @Param collaborator body domain.AddCollaboratorRequest true "Collaborator's email"
+// @Success 200 {object} domain.CuratedList
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /lists/{id}/collaborators [post]
+func (h *ListHandler) AddCollaborator(ctx *gin.Context) {
+	actor, ok := currentActor(ctx)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+
+		return
+	}
+
+	var req domain.AddCollaboratorRequest
+	if bindErr := ctx.ShouldBindJSON(&req); bindErr != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": bindErr.Error()})
+
+		return
+	}
+
+	list, err := h.service.AddCollaborator(ctx.Request.Context(), actor, uint(id), req.Email)
+	if err != nil {
+		writeListError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, list)
+}
+
+// @Summary Remove a collaborator from a list
+// @Description Remove a collaborator from a list the current user owns, or leave a list as a collaborator
+// @Tags lists
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param id path int true "List ID"
+// @Param userId path int true "Collaborator's user ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /lists/{id}/collaborators/{userId} [delete]
+func (h *ListHandler) RemoveCollaborator(ctx *gin.Context) {
+	actor, ok := currentActor(ctx)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+
+		return
+	}
+
+	userID, err := strconv.ParseUint(ctx.Param("userId"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+
+		return
+	}
+
+	if err := h.service.RemoveCollaborator(ctx.Request.Context(), actor, uint(id), uint(userID)); err != nil {
+		writeListError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+// listRef reads the list's ID or slug from the path, writing a 400 response
+// when the ID is invalid.
+func listRef(ctx *gin.Context, bySlug bool) (domain.ListRef, bool) {
+	if bySlug {
+		return domain.ListRef{Slug: ctx.Param("slug")}, true
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+
+		return domain.ListRef{}, false
+	}
+
+	return domain.ListRef{ID: uint(id)}, true
+}
+
+// listEntryIDs reads the list and entry IDs from the path, writing a 400
+// response when either is invalid.
+func listEntryIDs(ctx *gin.Context) (listID, entryID uint, ok bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+
+		return 0, 0, false
+	}
+
+	entry, err := strconv.ParseUint(ctx.Param("entryId"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid entry id"})
+
+		return 0, 0, false
+	}
+
+	return uint(id), uint(entry), true
+}
+
+func newListsResponse(query domain.ListQuery, page *domain.ListPage) domain.ListsResponse {
+	response := domain.ListsResponse{
+		Items: page.Lists,
+		Total: page.Total,
+		Page:  max(query.Page, 1),
+		Limit: query.Limit,
+	}
+
+	if response.Items == nil {
+		response.Items = []domain.CuratedList{}
+	}
+
+	if response.Limit == 0 {
+		response.Limit = service.DefaultPageSize
+	}
+
+	return response
+}
+
+func writeListError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrListNotFound), errors.Is(err, service.ErrListEntryNotFound),
+		errors.Is(err, service.ErrMovieNotFound), errors.Is(err, service.ErrUserNotFound),
+		errors.Is(err, service.ErrCollaboratorNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrListForbidden):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrListConflict), errors.Is(err, service.ErrMovieAlreadyInList):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidReorder), errors.Is(err, service.ErrOwnerCollaborator), isQueryError(err):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"errors"
+	"movie_app/internal/domain"
+	"movie_app/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MFAHandler struct {
+	service service.MFAService
+}
+
+func NewMFAHandler(svc service.MFAService) *MFAHandler {
+	return &MFAHandler{service: svc}
+}
+
+// GetMFAStatus godoc
+// @Summary Get my two-factor authentication status
+// @Description Whether two-factor authentication is enabled, and how many unused recovery codes are left
+// @Tags mfa
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} domain.MFAStatus
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/me/mfa [get]
+func (h *MFAHandler) GetMFAStatus(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	status, err := h.service.GetStatus(ctx.Request.Context(), userID)
+	if err != nil {
+		writeMFAError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, status)
+}
+
+// EnrollTOTP godoc
+// @Summary Set up an authenticator app
+// @Description Generate a TOTP secret, returned as an otpauth URI and a base64 PNG QR code. Two-factor
+// @Description authentication is enabled once a code from the app is confirmed. Enrolling again before
+// @Description confirming replaces the secret.
+// @Tags mfa
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} domain.TOTPEnrollment
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/me/mfa/totp [post]
+func (h *MFAHandler) EnrollTOTP(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	enrollment, err := h.service.EnrollTOTP(ctx.Request.Context(), userID)
+	if err != nil {
+		writeMFAError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, enrollment)
+}
+
+// ConfirmTOTP godoc
+// @Summary Confirm an authenticator app
+// @Description Enable two-factor authentication with a code from the newly set up authenticator app. The
+// @Description response holds the recovery codes, which are not shown again.
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body domain.MFACodeRequest true "Authenticator app code"
+// @Success 200 {object} domain.RecoveryCodesResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/me/mfa/totp/confirm [post]
+func (h *MFAHandler) ConfirmTOTP(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req domain.MFACodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	codes, err := h.service.ConfirmTOTP(ctx.Request.Context(), userID, req.Code, clientInfo(ctx))
+	if err != nil {
+		writeMFAError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, codes)
+}
+
+// DisableMFA godoc
+// @Summary Disable two-factor authentication
+// @Description Remove the authenticator app and recovery codes, given a code from either
+// @Tags mfa
+// @Accept json
+// @Security ApiKeyAuth
+// @Param request body domain.MFACodeRequest true "Authenticator app code or recovery code"
+// @Success 204 "No Content"
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/me/mfa/disable [post]
+func (h *MFAHandler) DisableMFA(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req domain.MFACodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	if err := h.service.Disable(ctx.Request.Context(), userID, req.Code, clientInfo(ctx)); err != nil {
+		writeMFAError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Regenerate recovery codes
+// @Description Replace the recovery codes, given an authenticator app code or a recovery code. The
+// @Description response holds the new codes, which are not shown again.
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body domain.MFACodeRequest true "Authenticator app code or recovery code"
+// @Success 200 {object} domain.RecoveryCodesResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/me/mfa/recovery-codes [post]
+func (h *MFAHandler) RegenerateRecoveryCodes(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req domain.MFACodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	codes, err := h.service.RegenerateRecoveryCodes(ctx.Request.Context(), userID, req.Code, clientInfo(ctx))
+	if err != nil {
+		writeMFAError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, codes)
+}
+
+func writeMFAError(ctx *gin.Context, err error) {
+	if writeLoginLocked(ctx, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, service.ErrInvalidMFACode):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrMFAAlreadyEnabled),
+		errors.Is(err, service.ErrMFANotEnabled),
+		errors.Is(err, service.ErrMFANotEnrolled):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"movie_app/internal/domain"
 	"movie_app/internal/service"
 	"net/http"
@@ -18,18 +19,25 @@ func NewMovieHandler(svc service.MovieService) *MovieHandler {
 }
 
 // @Summary Create a new movie
-// @Description Create a new movie in the system
+// @Description Create a new movie in the system (requires movies:write)
 // @Tags movies
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
+// @Security PersonalAPIKey
 // @Param movie body domain.CreateMovieRequest true "Movie object"
 // @Success 201 {object} domain.Movie
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /movies [post]
 func (h *MovieHandler) CreateMovie(ctx *gin.Context) {
+	actor, ok := currentActor(ctx)
+	if !ok {
+		return
+	}
+
 	var req domain.CreateMovieRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -38,17 +46,33 @@ func (h *MovieHandler) CreateMovie(ctx *gin.Context) {
 	}
 
 	movie := &domain.Movie{
-		Title:       req.Title,
-		Director:    req.Director,
-		Year:        req.Year,
-		Plot:        req.Plot,
-		Genre:       req.Genre,
-		Rating:      req.Rating,
-		Duration:    req.Duration,
+		Title:    req.Title,
+		Director: req.Director,
+		Year:     req.Year,
+		Plot:     req.Plot,
+		Genres:   genreRefs(req.GenreIDs, req.GenreSlugs),
+		Rating:   req.Rating,
+		Duration: req.Duration,
 	}
 
-	result, err := h.service.Create(ctx.Request.Context(), movie)
+	for _, credit := range req.Credits {
+		movie.Credits = append(movie.Credits, domain.Credit{
+			PersonID:     credit.PersonID,
+			Department:   credit.Department,
+			Job:          credit.Job,
+			Character:    credit.Character,
+			BillingOrder: credit.BillingOrder,
+		})
+	}
+
+	result, err := h.service.Create(ctx.Request.Context(), actor, movie)
 	if err != nil {
+		if isValidationError(err) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+			return
+		}
+
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 
 		return
@@ -63,6 +87,7 @@ func (h *MovieHandler) CreateMovie(ctx *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
+// @Security PersonalAPIKey
 // @Param id path int true "Movie ID"
 // @Success 200 {object} domain.Movie
 // @Failure 400 {object} map[string]string
@@ -88,40 +113,176 @@ func (h *MovieHandler) GetMovie(ctx *gin.Context) {
 }
 
 // @Summary Get all movies
-// @Description Get a list of all movies
+// @Description Get a paginated list of movies, optionally filtered and sorted
 // @Tags movies
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
-// @Success 200 {array} domain.Movie
+// @Security PersonalAPIKey
+// @Param page query int false "Page number (default 1, max 10000)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param genre query string false "Genre"
+// @Param director query string false "Director name (partial match)"
+// @Param yearFrom query int false "Minimum release year"
+// @Param yearTo query int false "Maximum release year"
+// @Param ratingMin query number false "Minimum rating"
+// @Param ratingMax query number false "Maximum rating"
+// @Param durationMin query int false "Minimum duration in minutes"
+// @Param durationMax query int false "Maximum duration in minutes"
+// @Param sort query string false "Comma separated sort fields, prefix with - for descending (e.g. -rating,title)"
+// @Param pagination query string false "Pagination mode: offset (default) or cursor"
+// @Param cursor query string false "Opaque cursor from a previous response's nextCursor"
+// @Param facets query bool false "Include facet counts over all matching movies"
+// @Success 200 {object} domain.MovieListResponse
+// @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /movies [get]
 func (h *MovieHandler) GetAllMovies(ctx *gin.Context) {
-	movies, err := h.service.GetAll(ctx.Request.Context())
+	var query domain.MovieQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	page, err := h.service.List(ctx.Request.Context(), query)
+	if err != nil {
+		if isQueryError(err) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newMovieListResponse(ctx, query, page))
+}
+
+// @Summary Search movies
+// @Description Full-text search over title, director and plot, ranked by relevance with highlighted matches
+// @Tags movies
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param q query string true "Search terms, each matched as a prefix"
+// @Param page query int false "Page number (default 1, max 10000)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param genre query string false "Genre"
+// @Param director query string false "Director name (partial match)"
+// @Param yearFrom query int false "Minimum release year"
+// @Param yearTo query int false "Maximum release year"
+// @Param ratingMin query number false "Minimum rating"
+// @Param ratingMax query number false "Maximum rating"
+// @Param durationMin query int false "Minimum duration in minutes"
+// @Param durationMax query int false "Maximum duration in minutes"
+// @Param sort query string false "Sort fields instead of relevance (e.g. -year)"
+// @Param facets query bool false "Include facet counts over all matching movies"
+// @Success 200 {object} domain.MovieSearchResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /movies/search [get]
+func (h *MovieHandler) SearchMovies(ctx *gin.Context) {
+	var query domain.MovieSearchQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	page, err := h.service.Search(ctx.Request.Context(), query)
+	if err != nil {
+		if isQueryError(err) || errors.Is(err, service.ErrEmptySearch) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	response := domain.MovieSearchResponse{
+		Items:  page.Hits,
+		Total:  page.Total,
+		Facets: page.Facets,
+	}
+
+	response.Page, response.Limit, response.Next, response.Prev = pageLinks(ctx, query.MovieQuery, page.Total)
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// @Summary Suggest movie titles
+// @Description Autocomplete titles by prefix, tolerating typos through trigram similarity
+// @Tags movies
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param prefix query string true "Title prefix typed so far"
+// @Param limit query int false "Number of suggestions (default 10, max 50)"
+// @Success 200 {array} domain.MovieSuggestion
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /movies/suggest [get]
+func (h *MovieHandler) SuggestMovies(ctx *gin.Context) {
+	var query domain.MovieSuggestQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	suggestions, err := h.service.Suggest(ctx.Request.Context(), query)
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidSuggest) || errors.Is(err, service.ErrEmptySearch) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+			return
+		}
+
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 
 		return
 	}
 
-	ctx.JSON(http.StatusOK, movies)
+	if suggestions == nil {
+		suggestions = []domain.MovieSuggestion{}
+	}
+
+	ctx.JSON(http.StatusOK, suggestions)
 }
 
 // @Summary Update a movie
-// @Description Update an existing movie's details
+// @Description Update an existing movie's details (requires movies:write, unless the current user added the movie)
 // @Tags movies
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
+// @Security PersonalAPIKey
 // @Param id path int true "Movie ID"
 // @Param movie body domain.UpdateMovieRequest true "Movie object"
 // @Success 200 {object} domain.Movie
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
 // @Router /movies/{id} [put]
 func (h *MovieHandler) UpdateMovie(ctx *gin.Context) {
+	actor, ok := currentActor(ctx)
+	if !ok {
+		return
+	}
+
 	movieID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
@@ -160,8 +321,8 @@ func (h *MovieHandler) UpdateMovie(ctx *gin.Context) {
 		movie.Plot = *req.Plot
 	}
 
-	if req.Genre != nil {
-		movie.Genre = *req.Genre
+	if req.GenreIDs != nil || req.GenreSlugs != nil {
+		movie.Genres = genreRefs(req.GenreIDs, req.GenreSlugs)
 	}
 
 	if req.Rating != nil {
@@ -172,8 +333,20 @@ func (h *MovieHandler) UpdateMovie(ctx *gin.Context) {
 		movie.Duration = *req.Duration
 	}
 
-	result, err := h.service.Update(ctx.Request.Context(), movie)
+	result, err := h.service.Update(ctx.Request.Context(), actor, movie)
 	if err != nil {
+		if isValidationError(err) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+			return
+		}
+
+		if errors.Is(err, service.ErrMovieForbidden) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+
+			return
+		}
+
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 
 		return
@@ -183,18 +356,26 @@ func (h *MovieHandler) UpdateMovie(ctx *gin.Context) {
 }
 
 // @Summary Delete a movie
-// @Description Delete a movie by its ID
+// @Description Move a movie to the trash, from which it is purged after the retention period (requires movies:delete, unless the current user added the movie)
 // @Tags movies
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
+// @Security PersonalAPIKey
 // @Param id path int true "Movie ID"
 // @Success 204 "No Content"
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /movies/{id} [delete]
 func (h *MovieHandler) DeleteMovie(ctx *gin.Context) {
+	actor, ok := currentActor(ctx)
+	if !ok {
+		return
+	}
+
 	movieID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
@@ -202,11 +383,240 @@ func (h *MovieHandler) DeleteMovie(ctx *gin.Context) {
 		return
 	}
 
-	if err := h.service.Delete(ctx.Request.Context(), uint(movieID)); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.service.Delete(ctx.Request.Context(), actor, uint(movieID)); err != nil {
+		switch {
+		case errors.Is(err, service.ErrMovieNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrMovieForbidden):
+			ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 
 		return
 	}
 
 	ctx.JSON(http.StatusNoContent, nil)
 }
+
+// @Summary Get deleted movies
+// @Description Get a paginated list of the movies in the trash, most recently deleted first, with when each will be purged (requires movies:trash)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param page query int false "Page number (default 1, max 10000)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {object} domain.MovieTrashResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/movies/trash [get]
+func (h *MovieHandler) GetTrashedMovies(ctx *gin.Context) {
+	var query domain.MovieTrashQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	page, err := h.service.ListTrash(ctx.Request.Context(), query)
+	if err != nil {
+		if isQueryError(err) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+
+		return
+	}
+
+	response := domain.MovieTrashResponse{
+		Items: page.Movies,
+		Total: page.Total,
+		Page:  max(query.Page, 1),
+		Limit: query.Limit,
+	}
+
+	if response.Limit == 0 {
+		response.Limit = service.DefaultPageSize
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// @Summary Restore a deleted movie
+// @Description Take a movie out of the trash along with its ratings and reviews (requires movies:trash)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param id path int true "Movie ID"
+// @Success 200 {object} domain.Movie
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/movies/trash/{id}/restore [post]
+func (h *MovieHandler) RestoreMovie(ctx *gin.Context) {
+	movieID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+
+		return
+	}
+
+	movie, err := h.service.Restore(ctx.Request.Context(), uint(movieID))
+	if err != nil {
+		writeTrashError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, movie)
+}
+
+// @Summary Purge a deleted movie
+// @Description Permanently delete a movie in the trash along with its credits, ratings and reviews, and every user's watchlist, diary and list entries for it, without waiting for the retention period (requires movies:trash)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param id path int true "Movie ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/movies/trash/{id} [delete]
+func (h *MovieHandler) PurgeMovie(ctx *gin.Context) {
+	movieID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+
+		return
+	}
+
+	if err := h.service.Purge(ctx.Request.Context(), uint(movieID)); err != nil {
+		writeTrashError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+// writeTrashError reports movies missing from the trash as not found.
+func writeTrashError(ctx *gin.Context, err error) {
+	if errors.Is(err, service.ErrMovieNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "movie not found in trash"})
+
+		return
+	}
+
+	ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// genreRefs references genres by ID or slug for the service to resolve.
+func genreRefs(ids []uint, slugs []string) []domain.Genre {
+	genres := make([]domain.Genre, 0, len(ids)+len(slugs))
+
+	for _, id := range ids {
+		genres = append(genres, domain.Genre{ID: id})
+	}
+
+	for _, slug := range slugs {
+		genres = append(genres, domain.Genre{Slug: slug})
+	}
+
+	return genres
+}
+
+func isValidationError(err error) bool {
+	return errors.Is(err, service.ErrInvalidYear) ||
+		errors.Is(err, service.ErrInvalidDuration) ||
+		errors.Is(err, service.ErrInvalidRating) ||
+		errors.Is(err, service.ErrGenreRequired) ||
+		errors.Is(err, service.ErrUnknownGenre) ||
+		errors.Is(err, service.ErrInvalidDepartment) ||
+		errors.Is(err, service.ErrPersonNotFound)
+}
+
+func isQueryError(err error) bool {
+	return errors.Is(err, service.ErrInvalidPage) ||
+		errors.Is(err, service.ErrInvalidLimit) ||
+		errors.Is(err, service.ErrInvalidPagination) ||
+		errors.Is(err, service.ErrInvalidCursor) ||
+		errors.Is(err, service.ErrCursorMismatch) ||
+		errors.Is(err, service.ErrInvalidSort) ||
+		errors.Is(err, service.ErrInvalidRange)
+}
+
+func newMovieListResponse(ctx *gin.Context, query domain.MovieQuery, page *domain.MoviePage) domain.MovieListResponse {
+	response := domain.MovieListResponse{
+		Items:  page.Movies,
+		Limit:  query.Limit,
+		Facets: page.Facets,
+	}
+
+	if response.Items == nil {
+		response.Items = []domain.Movie{}
+	}
+
+	if query.Cursor != "" || query.Pagination == domain.PaginationCursor {
+		if response.Limit == 0 {
+			response.Limit = service.DefaultPageSize
+		}
+
+		response.NextCursor = page.NextCursor
+		if page.NextCursor != "" {
+			response.Next = queryLink(ctx, "cursor", page.NextCursor)
+		}
+
+		return response
+	}
+
+	response.Total = &page.Total
+	response.Page, response.Limit, response.Next, response.Prev = pageLinks(ctx, query, page.Total)
+
+	return response
+}
+
+// pageLinks resolves the effective page and limit of an offset-paginated
+// query and builds the links to its neighbouring pages.
+func pageLinks(ctx *gin.Context, query domain.MovieQuery, total int64) (page, limit int, next, prev string) {
+	page, limit = query.Page, query.Limit
+	if page == 0 {
+		page = 1
+	}
+
+	if limit == 0 {
+		limit = service.DefaultPageSize
+	}
+
+	if int64(page*limit) < total {
+		next = queryLink(ctx, "page", strconv.Itoa(page+1))
+	}
+
+	if page > 1 {
+		prev = queryLink(ctx, "page", strconv.Itoa(page-1))
+	}
+
+	return page, limit, next, prev
+}
+
+// queryLink returns the current request URL with one query parameter replaced.
+func queryLink(ctx *gin.Context, key, value string) string {
+	link := *ctx.Request.URL
+	values := link.Query()
+	values.Set(key, value)
+	link.RawQuery = values.Encode()
+
+	return link.RequestURI()
+}
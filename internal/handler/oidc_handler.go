@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"errors"
+	"movie_app/internal/domain"
+	"movie_app/internal/service"
+	"net/http"
+	"path"
+
+	"github.com/gin-gonic/gin"
+)
+
+// oidcStateCookie holds the state of the login started in the browser. The
+// callback must come with it, so that an attacker cannot complete a login
+// they started in someone else's browser and sign them in to their account.
+const oidcStateCookie = "oidc_state"
+
+type OIDCHandler struct {
+	service service.OIDCService
+}
+
+func NewOIDCHandler(svc service.OIDCService) *OIDCHandler {
+	return &OIDCHandler{service: svc}
+}
+
+// Authorize godoc
+// @Summary Log in with the identity provider
+// @Description Redirect the user to the identity provider to log in. The provider sends them back to the
+// @Description web app's redirect URL with a code and a state to complete the login with at
+// @Description /auth/oidc/callback. The state is also set in an HttpOnly cookie, which the callback
+// @Description must be sent with from the same browser.
+// @Tags auth
+// @Success 302 "Found"
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/oidc/authorize [get]
+func (h *OIDCHandler) Authorize(ctx *gin.Context) {
+	url, state, err := h.service.Authorize(ctx.Request.Context())
+	if err != nil {
+		writeOIDCError(ctx, err)
+
+		return
+	}
+
+	// Lax, since the callback follows the redirect back from the provider
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(oidcStateCookie, state, 0, oidcCookiePath(ctx), "", secureRequest(ctx), true)
+	ctx.Redirect(http.StatusFound, url)
+}
+
+// Callback godoc
+// @Summary Complete a login with the identity provider
+// @Description Exchange the code and state the identity provider sent the user back with for the access
+// @Description and refresh tokens. The identity is linked to the account registered with its verified
+// @Description email, or to a new account if there is none. Users with two-factor authentication get an
+// @Description MFA token instead, to exchange at /auth/mfa/verify. The request must carry the state
+// @Description cookie set by /auth/oidc/authorize in the same browser.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body domain.OIDCCallbackRequest true "Code and state"
+// @Success 200 {object} domain.LoginResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/oidc/callback [post]
+func (h *OIDCHandler) Callback(ctx *gin.Context) {
+	var req domain.OIDCCallbackRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	cookie, err := ctx.Cookie(oidcStateCookie)
+	if err != nil || subtle.ConstantTimeCompare([]byte(cookie), []byte(req.State)) != 1 {
+		writeOIDCError(ctx, service.ErrOIDCStateInvalid)
+
+		return
+	}
+
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(oidcStateCookie, "", -1, oidcCookiePath(ctx), "", secureRequest(ctx), true)
+
+	result, err := h.service.Callback(ctx.Request.Context(), req, clientInfo(ctx))
+	if err != nil {
+		writeOIDCError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// oidcCookiePath limits the state cookie to the OIDC routes, wherever the
+// router mounts them.
+func oidcCookiePath(ctx *gin.Context) string {
+	return path.Dir(ctx.FullPath())
+}
+
+// secureRequest reports whether the client made the request over HTTPS,
+// directly or through a proxy terminating it.
+func secureRequest(ctx *gin.Context) bool {
+	return ctx.Request.TLS != nil || ctx.GetHeader("X-Forwarded-Proto") == "https"
+}
+
+func writeOIDCError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrOIDCDisabled):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrOIDCStateInvalid):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrOIDCLoginFailed):
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrOIDCEmailNotVerified), errors.Is(err, service.ErrOIDCNoAccount):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrOIDCIdentityConflict), errors.Is(err, service.ErrEmailTaken):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
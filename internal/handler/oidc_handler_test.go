@@ -0,0 +1,617 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"movie_app/internal/config"
+	"movie_app/internal/domain"
+	"movie_app/internal/handler"
+	"movie_app/internal/oidc"
+	"movie_app/internal/repository"
+	"movie_app/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+const (
+	testClientID    = "movie-app"
+	testRedirectURL = "http://localhost:3000/oidc/callback"
+)
+
+// identity is the user the mock provider logs in.
+type identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// grant is an authorization code the mock provider issued, along with the
+// PKCE challenge and nonce of the request it was issued for.
+type grant struct {
+	challenge string
+	nonce     string
+	identity  identity
+}
+
+// mockProvider is an OpenID Connect provider serving discovery, JWKS and
+// token endpoints. Tests stand in for its login page by granting codes.
+type mockProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu     sync.Mutex
+	grants map[string]grant
+	next   int
+}
+
+func newMockProvider(t *testing.T) *mockProvider {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+
+	p := &mockProvider{key: key, grants: make(map[string]grant)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("GET /jwks", p.jwks)
+	mux.HandleFunc("POST /token", p.token)
+
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+
+	return p
+}
+
+// grant issues a code for the authorization request, as the provider does
+// once the user logged in.
+func (p *mockProvider) grant(challenge, nonce string, id identity) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.next++
+	code := fmt.Sprintf("code-%d", p.next)
+	p.grants[code] = grant{challenge: challenge, nonce: nonce, identity: id}
+
+	return code
+}
+
+func (p *mockProvider) discovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                p.server.URL,
+		"authorization_endpoint":                p.server.URL + "/authorize",
+		"token_endpoint":                        p.server.URL + "/token",
+		"jwks_uri":                              p.server.URL + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (p *mockProvider) jwks(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test",
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+		}},
+	})
+}
+
+func (p *mockProvider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+
+		return
+	}
+
+	p.mu.Lock()
+	g, ok := p.grants[r.PostForm.Get("code")]
+	delete(p.grants, r.PostForm.Get("code"))
+	p.mu.Unlock()
+
+	if !ok || r.PostForm.Get("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+
+		return
+	}
+
+	if s256(r.PostForm.Get("code_verifier")) != g.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{
+			"error":             "invalid_grant",
+			"error_description": "PKCE verification failed",
+		})
+
+		return
+	}
+
+	now := time.Now()
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            p.server.URL,
+		"sub":            g.identity.Subject,
+		"aud":            testClientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Minute).Unix(),
+		"nonce":          g.nonce,
+		"email":          g.identity.Email,
+		"email_verified": g.identity.EmailVerified,
+		"name":           g.identity.Name,
+	})
+	idToken.Header["kid"] = "test"
+
+	signed, err := idToken.SignedString(p.key)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": "provider-access-token",
+		"token_type":   "Bearer",
+		"expires_in":   60,
+		"id_token":     signed,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func s256(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// fakeOIDCRepository keeps login states and identities in memory.
+type fakeOIDCRepository struct {
+	users      *fakeUserRepository
+	states     map[string]domain.OIDCLoginState
+	identities []domain.UserIdentity
+}
+
+func (r *fakeOIDCRepository) CreateState(_ context.Context, state *domain.OIDCLoginState) error {
+	r.states[state.StateHash] = *state
+
+	return nil
+}
+
+func (r *fakeOIDCRepository) ConsumeState(_ context.Context, stateHash string) (*domain.OIDCLoginState, error) {
+	state, ok := r.states[stateHash]
+	if !ok || !state.ExpiresAt.After(time.Now()) {
+		return nil, repository.ErrOIDCStateInvalid
+	}
+
+	delete(r.states, stateHash)
+
+	return &state, nil
+}
+
+func (r *fakeOIDCRepository) GetIdentity(_ context.Context, issuer, subject string) (*domain.UserIdentity, error) {
+	for _, identity := range r.identities {
+		if identity.Issuer == issuer && identity.Subject == subject {
+			identity.User = *r.users.byID(identity.UserID)
+
+			return &identity, nil
+		}
+	}
+
+	return nil, fmt.Errorf("failed to get identity: %w", gorm.ErrRecordNotFound)
+}
+
+func (r *fakeOIDCRepository) TouchIdentity(context.Context, *domain.UserIdentity) error {
+	return nil
+}
+
+func (r *fakeOIDCRepository) LinkIdentity(_ context.Context, identity *domain.UserIdentity) error {
+	for _, linked := range r.identities {
+		if linked.UserID == identity.UserID && linked.Issuer == identity.Issuer {
+			return repository.ErrIdentityConflict
+		}
+	}
+
+	identity.ID = uint(len(r.identities) + 1)
+	r.identities = append(r.identities, *identity)
+
+	if user := r.users.byID(identity.UserID); user.EmailVerifiedAt == nil {
+		user.EmailVerifiedAt = &identity.CreatedAt
+	}
+
+	return nil
+}
+
+func (r *fakeOIDCRepository) CreateUser(
+	_ context.Context, user *domain.User, identity *domain.UserIdentity,
+) error {
+	r.users.add(user)
+
+	identity.UserID = user.ID
+
+	return r.LinkIdentity(context.Background(), identity)
+}
+
+// fakeUserRepository implements the lookups by email the login needs.
+type fakeUserRepository struct {
+	repository.UserRepository
+
+	users []*domain.User
+}
+
+func (r *fakeUserRepository) add(user *domain.User) {
+	user.ID = uint(len(r.users) + 1)
+	r.users = append(r.users, user)
+}
+
+func (r *fakeUserRepository) byID(id uint) *domain.User {
+	return r.users[id-1]
+}
+
+func (r *fakeUserRepository) GetByEmail(_ context.Context, email string) (*domain.User, error) {
+	for _, user := range r.users {
+		if user.Email == email {
+			copied := *user
+
+			return &copied, nil
+		}
+	}
+
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserRepository) EmailTaken(ctx context.Context, email string) (bool, error) {
+	_, err := r.GetByEmail(ctx, email)
+
+	return err == nil, nil
+}
+
+type fakeAuditRepository struct {
+	entries []domain.AuditEntry
+}
+
+func (r *fakeAuditRepository) Create(_ context.Context, entry *domain.AuditEntry) error {
+	r.entries = append(r.entries, *entry)
+
+	return nil
+}
+
+// fakeTokenService issues tokens naming the user they were issued to.
+type fakeTokenService struct {
+	service.TokenService
+}
+
+func (fakeTokenService) Issue(_ context.Context, user *domain.User, _ domain.ClientInfo) (*domain.LoginResponse, error) {
+	return &domain.LoginResponse{Token: fmt.Sprintf("access-%d", user.ID), User: user}, nil
+}
+
+type fakeMFAService struct {
+	service.MFAService
+}
+
+func (fakeMFAService) Enabled(context.Context, uint) (bool, error) {
+	return false, nil
+}
+
+type oidcTest struct {
+	provider *mockProvider
+	router   *gin.Engine
+	repo     *fakeOIDCRepository
+	users    *fakeUserRepository
+	audit    *fakeAuditRepository
+}
+
+func newOIDCTest(t *testing.T) *oidcTest {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	mock := newMockProvider(t)
+	users := &fakeUserRepository{}
+	repo := &fakeOIDCRepository{users: users, states: make(map[string]domain.OIDCLoginState)}
+	audit := &fakeAuditRepository{}
+
+	provider := oidc.NewDiscoveryProvider(config.OIDCConfig{
+		Issuer:       mock.server.URL,
+		ClientID:     testClientID,
+		ClientSecret: "provider-secret",
+		RedirectURL:  testRedirectURL,
+		Scopes:       []string{"email", "profile"},
+	})
+
+	svc := service.NewOIDCService(repo, users, audit, fakeTokenService{}, fakeMFAService{}, provider, service.OIDCOptions{
+		AutoRegister: true,
+		StateTTL:     time.Minute,
+	})
+	h := handler.NewOIDCHandler(svc)
+
+	router := gin.New()
+	router.GET("/api/v1/auth/oidc/authorize", h.Authorize)
+	router.POST("/api/v1/auth/oidc/callback", h.Callback)
+
+	return &oidcTest{provider: mock, router: router, repo: repo, users: users, audit: audit}
+}
+
+// authorization is a login started at /auth/oidc/authorize, as the
+// provider received it.
+type authorization struct {
+	state     string
+	nonce     string
+	challenge string
+	cookie    *http.Cookie // Tying the state to the browser that started the login
+}
+
+func (o *oidcTest) authorize(t *testing.T) authorization {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	o.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/auth/oidc/authorize", nil))
+
+	if rec.Code != http.StatusFound {
+		t.Fatalf("authorize: got status %d, want %d: %s", rec.Code, http.StatusFound, rec.Body)
+	}
+
+	location, err := url.Parse(rec.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("parsing redirect: %v", err)
+	}
+
+	query := location.Query()
+	if location.Path != "/authorize" || query.Get("client_id") != testClientID ||
+		query.Get("redirect_uri") != testRedirectURL || query.Get("response_type") != "code" {
+		t.Fatalf("unexpected authorization URL %s", location)
+	}
+
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		t.Fatalf("authorization URL %s lacks a S256 PKCE challenge", location)
+	}
+
+	if query.Get("state") == "" || query.Get("nonce") == "" {
+		t.Fatalf("authorization URL %s lacks a state or nonce", location)
+	}
+
+	var cookie *http.Cookie
+
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == "oidc_state" {
+			cookie = c
+		}
+	}
+
+	if cookie == nil || cookie.Value != query.Get("state") || !cookie.HttpOnly || cookie.SameSite != http.SameSiteLaxMode {
+		t.Fatalf("got state cookie %+v, want an HttpOnly, SameSite one holding the state", cookie)
+	}
+
+	return authorization{
+		state:     query.Get("state"),
+		nonce:     query.Get("nonce"),
+		challenge: query.Get("code_challenge"),
+		cookie:    cookie,
+	}
+}
+
+func (o *oidcTest) callback(t *testing.T, code, state string, cookie *http.Cookie) (int, domain.LoginResponse) {
+	t.Helper()
+
+	body, _ := json.Marshal(domain.OIDCCallbackRequest{Code: code, State: state})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/oidc/callback", bytes.NewReader(body))
+	if cookie != nil {
+		req.AddCookie(&http.Cookie{Name: cookie.Name, Value: cookie.Value})
+	}
+
+	rec := httptest.NewRecorder()
+	o.router.ServeHTTP(rec, req)
+
+	var response domain.LoginResponse
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("decoding login response: %v", err)
+		}
+	}
+
+	return rec.Code, response
+}
+
+// login starts a login and completes it as the identity.
+func (o *oidcTest) login(t *testing.T, id identity) (int, domain.LoginResponse) {
+	t.Helper()
+
+	auth := o.authorize(t)
+	code := o.provider.grant(auth.challenge, auth.nonce, id)
+
+	return o.callback(t, code, auth.state, auth.cookie)
+}
+
+func TestOIDCLoginRegistersNewUser(t *testing.T) {
+	o := newOIDCTest(t)
+	id := identity{Subject: "alice-sub", Email: "alice@example.com", EmailVerified: true, Name: "Alice"}
+
+	status, response := o.login(t, id)
+	if status != http.StatusOK {
+		t.Fatalf("got status %d, want %d", status, http.StatusOK)
+	}
+
+	if len(o.users.users) != 1 {
+		t.Fatalf("got %d users, want 1", len(o.users.users))
+	}
+
+	user := o.users.users[0]
+	if response.Token != fmt.Sprintf("access-%d", user.ID) {
+		t.Errorf("got token %q for user %d", response.Token, user.ID)
+	}
+
+	if user.Email != id.Email || user.DisplayName != id.Name || !user.EmailVerified() || user.Role != domain.RoleViewer {
+		t.Errorf("registered user %+v does not match the identity", user)
+	}
+
+	if len(o.repo.identities) != 1 || o.repo.identities[0].Issuer != o.provider.server.URL ||
+		o.repo.identities[0].Subject != id.Subject {
+		t.Fatalf("got identities %+v, want the identity at the provider", o.repo.identities)
+	}
+
+	// Logging in again finds the user by the identity
+	status, response = o.login(t, id)
+	if status != http.StatusOK || response.Token != fmt.Sprintf("access-%d", user.ID) {
+		t.Errorf("second login: got status %d and token %q", status, response.Token)
+	}
+
+	if len(o.users.users) != 1 || len(o.repo.identities) != 1 {
+		t.Errorf("second login created %d users and %d identities", len(o.users.users), len(o.repo.identities))
+	}
+}
+
+func TestOIDCLoginRejectsStateMismatch(t *testing.T) {
+	o := newOIDCTest(t)
+	id := identity{Subject: "alice-sub", Email: "alice@example.com", EmailVerified: true}
+
+	auth := o.authorize(t)
+	code := o.provider.grant(auth.challenge, auth.nonce, id)
+
+	if status, _ := o.callback(t, code, "forged-state", auth.cookie); status != http.StatusBadRequest {
+		t.Errorf("forged state: got status %d, want %d", status, http.StatusBadRequest)
+	}
+
+	if status, _ := o.callback(t, code, auth.state, auth.cookie); status != http.StatusOK {
+		t.Fatalf("valid state: got status %d, want %d", status, http.StatusOK)
+	}
+
+	// Every state completes a single login
+	code = o.provider.grant(auth.challenge, auth.nonce, id)
+	if status, _ := o.callback(t, code, auth.state, auth.cookie); status != http.StatusBadRequest {
+		t.Errorf("reused state: got status %d, want %d", status, http.StatusBadRequest)
+	}
+}
+
+func TestOIDCLoginRejectsStateFromAnotherBrowser(t *testing.T) {
+	o := newOIDCTest(t)
+
+	// The attacker starts a login and has the victim's browser, which
+	// started one of its own, complete it
+	victim := o.authorize(t)
+	attacker := o.authorize(t)
+	code := o.provider.grant(attacker.challenge, attacker.nonce, identity{
+		Subject: "mallory-sub", Email: "mallory@example.com", EmailVerified: true,
+	})
+
+	if status, _ := o.callback(t, code, attacker.state, victim.cookie); status != http.StatusBadRequest {
+		t.Errorf("another browser's state: got status %d, want %d", status, http.StatusBadRequest)
+	}
+
+	if status, _ := o.callback(t, code, attacker.state, nil); status != http.StatusBadRequest {
+		t.Errorf("no state cookie: got status %d, want %d", status, http.StatusBadRequest)
+	}
+
+	if len(o.users.users) != 0 {
+		t.Errorf("got %d users, want none", len(o.users.users))
+	}
+}
+
+func TestOIDCLoginRejectsBadPKCEVerifier(t *testing.T) {
+	o := newOIDCTest(t)
+
+	// A code issued for another authorization request, such as one injected
+	// by an attacker, was bound to a challenge this login cannot answer
+	auth := o.authorize(t)
+	other := o.authorize(t)
+	code := o.provider.grant(other.challenge, auth.nonce, identity{
+		Subject: "alice-sub", Email: "alice@example.com", EmailVerified: true,
+	})
+
+	if status, _ := o.callback(t, code, auth.state, auth.cookie); status != http.StatusUnauthorized {
+		t.Errorf("got status %d, want %d", status, http.StatusUnauthorized)
+	}
+
+	if len(o.users.users) != 0 {
+		t.Errorf("got %d users, want none", len(o.users.users))
+	}
+}
+
+func TestOIDCLoginRejectsNonceMismatch(t *testing.T) {
+	o := newOIDCTest(t)
+
+	auth := o.authorize(t)
+	code := o.provider.grant(auth.challenge, "replayed-nonce", identity{
+		Subject: "alice-sub", Email: "alice@example.com", EmailVerified: true,
+	})
+
+	if status, _ := o.callback(t, code, auth.state, auth.cookie); status != http.StatusUnauthorized {
+		t.Errorf("got status %d, want %d", status, http.StatusUnauthorized)
+	}
+
+	if len(o.users.users) != 0 {
+		t.Errorf("got %d users, want none", len(o.users.users))
+	}
+}
+
+func TestOIDCLoginRefusesToLinkUnverifiedEmail(t *testing.T) {
+	o := newOIDCTest(t)
+	o.users.add(&domain.User{Email: "alice@example.com", Role: domain.RoleAdmin})
+
+	status, _ := o.login(t, identity{Subject: "mallory-sub", Email: "alice@example.com", EmailVerified: false})
+	if status != http.StatusForbidden {
+		t.Errorf("got status %d, want %d", status, http.StatusForbidden)
+	}
+
+	if len(o.repo.identities) != 0 {
+		t.Errorf("got identities %+v, want none", o.repo.identities)
+	}
+}
+
+func TestOIDCLoginLinksExistingUser(t *testing.T) {
+	o := newOIDCTest(t)
+	existing := &domain.User{Email: "alice@example.com", Role: domain.RoleEditor}
+	o.users.add(existing)
+
+	status, response := o.login(t, identity{Subject: "alice-sub", Email: "alice@example.com", EmailVerified: true})
+	if status != http.StatusOK {
+		t.Fatalf("got status %d, want %d", status, http.StatusOK)
+	}
+
+	if response.Token != fmt.Sprintf("access-%d", existing.ID) {
+		t.Errorf("got token %q, want one for user %d", response.Token, existing.ID)
+	}
+
+	if len(o.users.users) != 1 {
+		t.Errorf("got %d users, want the existing one only", len(o.users.users))
+	}
+
+	if len(o.repo.identities) != 1 || o.repo.identities[0].UserID != existing.ID {
+		t.Fatalf("got identities %+v, want one linked to user %d", o.repo.identities, existing.ID)
+	}
+
+	if !existing.EmailVerified() {
+		t.Error("linking did not mark the email as verified")
+	}
+
+	if len(o.audit.entries) != 1 || o.audit.entries[0].Action != domain.AuditIdentityLinked {
+		t.Errorf("got audit entries %+v, want an %s entry", o.audit.entries, domain.AuditIdentityLinked)
+	}
+
+	// Another identity at the same provider cannot take over the account
+	status, _ = o.login(t, identity{Subject: "other-sub", Email: "alice@example.com", EmailVerified: true})
+	if status != http.StatusConflict {
+		t.Errorf("second identity: got status %d, want %d", status, http.StatusConflict)
+	}
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"errors"
+	"movie_app/internal/domain"
+	"movie_app/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PasswordHandler struct {
+	service service.PasswordService
+}
+
+func NewPasswordHandler(svc service.PasswordService) *PasswordHandler {
+	return &PasswordHandler{service: svc}
+}
+
+// ForgotPassword godoc
+// @Summary Request a password reset
+// @Description Email a single-use password reset link to the address. The response is the same whether or
+// @Description not the address is registered.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body domain.ForgotPasswordRequest true "Account email"
+// @Success 202 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/password/forgot [post]
+func (h *PasswordHandler) ForgotPassword(ctx *gin.Context) {
+	var req domain.ForgotPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	if err := h.service.ForgotPassword(ctx.Request.Context(), req); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to request a password reset"})
+
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"message": "If the email is registered, a password reset link has been sent to it",
+	})
+}
+
+// ResetPassword godoc
+// @Summary Reset a password
+// @Description Choose a new password with the token from a password reset email. The token can be used
+// @Description once, and every session of the user is signed out.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body domain.ResetPasswordRequest true "Reset token and new password"
+// @Success 204 "No Content"
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/password/reset [post]
+func (h *PasswordHandler) ResetPassword(ctx *gin.Context) {
+	var req domain.ResetPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	if err := h.service.ResetPassword(ctx.Request.Context(), req); err != nil {
+		if errors.Is(err, service.ErrInvalidResetToken) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
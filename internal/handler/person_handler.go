@@ -0,0 +1,423 @@
+package handler
+
+import (
+	"errors"
+	"movie_app/internal/domain"
+	"movie_app/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PersonHandler struct {
+	service service.PersonService
+}
+
+func NewPersonHandler(svc service.PersonService) *PersonHandler {
+	return &PersonHandler{service: svc}
+}
+
+// @Summary Create a person
+// @Description Create a new person who can be credited on movies (requires people:write)
+// @Tags people
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param person body domain.CreatePersonRequest true "Person object"
+// @Success 201 {object} domain.Person
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /people [post]
+func (h *PersonHandler) CreatePerson(ctx *gin.Context) {
+	var req domain.CreatePersonRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	person, err := h.service.Create(ctx.Request.Context(), req)
+	if err != nil {
+		writePersonError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, person)
+}
+
+// @Summary Get all people
+// @Description Get a paginated list of people, optionally filtered by name
+// @Tags people
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param page query int false "Page number (default 1, max 10000)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param name query string false "Name (partial match)"
+// @Success 200 {object} domain.PersonListResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /people [get]
+func (h *PersonHandler) GetAllPeople(ctx *gin.Context) {
+	var query domain.PersonQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	page, err := h.service.List(ctx.Request.Context(), query)
+	if err != nil {
+		writePersonError(ctx, err)
+
+		return
+	}
+
+	response := domain.PersonListResponse{
+		Items: page.People,
+		Total: page.Total,
+		Page:  max(query.Page, 1),
+		Limit: query.Limit,
+	}
+
+	if response.Items == nil {
+		response.Items = []domain.Person{}
+	}
+
+	if response.Limit == 0 {
+		response.Limit = service.DefaultPageSize
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// @Summary Get a person by ID
+// @Description Get a person's details by their ID
+// @Tags people
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param id path int true "Person ID"
+// @Success 200 {object} domain.Person
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /people/{id} [get]
+func (h *PersonHandler) GetPerson(ctx *gin.Context) {
+	personID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+
+		return
+	}
+
+	person, err := h.service.GetByID(ctx.Request.Context(), uint(personID))
+	if err != nil {
+		writePersonError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, person)
+}
+
+// @Summary Update a person
+// @Description Update an existing person's details (requires people:write)
+// @Tags people
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param id path int true "Person ID"
+// @Param person body domain.UpdatePersonRequest true "Person object"
+// @Success 200 {object} domain.Person
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /people/{id} [put]
+func (h *PersonHandler) UpdatePerson(ctx *gin.Context) {
+	personID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+
+		return
+	}
+
+	var req domain.UpdatePersonRequest
+	if bindErr := ctx.ShouldBindJSON(&req); bindErr != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": bindErr.Error()})
+
+		return
+	}
+
+	person, err := h.service.Update(ctx.Request.Context(), uint(personID), req)
+	if err != nil {
+		writePersonError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, person)
+}
+
+// @Summary Delete a person
+// @Description Delete a person who has no remaining credits (requires people:write)
+// @Tags people
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param id path int true "Person ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /people/{id} [delete]
+func (h *PersonHandler) DeletePerson(ctx *gin.Context) {
+	personID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+
+		return
+	}
+
+	if err := h.service.Delete(ctx.Request.Context(), uint(personID)); err != nil {
+		writePersonError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+// @Summary Get a person's filmography
+// @Description Get every credit of a person with its movie, newest first
+// @Tags people
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param id path int true "Person ID"
+// @Success 200 {object} domain.Filmography
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /people/{id}/filmography [get]
+func (h *PersonHandler) GetFilmography(ctx *gin.Context) {
+	personID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+
+		return
+	}
+
+	filmography, err := h.service.Filmography(ctx.Request.Context(), uint(personID))
+	if err != nil {
+		writePersonError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, filmography)
+}
+
+// @Summary Get a movie's credits
+// @Description Get the cast and crew of a movie
+// @Tags credits
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param id path int true "Movie ID"
+// @Success 200 {array} domain.Credit
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /movies/{id}/credits [get]
+func (h *PersonHandler) GetMovieCredits(ctx *gin.Context) {
+	movieID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+
+		return
+	}
+
+	credits, err := h.service.GetCredits(ctx.Request.Context(), uint(movieID))
+	if err != nil {
+		writePersonError(ctx, err)
+
+		return
+	}
+
+	if credits == nil {
+		credits = []domain.Credit{}
+	}
+
+	ctx.JSON(http.StatusOK, credits)
+}
+
+// @Summary Add a credit to a movie
+// @Description Credit a person on a movie with a department and job or character (requires movies:write)
+// @Tags credits
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param id path int true "Movie ID"
+// @Param credit body domain.CreditRequest true "Credit object"
+// @Success 201 {object} domain.Credit
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /movies/{id}/credits [post]
+func (h *PersonHandler) AddMovieCredit(ctx *gin.Context) {
+	movieID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+
+		return
+	}
+
+	var req domain.CreditRequest
+	if bindErr := ctx.ShouldBindJSON(&req); bindErr != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": bindErr.Error()})
+
+		return
+	}
+
+	credit, err := h.service.AddCredit(ctx.Request.Context(), uint(movieID), req)
+	if err != nil {
+		writeCreditError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, credit)
+}
+
+// @Summary Update a movie credit
+// @Description Update the department, job, character or billing order of a credit (requires movies:write)
+// @Tags credits
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param id path int true "Movie ID"
+// @Param creditId path int true "Credit ID"
+// @Param credit body domain.UpdateCreditRequest true "Credit object"
+// @Success 200 {object} domain.Credit
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /movies/{id}/credits/{creditId} [put]
+func (h *PersonHandler) UpdateMovieCredit(ctx *gin.Context) {
+	movieID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+
+		return
+	}
+
+	creditID, err := strconv.ParseUint(ctx.Param("creditId"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid credit id"})
+
+		return
+	}
+
+	var req domain.UpdateCreditRequest
+	if bindErr := ctx.ShouldBindJSON(&req); bindErr != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": bindErr.Error()})
+
+		return
+	}
+
+	credit, err := h.service.UpdateCredit(ctx.Request.Context(), uint(movieID), uint(creditID), req)
+	if err != nil {
+		writeCreditError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, credit)
+}
+
+// @Summary Delete a movie credit
+// @Description Remove a credit from a movie (requires movies:write)
+// @Tags credits
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param id path int true "Movie ID"
+// @Param creditId path int true "Credit ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /movies/{id}/credits/{creditId} [delete]
+func (h *PersonHandler) DeleteMovieCredit(ctx *gin.Context) {
+	movieID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+
+		return
+	}
+
+	creditID, err := strconv.ParseUint(ctx.Param("creditId"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid credit id"})
+
+		return
+	}
+
+	if err := h.service.DeleteCredit(ctx.Request.Context(), uint(movieID), uint(creditID)); err != nil {
+		writeCreditError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+func writePersonError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrPersonNotFound), errors.Is(err, service.ErrMovieNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrPersonHasCredits):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidBirthDate),
+		errors.Is(err, service.ErrInvalidPage),
+		errors.Is(err, service.ErrInvalidLimit):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// writeCreditError treats an unknown person as a bad request, since it is
+// referenced from the request body rather than the path.
+func writeCreditError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrCreditNotFound), errors.Is(err, service.ErrMovieNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidDepartment), errors.Is(err, service.ErrPersonNotFound):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
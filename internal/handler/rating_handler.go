@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"errors"
+	"movie_app/internal/domain"
+	"movie_app/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RatingHandler struct {
+	service service.RatingService
+}
+
+func NewRatingHandler(svc service.RatingService) *RatingHandler {
+	return &RatingHandler{service: svc}
+}
+
+// @Summary Rate a movie
+// @Description Create or replace the current user's rating of a movie
+// @Tags ratings
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param id path int true "Movie ID"
+// @Param rating body domain.RateMovieRequest true "Score from 1 to 10"
+// @Success 200 {object} domain.MyRatingResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /movies/{id}/my-rating [put]
+func (h *RatingHandler) RateMovie(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	movieID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+
+		return
+	}
+
+	var req domain.RateMovieRequest
+	if bindErr := ctx.ShouldBindJSON(&req); bindErr != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": bindErr.Error()})
+
+		return
+	}
+
+	result, err := h.service.Rate(ctx.Request.Context(), userID, uint(movieID), req.Score)
+	if err != nil {
+		writeRatingError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// @Summary Get my rating of a movie
+// @Description Get the current user's rating of a movie along with the community rating
+// @Tags ratings
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param id path int true "Movie ID"
+// @Success 200 {object} domain.MyRatingResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /movies/{id}/my-rating [get]
+func (h *RatingHandler) GetMyRating(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	movieID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+
+		return
+	}
+
+	result, err := h.service.GetMine(ctx.Request.Context(), userID, uint(movieID))
+	if err != nil {
+		writeRatingError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// @Summary Delete my rating of a movie
+// @Description Remove the current user's rating of a movie
+// @Tags ratings
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param id path int true "Movie ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /movies/{id}/my-rating [delete]
+func (h *RatingHandler) DeleteMyRating(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	movieID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+
+		return
+	}
+
+	if err := h.service.DeleteMine(ctx.Request.Context(), userID, uint(movieID)); err != nil {
+		writeRatingError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+// @Summary Get a movie's community rating
+// @Description Get the average, count, Bayesian-weighted score and histogram of user ratings
+// @Tags ratings
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param id path int true "Movie ID"
+// @Success 200 {object} domain.RatingSummary
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /movies/{id}/ratings [get]
+func (h *RatingHandler) GetRatingSummary(ctx *gin.Context) {
+	movieID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+
+		return
+	}
+
+	summary, err := h.service.Summary(ctx.Request.Context(), uint(movieID))
+	if err != nil {
+		writeRatingError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, summary)
+}
+
+func writeRatingError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrMovieNotFound), errors.Is(err, service.ErrRatingNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidRating):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,418 @@
+package handler
+
+import (
+	"errors"
+	"movie_app/internal/domain"
+	"movie_app/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReviewHandler struct {
+	service service.ReviewService
+}
+
+func NewReviewHandler(svc service.ReviewService) *ReviewHandler {
+	return &ReviewHandler{service: svc}
+}
+
+// @Summary Get a movie's reviews
+// @Description Get a paginated list of a movie's published reviews
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param id path int true "Movie ID"
+// @Param page query int false "Page number (default 1, max 10000)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param sort query string false "helpful (default) or recent"
+// @Success 200 {object} domain.ReviewListResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /movies/{id}/reviews [get]
+func (h *ReviewHandler) GetMovieReviews(ctx *gin.Context) {
+	movieID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+
+		return
+	}
+
+	var query domain.ReviewQuery
+	if bindErr := ctx.ShouldBindQuery(&query); bindErr != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": bindErr.Error()})
+
+		return
+	}
+
+	page, err := h.service.ListByMovie(ctx.Request.Context(), uint(movieID), query)
+	if err != nil {
+		writeReviewError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newReviewListResponse(query, page))
+}
+
+// @Summary Review a movie
+// @Description Write the current user's review of a movie
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param id path int true "Movie ID"
+// @Param review body domain.CreateReviewRequest true "Review"
+// @Success 201 {object} domain.Review
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /movies/{id}/reviews [post]
+func (h *ReviewHandler) CreateReview(ctx *gin.Context) {
+	actor, ok := currentActor(ctx)
+	if !ok {
+		return
+	}
+
+	movieID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+
+		return
+	}
+
+	var req domain.CreateReviewRequest
+	if bindErr := ctx.ShouldBindJSON(&req); bindErr != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": bindErr.Error()})
+
+		return
+	}
+
+	review, err := h.service.Create(ctx.Request.Context(), actor, uint(movieID), req)
+	if err != nil {
+		writeReviewError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, review)
+}
+
+// @Summary Get a review
+// @Description Get a published review, or an unpublished one to its author and moderators
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param id path int true "Review ID"
+// @Success 200 {object} domain.Review
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /reviews/{id} [get]
+func (h *ReviewHandler) GetReview(ctx *gin.Context) {
+	actor, ok := currentActor(ctx)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+
+		return
+	}
+
+	review, err := h.service.GetByID(ctx.Request.Context(), actor, uint(id))
+	if err != nil {
+		writeReviewError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, review)
+}
+
+// @Summary Update a review
+// @Description Edit the current user's own review; edited reviews may need to be approved again, and
+// @Description rejected ones always do
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param id path int true "Review ID"
+// @Param review body domain.UpdateReviewRequest true "Fields to update"
+// @Success 200 {object} domain.Review
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /reviews/{id} [put]
+func (h *ReviewHandler) UpdateReview(ctx *gin.Context) {
+	actor, ok := currentActor(ctx)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+
+		return
+	}
+
+	var req domain.UpdateReviewRequest
+	if bindErr := ctx.ShouldBindJSON(&req); bindErr != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": bindErr.Error()})
+
+		return
+	}
+
+	review, err := h.service.Update(ctx.Request.Context(), actor, uint(id), req)
+	if err != nil {
+		writeReviewError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, review)
+}
+
+// @Summary Delete a review
+// @Description Delete the current user's own review; moderators can delete any review
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param id path int true "Review ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /reviews/{id} [delete]
+func (h *ReviewHandler) DeleteReview(ctx *gin.Context) {
+	actor, ok := currentActor(ctx)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+
+		return
+	}
+
+	if err := h.service.Delete(ctx.Request.Context(), actor, uint(id)); err != nil {
+		writeReviewError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+// @Summary Vote on a review
+// @Description Mark another user's published review as helpful or unhelpful, replacing any previous vote
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param id path int true "Review ID"
+// @Param vote body domain.VoteReviewRequest true "Vote"
+// @Success 200 {object} domain.Review
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /reviews/{id}/vote [put]
+func (h *ReviewHandler) VoteReview(ctx *gin.Context) {
+	actor, ok := currentActor(ctx)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+
+		return
+	}
+
+	var req domain.VoteReviewRequest
+	if bindErr := ctx.ShouldBindJSON(&req); bindErr != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": bindErr.Error()})
+
+		return
+	}
+
+	review, err := h.service.Vote(ctx.Request.Context(), actor, uint(id), *req.Helpful)
+	if err != nil {
+		writeReviewError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, review)
+}
+
+// @Summary Remove a vote on a review
+// @Description Withdraw the current user's vote on a review
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param id path int true "Review ID"
+// @Success 200 {object} domain.Review
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /reviews/{id}/vote [delete]
+func (h *ReviewHandler) DeleteReviewVote(ctx *gin.Context) {
+	actor, ok := currentActor(ctx)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+
+		return
+	}
+
+	review, err := h.service.DeleteVote(ctx.Request.Context(), actor, uint(id))
+	if err != nil {
+		writeReviewError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, review)
+}
+
+// @Summary Get reviews for moderation
+// @Description Get a paginated list of reviews of every movie in a moderation state (requires reviews:moderate)
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param status query string false "pending (default), published or rejected"
+// @Param page query int false "Page number (default 1, max 10000)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param sort query string false "recent (default) or helpful"
+// @Success 200 {object} domain.ReviewListResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/reviews [get]
+func (h *ReviewHandler) GetReviewsForModeration(ctx *gin.Context) {
+	var query domain.ReviewQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	page, err := h.service.ListForModeration(ctx.Request.Context(), query)
+	if err != nil {
+		writeReviewError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newReviewListResponse(query, page))
+}
+
+// @Summary Moderate a review
+// @Description Change a review's moderation state (requires reviews:moderate)
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param id path int true "Review ID"
+// @Param moderation body domain.ModerateReviewRequest true "New state and optional note to the author"
+// @Success 200 {object} domain.Review
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/reviews/{id}/status [put]
+func (h *ReviewHandler) ModerateReview(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+
+		return
+	}
+
+	var req domain.ModerateReviewRequest
+	if bindErr := ctx.ShouldBindJSON(&req); bindErr != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": bindErr.Error()})
+
+		return
+	}
+
+	review, err := h.service.Moderate(ctx.Request.Context(), uint(id), req)
+	if err != nil {
+		writeReviewError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, review)
+}
+
+func newReviewListResponse(query domain.ReviewQuery, page *domain.ReviewPage) domain.ReviewListResponse {
+	response := domain.ReviewListResponse{
+		Items: page.Reviews,
+		Total: page.Total,
+		Page:  max(query.Page, 1),
+		Limit: query.Limit,
+	}
+
+	if response.Limit == 0 {
+		response.Limit = service.DefaultPageSize
+	}
+
+	return response
+}
+
+func writeReviewError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrMovieNotFound), errors.Is(err, service.ErrReviewNotFound),
+		errors.Is(err, service.ErrVoteNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrReviewExists):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrReviewForbidden), errors.Is(err, service.ErrOwnReviewVote):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidPage), errors.Is(err, service.ErrInvalidLimit),
+		errors.Is(err, service.ErrInvalidReviewSort), errors.Is(err, service.ErrInvalidReviewStatus):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
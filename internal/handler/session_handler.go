@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"errors"
+	"movie_app/internal/domain"
+	"movie_app/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SessionHandler struct {
+	service service.SessionService
+}
+
+func NewSessionHandler(svc service.SessionService) *SessionHandler {
+	return &SessionHandler{service: svc}
+}
+
+// GetSessions godoc
+// @Summary List my sessions
+// @Description List the current user's active sessions, most recently seen first. A session is seen when
+// @Description it logs in or refreshes its tokens.
+// @Tags sessions
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} domain.Session
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/me/sessions [get]
+func (h *SessionHandler) GetSessions(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	sessionID, ok := currentSessionID(ctx)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.service.ListSessions(ctx.Request.Context(), userID, sessionID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	if sessions == nil {
+		sessions = []domain.Session{}
+	}
+
+	ctx.JSON(http.StatusOK, sessions)
+}
+
+// RevokeSession godoc
+// @Summary Revoke a session
+// @Description Sign one of the current user's sessions out. Its tokens stop working immediately.
+// @Tags sessions
+// @Security ApiKeyAuth
+// @Param id path int true "Session ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/me/sessions/{id} [delete]
+func (h *SessionHandler) RevokeSession(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+
+		return
+	}
+
+	if err := h.service.RevokeSession(ctx.Request.Context(), userID, uint(id)); err != nil {
+		writeSessionError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+// Logout godoc
+// @Summary Log out
+// @Description Revoke the session the request is made with, along with its access and refresh tokens
+// @Tags auth
+// @Security ApiKeyAuth
+// @Success 204 "No Content"
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/logout [post]
+func (h *SessionHandler) Logout(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	sessionID, ok := currentSessionID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := h.service.RevokeSession(ctx.Request.Context(), userID, sessionID); err != nil {
+		writeSessionError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+func writeSessionError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrSessionNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -2,9 +2,11 @@ package handler
 
 import (
 	"errors"
+	"math"
 	"movie_app/internal/domain"
 	"movie_app/internal/service"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -19,7 +21,7 @@ func NewUserHandler(svc service.UserService) *UserHandler {
 
 // Register godoc
 // @Summary Register a new user
-// @Description Register a new user in the system
+// @Description Register a new user in the system and email them a verification link
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -55,7 +57,8 @@ func (h *UserHandler) Register(ctx *gin.Context) {
 
 // Login godoc
 // @Summary User login
-// @Description Authenticate a user and return a JWT token
+// @Description Authenticate a user and return a short-lived JWT access token and a refresh token. Users with
+// @Description two-factor authentication get an MFA token instead, to exchange at /auth/mfa/verify.
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -63,6 +66,8 @@ func (h *UserHandler) Register(ctx *gin.Context) {
 // @Success 200 {object} domain.LoginResponse
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 429 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /auth/login [post]
 func (h *UserHandler) Login(ctx *gin.Context) {
@@ -73,14 +78,108 @@ func (h *UserHandler) Login(ctx *gin.Context) {
 		return
 	}
 
-	result, err := h.service.Login(ctx.Request.Context(), req)
+	client := clientInfo(ctx)
+	client.Device = req.Device
+
+	result, err := h.service.Login(ctx.Request.Context(), req, client)
 	if err != nil {
+		if writeLoginLocked(ctx, err) {
+			return
+		}
+
 		if errors.Is(err, service.ErrInvalidCredentials) {
 			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 
 			return
 		}
 
+		if errors.Is(err, service.ErrEmailNotVerified) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// VerifyMFA godoc
+// @Summary Complete a two-factor login
+// @Description Exchange the MFA token returned by login, along with an authenticator app code or a
+// @Description recovery code, for the access and refresh tokens
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body domain.MFAVerifyRequest true "MFA token and code"
+// @Success 200 {object} domain.LoginResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/mfa/verify [post]
+func (h *UserHandler) VerifyMFA(ctx *gin.Context) {
+	var req domain.MFAVerifyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	result, err := h.service.VerifyMFA(ctx.Request.Context(), req, clientInfo(ctx))
+	if err != nil {
+		if writeLoginLocked(ctx, err) {
+			return
+		}
+
+		if errors.Is(err, service.ErrInvalidMFAToken) ||
+			errors.Is(err, service.ErrInvalidMFACode) ||
+			errors.Is(err, service.ErrMFANotEnabled) {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// Refresh godoc
+// @Summary Refresh tokens
+// @Description Exchange a refresh token for a new access token and a new refresh token. Each refresh token
+// @Description can only be used once; using one again revokes every token issued from the same login.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param refresh body domain.RefreshRequest true "Refresh token"
+// @Success 200 {object} domain.LoginResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/refresh [post]
+func (h *UserHandler) Refresh(ctx *gin.Context) {
+	var req domain.RefreshRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	result, err := h.service.Refresh(ctx.Request.Context(), req, clientInfo(ctx))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRefreshToken) || errors.Is(err, service.ErrRefreshTokenReused) {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+
+			return
+		}
+
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 
 		return
@@ -117,3 +216,326 @@ func (h *UserHandler) GetUser(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, user)
 }
+
+// UpdateProfile godoc
+// @Summary Update user profile
+// @Description Update the current user's display name, avatar URL and preferences. Only the fields
+// @Description that are set change, and preferences are replaced as a whole.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param profile body domain.UpdateProfileRequest true "Profile changes"
+// @Success 200 {object} domain.User
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/me [patch]
+func (h *UserHandler) UpdateProfile(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req domain.UpdateProfileRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	user, err := h.service.UpdateProfile(ctx.Request.Context(), userID, req)
+	if err != nil {
+		writeUserError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, user)
+}
+
+// ChangePassword godoc
+// @Summary Change password
+// @Description Replace the current user's password, given the current one, and sign them out of
+// @Description every other session. Wrong passwords count as failed logins.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body domain.ChangePasswordRequest true "Current and new password"
+// @Success 204 "No Content"
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/me/password [post]
+func (h *UserHandler) ChangePassword(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	sessionID, ok := currentSessionID(ctx)
+	if !ok {
+		return
+	}
+
+	var req domain.ChangePasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	err := h.service.ChangePassword(ctx.Request.Context(), userID, sessionID, req, clientInfo(ctx))
+	if err != nil {
+		writeUserError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+// ChangeEmail godoc
+// @Summary Change email
+// @Description Email a verification link to the new address, given the current user's password. The
+// @Description email changes once the link is followed through /auth/verify.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body domain.ChangeEmailRequest true "New email and password"
+// @Success 202 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/me/email [post]
+func (h *UserHandler) ChangeEmail(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req domain.ChangeEmailRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	if err := h.service.ChangeEmail(ctx.Request.Context(), userID, req, clientInfo(ctx)); err != nil {
+		writeUserError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{"message": "A verification link was sent to the new email"})
+}
+
+// DeleteAccount godoc
+// @Summary Delete account
+// @Description Delete the current user's account, given their password, and sign them out everywhere.
+// @Description The account and its data are purged after the deletion grace period.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body domain.DeleteAccountRequest true "Password"
+// @Success 204 "No Content"
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/me [delete]
+func (h *UserHandler) DeleteAccount(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req domain.DeleteAccountRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	if err := h.service.DeleteAccount(ctx.Request.Context(), userID, req, clientInfo(ctx)); err != nil {
+		writeUserError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+// @Summary Get users
+// @Description Get a paginated list of users, optionally filtered by role or email (requires users:admin)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param page query int false "Page number (default 1, max 10000)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param role query string false "admin, editor or viewer"
+// @Param email query string false "Email (partial match)"
+// @Success 200 {object} domain.UserListResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/users [get]
+func (h *UserHandler) GetUsers(ctx *gin.Context) {
+	var query domain.UserQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	page, err := h.service.ListUsers(ctx.Request.Context(), query)
+	if err != nil {
+		writeUserError(ctx, err)
+
+		return
+	}
+
+	response := domain.UserListResponse{
+		Items: page.Users,
+		Total: page.Total,
+		Page:  max(query.Page, 1),
+		Limit: query.Limit,
+	}
+
+	if response.Items == nil {
+		response.Items = []domain.User{}
+	}
+
+	if response.Limit == 0 {
+		response.Limit = service.DefaultPageSize
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// @Summary Assign a role
+// @Description Assign a role to a user, effective from their next login or token refresh (requires users:admin)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param id path int true "User ID"
+// @Param role body domain.UpdateRoleRequest true "Role"
+// @Success 200 {object} domain.User
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/users/{id}/role [put]
+func (h *UserHandler) UpdateUserRole(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+
+		return
+	}
+
+	var req domain.UpdateRoleRequest
+	if bindErr := ctx.ShouldBindJSON(&req); bindErr != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": bindErr.Error()})
+
+		return
+	}
+
+	user, err := h.service.UpdateRole(ctx.Request.Context(), uint(id), req.Role)
+	if err != nil {
+		writeUserError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, user)
+}
+
+// @Summary Unlock a user
+// @Description Lift the lockout of a user's account after too many failed logins (requires users:admin)
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param id path int true "User ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/users/{id}/unlock [post]
+func (h *UserHandler) UnlockUser(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+
+		return
+	}
+
+	actorID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := h.service.UnlockUser(ctx.Request.Context(), actorID, uint(id)); err != nil {
+		writeUserError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+// writeLoginLocked writes a 429 response telling the client when to retry if
+// logins are locked out, and reports whether it did.
+func writeLoginLocked(ctx *gin.Context, err error) bool {
+	var locked *service.LoginLockedError
+	if !errors.As(err, &locked) {
+		return false
+	}
+
+	seconds := int(math.Ceil(locked.RetryAfter.Seconds()))
+	ctx.Header("Retry-After", strconv.Itoa(seconds))
+	ctx.JSON(http.StatusTooManyRequests, gin.H{"error": locked.Error()})
+
+	return true
+}
+
+func writeUserError(ctx *gin.Context, err error) {
+	if writeLoginLocked(ctx, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrWrongPassword):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrLastAdmin), errors.Is(err, service.ErrEmailTaken):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidRole), errors.Is(err, service.ErrInvalidPage),
+		errors.Is(err, service.ErrInvalidLimit), errors.Is(err, service.ErrEmailUnchanged):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"errors"
+	"movie_app/internal/domain"
+	"movie_app/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type VerificationHandler struct {
+	service service.VerificationService
+}
+
+func NewVerificationHandler(svc service.VerificationService) *VerificationHandler {
+	return &VerificationHandler{service: svc}
+}
+
+// VerifyEmail godoc
+// @Summary Verify an email address
+// @Description Verify the user's email with the token from a verification email, or change it to the
+// @Description new address for an email change. Access tokens issued before the verification keep
+// @Description treating the email as unverified until they are refreshed.
+// @Tags auth
+// @Produce json
+// @Param token query string true "Verification token"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/verify [get]
+func (h *VerificationHandler) VerifyEmail(ctx *gin.Context) {
+	token := ctx.Query("token")
+	if token == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "token is required"})
+
+		return
+	}
+
+	if err := h.service.VerifyEmail(ctx.Request.Context(), token); err != nil {
+		if errors.Is(err, service.ErrInvalidVerificationToken) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+			return
+		}
+
+		if errors.Is(err, service.ErrEmailTaken) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Email verified"})
+}
+
+// ResendVerification godoc
+// @Summary Resend the verification email
+// @Description Email a new verification link to the address, at most once per resend interval. The
+// @Description response is the same whether or not the address is registered or already verified.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body domain.ResendVerificationRequest true "Account email"
+// @Success 202 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/verify/resend [post]
+func (h *VerificationHandler) ResendVerification(ctx *gin.Context) {
+	var req domain.ResendVerificationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	if err := h.service.ResendVerification(ctx.Request.Context(), req); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to resend the verification email"})
+
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"message": "If the email is registered and unverified, a verification link has been sent to it",
+	})
+}
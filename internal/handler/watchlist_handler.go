@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"errors"
+	"movie_app/internal/domain"
+	"movie_app/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WatchlistHandler struct {
+	service service.WatchlistService
+}
+
+func NewWatchlistHandler(svc service.WatchlistService) *WatchlistHandler {
+	return &WatchlistHandler{service: svc}
+}
+
+// @Summary Get my watchlist
+// @Description Get a paginated list of the movies the current user plans to watch, most recently added first
+// @Tags watchlist
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param page query int false "Page number (default 1, max 10000)"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {object} domain.WatchlistResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/me/watchlist [get]
+func (h *WatchlistHandler) GetWatchlist(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var query domain.WatchlistQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	page, err := h.service.List(ctx.Request.Context(), userID, query)
+	if err != nil {
+		writeWatchlistError(ctx, err)
+
+		return
+	}
+
+	response := domain.WatchlistResponse{
+		Items: page.Entries,
+		Total: page.Total,
+		Page:  max(query.Page, 1),
+		Limit: query.Limit,
+	}
+
+	if response.Items == nil {
+		response.Items = []domain.WatchlistEntry{}
+	}
+
+	if response.Limit == 0 {
+		response.Limit = service.DefaultPageSize
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// @Summary Add a movie to my watchlist
+// @Description Add a movie to the current user's watchlist
+// @Tags watchlist
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param entry body domain.AddToWatchlistRequest true "Movie to add"
+// @Success 201 {object} domain.WatchlistEntry
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/me/watchlist [post]
+func (h *WatchlistHandler) AddToWatchlist(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var req domain.AddToWatchlistRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	entry, err := h.service.Add(ctx.Request.Context(), userID, req.MovieID)
+	if err != nil {
+		writeWatchlistError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, entry)
+}
+
+// @Summary Remove a movie from my watchlist
+// @Description Remove a movie from the current user's watchlist
+// @Tags watchlist
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security PersonalAPIKey
+// @Param movieId path int true "Movie ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/me/watchlist/{movieId} [delete]
+func (h *WatchlistHandler) RemoveFromWatchlist(ctx *gin.Context) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return
+	}
+
+	movieID, err := strconv.ParseUint(ctx.Param("movieId"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid movie id"})
+
+		return
+	}
+
+	if err := h.service.Remove(ctx.Request.Context(), userID, uint(movieID)); err != nil {
+		writeWatchlistError(ctx, err)
+
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+func writeWatchlistError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrMovieNotFound), errors.Is(err, service.ErrNotInWatchlist):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrAlreadyInWatchlist):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidPage), errors.Is(err, service.ErrInvalidLimit):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,64 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// LogMailer writes emails to the application log instead of sending them,
+// for local development.
+type LogMailer struct {
+	from string
+}
+
+func NewLogMailer(from string) *LogMailer {
+	return &LogMailer{from: from}
+}
+
+func (m *LogMailer) Send(_ context.Context, message Message) error {
+	log.Printf("Email from %s to %s: %s\n%s", m.from, message.To, message.Subject, message.Text)
+
+	return nil
+}
+
+// FileMailer writes every email to its own .eml file in a directory instead
+// of sending it, for local development.
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+func NewFileMailer(dir, from string) *FileMailer {
+	return &FileMailer{
+		dir:  dir,
+		from: from,
+	}
+}
+
+func (m *FileMailer) Send(_ context.Context, message Message) error {
+	body, err := encode(m.from, message)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return fmt.Errorf("creating mail directory: %w", err)
+	}
+
+	file, err := os.CreateTemp(m.dir, time.Now().Format("20060102-150405-*.eml"))
+	if err != nil {
+		return fmt.Errorf("creating mail file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(body); err != nil {
+		return fmt.Errorf("writing mail file: %w", err)
+	}
+
+	log.Printf("Email to %s written to %s", message.To, file.Name())
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// Templates renders the emails in the templates directory. Every email has a
+// NAME.txt template defining a "subject" and a "body" block, and an optional
+// NAME.html template for the HTML alternative.
+type Templates struct {
+	text map[string]*texttemplate.Template
+	html map[string]*htmltemplate.Template
+}
+
+func NewTemplates() (*Templates, error) {
+	templates := &Templates{
+		text: make(map[string]*texttemplate.Template),
+		html: make(map[string]*htmltemplate.Template),
+	}
+
+	texts, err := fs.Glob(templateFS, "templates/*.txt")
+	if err != nil {
+		return nil, fmt.Errorf("listing email templates: %w", err)
+	}
+
+	// Parsed one by one, as every email defines the same blocks
+	for _, file := range texts {
+		name := strings.TrimSuffix(path.Base(file), ".txt")
+
+		text, err := texttemplate.ParseFS(templateFS, file)
+		if err != nil {
+			return nil, fmt.Errorf("parsing email template %s: %w", file, err)
+		}
+
+		if text.Lookup("subject") == nil || text.Lookup("body") == nil {
+			return nil, fmt.Errorf("email template %s must define subject and body", file)
+		}
+
+		templates.text[name] = text
+
+		// The HTML alternative is optional
+		htmlFile := "templates/" + name + ".html"
+		if _, err := fs.Stat(templateFS, htmlFile); err != nil {
+			continue
+		}
+
+		html, err := htmltemplate.ParseFS(templateFS, htmlFile)
+		if err != nil {
+			return nil, fmt.Errorf("parsing email template %s: %w", htmlFile, err)
+		}
+
+		templates.html[name] = html
+	}
+
+	return templates, nil
+}
+
+// Render renders the named email for the recipient.
+func (t *Templates) Render(name, to string, data any) (Message, error) {
+	text, ok := t.text[name]
+	if !ok {
+		return Message{}, fmt.Errorf("unknown email template %q", name)
+	}
+
+	var subject, body, html bytes.Buffer
+
+	if err := text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, fmt.Errorf("rendering %s subject: %w", name, err)
+	}
+
+	if err := text.ExecuteTemplate(&body, "body", data); err != nil {
+		return Message{}, fmt.Errorf("rendering %s body: %w", name, err)
+	}
+
+	message := Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(body.String()) + "\n",
+	}
+
+	if tmpl, ok := t.html[name]; ok {
+		if err := tmpl.Execute(&html, data); err != nil {
+			return Message{}, fmt.Errorf("rendering %s HTML body: %w", name, err)
+		}
+
+		message.HTML = html.String()
+	}
+
+	return message, nil
+}
@@ -1,73 +1,191 @@
 package middleware
 
 import (
+	"context"
+	"errors"
+	"movie_app/internal/domain"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
-	"errors"
 )
 
+// SessionDenylist reports whether a session was revoked. It is consulted on
+// every request, so it must not hit the database.
+type SessionDenylist interface {
+	Denied(sessionID uint) bool
+}
+
+// APIKeyAuthenticator looks up the active API key a request is made with,
+// along with its owner.
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (*domain.APIKey, error)
+}
+
+// AuthOptions are the token properties Authenticate insists on.
+type AuthOptions struct {
+	Algorithms []string
+	Issuer     string
+	Audience   string
+	// What RequireVerifiedEmail keeps users with unverified emails from doing
+	VerificationPolicy domain.VerificationPolicy
+}
+
 type AuthMiddleware struct {
-	jwtKey string
+	keyFunc  jwt.Keyfunc
+	denylist SessionDenylist
+	apiKeys  APIKeyAuthenticator
+	options  AuthOptions
 }
 
-func NewAuthMiddleware(jwtKey string) *AuthMiddleware {
+func NewAuthMiddleware(
+	keyFunc jwt.Keyfunc, denylist SessionDenylist, apiKeys APIKeyAuthenticator, options AuthOptions,
+) *AuthMiddleware {
 	return &AuthMiddleware{
-		jwtKey: jwtKey,
+		keyFunc:  keyFunc,
+		denylist: denylist,
+		apiKeys:  apiKeys,
+		options:  options,
 	}
 }
 
 var (
-	ErrMissingToken     = errors.New("missing authorization token")
-	ErrInvalidAuthHeader = errors.New("invalid authorization header")
+	ErrMissingToken       = errors.New("missing authorization token")
+	ErrInvalidAuthHeader  = errors.New("invalid authorization header")
 	ErrInvalidTokenFormat = errors.New("invalid token format")
-	ErrUnauthorized      = errors.New("unauthorized")
+	ErrUnauthorized       = errors.New("unauthorized")
+	ErrSessionRevoked     = errors.New("session has been revoked")
+	ErrInvalidAPIKey      = errors.New("invalid API key")
+	ErrAPIKeyScope        = errors.New("API key is not scoped to this request")
 )
 
+// Authenticate only lets through requests with a valid access token, and
+// sets the user_id, session_id, role and email_verified context values.
 func (m *AuthMiddleware) Authenticate() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		authHeader := ctx.GetHeader("Authorization")
-		if authHeader == "" {
-			ctx.JSON(http.StatusUnauthorized, gin.H{"error": ErrMissingToken.Error()})
-			ctx.Abort()
-			return
+		if m.authenticateToken(ctx) {
+			ctx.Next()
 		}
+	}
+}
+
+// AuthenticateWithAPIKey is Authenticate, but also lets through requests
+// with a valid API key in the X-API-Key header instead of an access token.
+// For those it sets the api_key_id and scopes context values instead of
+// session_id, and rejects reads unless the key is scoped to read and other
+// requests unless it is scoped to write.
+func (m *AuthMiddleware) AuthenticateWithAPIKey() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := ctx.GetHeader("X-API-Key")
+		if key == "" {
+			if m.authenticateToken(ctx) {
+				ctx.Next()
+			}
 
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			ctx.JSON(http.StatusUnauthorized, gin.H{"error": ErrInvalidAuthHeader.Error()})
-			ctx.Abort()
 			return
 		}
 
-		tokenString := parts[1]
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			return []byte(m.jwtKey), nil
-		})
-
-		if err != nil || !token.Valid {
-			ctx.JSON(http.StatusUnauthorized, gin.H{"error": ErrUnauthorized.Error()})
+		apiKey, err := m.apiKeys.AuthenticateAPIKey(ctx.Request.Context(), key)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": ErrInvalidAPIKey.Error()})
 			ctx.Abort()
 			return
 		}
 
-		claims, isValid := token.Claims.(jwt.MapClaims)
-		if !isValid {
-			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
-			ctx.Abort()
-			return
+		actor := domain.Actor{UserID: apiKey.UserID, Role: apiKey.User.Role, Scopes: apiKey.Scopes}
+
+		required := domain.PermWrite
+		if ctx.Request.Method == http.MethodGet || ctx.Request.Method == http.MethodHead {
+			required = domain.PermRead
 		}
 
-		userID, isValid := claims["user_id"].(float64)
-		if !isValid {
-			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user ID in token"})
+		if !actor.Can(required) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": ErrAPIKeyScope.Error()})
 			ctx.Abort()
 			return
 		}
 
-		ctx.Set("user_id", uint(userID))
+		ctx.Set("user_id", apiKey.UserID)
+		ctx.Set("api_key_id", apiKey.ID)
+		ctx.Set("role", apiKey.User.Role)
+		ctx.Set("scopes", apiKey.Scopes)
+		ctx.Set("email_verified", apiKey.User.EmailVerified())
 		ctx.Next()
 	}
 }
+
+// authenticateToken checks the access token in the Authorization header and
+// sets the context values, or writes a 401 response and aborts. It reports
+// whether the token was valid.
+func (m *AuthMiddleware) authenticateToken(ctx *gin.Context) bool {
+	authHeader := ctx.GetHeader("Authorization")
+	if authHeader == "" {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": ErrMissingToken.Error()})
+		ctx.Abort()
+		return false
+	}
+
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": ErrInvalidAuthHeader.Error()})
+		ctx.Abort()
+		return false
+	}
+
+	tokenString := parts[1]
+	token, err := jwt.Parse(
+		tokenString,
+		m.keyFunc,
+		jwt.WithValidMethods(m.options.Algorithms),
+		jwt.WithIssuer(m.options.Issuer),
+		jwt.WithAudience(m.options.Audience),
+		jwt.WithExpirationRequired(),
+	)
+
+	if err != nil || !token.Valid {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": ErrUnauthorized.Error()})
+		ctx.Abort()
+		return false
+	}
+
+	claims, isValid := token.Claims.(jwt.MapClaims)
+	if !isValid {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
+		ctx.Abort()
+		return false
+	}
+
+	userID, isValid := claims["user_id"].(float64)
+	if !isValid {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user ID in token"})
+		ctx.Abort()
+		return false
+	}
+
+	sessionID, isValid := claims["sid"].(float64)
+	if !isValid {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid session ID in token"})
+		ctx.Abort()
+		return false
+	}
+
+	if m.denylist.Denied(uint(sessionID)) {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": ErrSessionRevoked.Error()})
+		ctx.Abort()
+		return false
+	}
+
+	role, _ := claims["role"].(string)
+
+	// A token without the claim is treated as unverified, so that it cannot
+	// get around the verification policy
+	emailVerified, _ := claims["email_verified"].(bool)
+
+	ctx.Set("user_id", uint(userID))
+	ctx.Set("session_id", uint(sessionID))
+	ctx.Set("role", domain.Role(role))
+	ctx.Set("email_verified", emailVerified)
+
+	return true
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"errors"
+	"movie_app/internal/domain"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	ErrForbidden        = errors.New("forbidden")
+	ErrEmailNotVerified = errors.New("email address has not been verified")
+)
+
+// RequirePermission only lets through users whose role grants every one of
+// the permissions, and whose API key, if any, is scoped to them. It must run
+// after AuthMiddleware.Authenticate.
+func RequirePermission(permissions ...domain.Permission) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		role, _ := ctx.Get("role")
+		scopes, _ := ctx.Get("scopes")
+		userRole, _ := role.(domain.Role)
+		keyScopes, _ := scopes.([]domain.Permission)
+		actor := domain.Actor{Role: userRole, Scopes: keyScopes}
+
+		for _, permission := range permissions {
+			if !actor.Can(permission) {
+				ctx.JSON(http.StatusForbidden, gin.H{"error": ErrForbidden.Error()})
+				ctx.Abort()
+
+				return
+			}
+		}
+
+		ctx.Next()
+	}
+}
+
+// RequireVerifiedEmail applies the verification policy: it rejects every
+// request of users with unverified emails when the policy is to verify before
+// logging in, and their write requests when it is to verify before writing.
+// It must run after Authenticate.
+func (m *AuthMiddleware) RequireVerifiedEmail() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		verified, _ := ctx.Get("email_verified")
+		if emailVerified, _ := verified.(bool); emailVerified {
+			ctx.Next()
+
+			return
+		}
+
+		readOnly := ctx.Request.Method == http.MethodGet || ctx.Request.Method == http.MethodHead
+
+		switch m.options.VerificationPolicy {
+		case domain.VerifyForLogin:
+			ctx.JSON(http.StatusForbidden, gin.H{"error": ErrEmailNotVerified.Error()})
+			ctx.Abort()
+
+			return
+		case domain.VerifyForWrite:
+			if !readOnly {
+				ctx.JSON(http.StatusForbidden, gin.H{"error": ErrEmailNotVerified.Error()})
+				ctx.Abort()
+
+				return
+			}
+		}
+
+		ctx.Next()
+	}
+}
@@ -0,0 +1,118 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movie_app/internal/config"
+	"net/http"
+	"sync"
+	"time"
+
+	gooidc "github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+const httpTimeout = 10 * time.Second
+
+var ErrInvalidIDToken = errors.New("identity provider returned an invalid ID token")
+
+// DiscoveryProvider is an identity provider whose endpoints and signing keys
+// are discovered from its issuer URL. Discovery happens on first use and is
+// retried until it succeeds, so that the API starts while the provider is
+// down.
+type DiscoveryProvider struct {
+	config config.OIDCConfig
+	client *http.Client
+
+	mu       sync.Mutex
+	provider *gooidc.Provider
+}
+
+func NewDiscoveryProvider(cfg config.OIDCConfig) *DiscoveryProvider {
+	return &DiscoveryProvider{
+		config: cfg,
+		client: &http.Client{Timeout: httpTimeout},
+	}
+}
+
+func (p *DiscoveryProvider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	oauth, _, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return oauth.AuthCodeURL(state, gooidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier)), nil
+}
+
+func (p *DiscoveryProvider) Exchange(ctx context.Context, code, verifier, nonce string) (*Claims, error) {
+	oauth, provider, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = gooidc.ClientContext(ctx, p.client)
+
+	token, err := oauth.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("exchanging code: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, ErrInvalidIDToken
+	}
+
+	idToken, err := provider.Verifier(&gooidc.Config{ClientID: p.config.ClientID}).Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidIDToken, err)
+	}
+
+	if idToken.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce does not match", ErrInvalidIDToken)
+	}
+
+	var claims struct {
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		Name          string `json:"name"`
+	}
+
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidIDToken, err)
+	}
+
+	return &Claims{
+		Issuer:        idToken.Issuer,
+		Subject:       idToken.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Name:          claims.Name,
+	}, nil
+}
+
+// discover returns the OAuth 2.0 client for the provider along with the
+// provider, discovering it first if needed.
+func (p *DiscoveryProvider) discover(ctx context.Context) (*oauth2.Config, *gooidc.Provider, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.provider == nil {
+		provider, err := gooidc.NewProvider(gooidc.ClientContext(ctx, p.client), p.config.Issuer)
+		if err != nil {
+			return nil, nil, fmt.Errorf("discovering identity provider: %w", err)
+		}
+
+		p.provider = provider
+	}
+
+	oauth := &oauth2.Config{
+		ClientID:     p.config.ClientID,
+		ClientSecret: p.config.ClientSecret,
+		RedirectURL:  p.config.RedirectURL,
+		Endpoint:     p.provider.Endpoint(),
+		Scopes:       append([]string{gooidc.ScopeOpenID}, p.config.Scopes...),
+	}
+
+	return oauth, p.provider, nil
+}
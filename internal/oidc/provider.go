@@ -0,0 +1,51 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"movie_app/internal/config"
+)
+
+// ErrDisabled is returned by the provider when no identity provider is
+// configured.
+var ErrDisabled = errors.New("single sign-on is not configured")
+
+// Claims are what the identity provider asserts about the user who logged in.
+type Claims struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// Provider is an OpenID Connect identity provider that users log in with
+// through the authorization code flow with PKCE.
+type Provider interface {
+	// AuthCodeURL returns where to send the user to log in. The provider
+	// sends them back to the redirect URL with the state and a code.
+	AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error)
+	// Exchange redeems the code for the user's verified ID token, which must
+	// carry the nonce, and returns its claims.
+	Exchange(ctx context.Context, code, verifier, nonce string) (*Claims, error)
+}
+
+// NewProvider returns the identity provider selected by the OIDC_ISSUER
+// setting.
+func NewProvider(cfg *config.Config) Provider {
+	if cfg.OIDC.Issuer == "" {
+		return disabledProvider{}
+	}
+
+	return NewDiscoveryProvider(cfg.OIDC)
+}
+
+type disabledProvider struct{}
+
+func (disabledProvider) AuthCodeURL(context.Context, string, string, string) (string, error) {
+	return "", ErrDisabled
+}
+
+func (disabledProvider) Exchange(context.Context, string, string, string) (*Claims, error) {
+	return nil, ErrDisabled
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movie_app/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrCreateAPIKey = errors.New("failed to create API key")
+	ErrListAPIKeys  = errors.New("failed to list API keys")
+	ErrRevokeAPIKey = errors.New("failed to revoke API key")
+)
+
+// apiKeyTouchInterval is how stale a key's last use may get before it is
+// updated, so that busy keys do not write on every request.
+const apiKeyTouchInterval = time.Minute
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *domain.APIKey) error
+	ListActive(ctx context.Context, userID uint) ([]domain.APIKey, error)
+	GetByHash(ctx context.Context, keyHash string) (*domain.APIKey, error)
+	Revoke(ctx context.Context, userID, id uint) (*domain.APIKey, error)
+	Touch(ctx context.Context, id uint, now time.Time) error
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) *apiKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *domain.APIKey) error {
+	if err := r.db.WithContext(ctx).Create(key).Error; err != nil {
+		return ErrCreateAPIKey
+	}
+
+	return nil
+}
+
+// ListActive returns the user's keys that are neither revoked nor expired,
+// newest first.
+func (r *apiKeyRepository) ListActive(ctx context.Context, userID uint) ([]domain.APIKey, error) {
+	var keys []domain.APIKey
+
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", userID, time.Now()).
+		Order("id DESC").
+		Find(&keys).Error
+	if err != nil {
+		return nil, ErrListAPIKeys
+	}
+
+	return keys, nil
+}
+
+// GetByHash returns the key with the hash along with its owner, unless the
+// owner deleted their account.
+func (r *apiKeyRepository) GetByHash(ctx context.Context, keyHash string) (*domain.APIKey, error) {
+	var key domain.APIKey
+
+	err := r.db.WithContext(ctx).InnerJoins("User").Where("key_hash = ?", keyHash).First(&key).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	return &key, nil
+}
+
+// Revoke disables the user's active key for good.
+func (r *apiKeyRepository) Revoke(ctx context.Context, userID, id uint) (*domain.APIKey, error) {
+	var key domain.APIKey
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+			First(&key).Error
+		if err != nil {
+			return fmt.Errorf("failed to get API key: %w", err)
+		}
+
+		now := time.Now()
+		key.RevokedAt = &now
+
+		if err := tx.Model(&key).Update("revoked_at", now).Error; err != nil {
+			return ErrRevokeAPIKey
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+// Touch records that the key was used at the time, unless it was already
+// recorded as used shortly before.
+func (r *apiKeyRepository) Touch(ctx context.Context, id uint, now time.Time) error {
+	return r.db.WithContext(ctx).Model(&domain.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, now.Add(-apiKeyTouchInterval)).
+		Update("last_used_at", now).Error
+}
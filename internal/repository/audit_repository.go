@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"movie_app/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+var ErrCreateAuditEntry = errors.New("failed to create audit entry")
+
+type AuditRepository interface {
+	Create(ctx context.Context, entry *domain.AuditEntry) error
+}
+
+type auditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) *auditRepository {
+	return &auditRepository{db: db}
+}
+
+func (r *auditRepository) Create(ctx context.Context, entry *domain.AuditEntry) error {
+	if err := r.db.WithContext(ctx).Create(entry).Error; err != nil {
+		return ErrCreateAuditEntry
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movie_app/internal/domain"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCreateCredit = errors.New("failed to create credit")
+	ErrUpdateCredit = errors.New("failed to update credit")
+	ErrDeleteCredit = errors.New("failed to delete credit")
+)
+
+type CreditRepository interface {
+	Create(ctx context.Context, credit *domain.Credit) (*domain.Credit, error)
+	GetByID(ctx context.Context, id uint) (*domain.Credit, error)
+	GetByMovie(ctx context.Context, movieID uint) ([]domain.Credit, error)
+	Update(ctx context.Context, credit *domain.Credit) (*domain.Credit, error)
+	Delete(ctx context.Context, id uint) error
+}
+
+type creditRepository struct {
+	db *gorm.DB
+}
+
+func NewCreditRepository(db *gorm.DB) *creditRepository {
+	return &creditRepository{db: db}
+}
+
+func (r *creditRepository) Create(ctx context.Context, credit *domain.Credit) (*domain.Credit, error) {
+	if err := r.db.WithContext(ctx).Omit("Movie", "Person").Create(credit).Error; err != nil {
+		return nil, ErrCreateCredit
+	}
+
+	return r.GetByID(ctx, credit.ID)
+}
+
+func (r *creditRepository) GetByID(ctx context.Context, id uint) (*domain.Credit, error) {
+	var credit domain.Credit
+	if err := r.db.WithContext(ctx).Preload("Person").First(&credit, id).Error; err != nil {
+		return nil, fmt.Errorf("failed to get credit: %w", err)
+	}
+
+	return &credit, nil
+}
+
+func (r *creditRepository) GetByMovie(ctx context.Context, movieID uint) ([]domain.Credit, error) {
+	var credits []domain.Credit
+
+	err := r.db.WithContext(ctx).
+		Preload("Person").
+		Where("movie_id = ?", movieID).
+		Scopes(orderCredits).
+		Find(&credits).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credits: %w", err)
+	}
+
+	return credits, nil
+}
+
+func (r *creditRepository) Update(ctx context.Context, credit *domain.Credit) (*domain.Credit, error) {
+	if err := r.db.WithContext(ctx).Omit("Movie", "Person").Save(credit).Error; err != nil {
+		return nil, ErrUpdateCredit
+	}
+
+	return r.GetByID(ctx, credit.ID)
+}
+
+func (r *creditRepository) Delete(ctx context.Context, id uint) error {
+	if err := r.db.WithContext(ctx).Delete(&domain.Credit{}, id).Error; err != nil {
+		return ErrDeleteCredit
+	}
+
+	return nil
+}
+
+// orderCredits lists directors first, then the rest by department and billing.
+func orderCredits(db *gorm.DB) *gorm.DB {
+	return db.
+		Order("credits.department <> '" + domain.DepartmentDirecting + "'").
+		Order("credits.department").
+		Order("credits.billing_order").
+		Order("credits.id")
+}
+
+// saveCredits creates the credits given along with a new movie.
+func saveCredits(tx *gorm.DB, movie *domain.Movie) error {
+	for i := range movie.Credits {
+		credit := &movie.Credits[i]
+		credit.MovieID = movie.ID
+
+		if err := tx.Omit("Movie", "Person").Create(credit).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// directorSeparator splits co-directors such as "Joel Coen, Ethan Coen".
+var directorSeparator = regexp.MustCompile(`\s*(?:,|&|/|;)\s*`)
+
+// syncDirectorCredits replaces the movie's Director credits when its
+// director names changed since the last save.
+func syncDirectorCredits(tx *gorm.DB, movie *domain.Movie) error {
+	var previous string
+
+	err := tx.Model(&domain.Movie{}).Select("director").Where("id = ?", movie.ID).Scan(&previous).Error
+	if err != nil {
+		return err
+	}
+
+	if previous == movie.Director {
+		return nil
+	}
+
+	err = tx.Where("movie_id = ? AND department = ? AND job = ?", movie.ID, domain.DepartmentDirecting, domain.JobDirector).
+		Delete(&domain.Credit{}).Error
+	if err != nil {
+		return err
+	}
+
+	return createDirectorCredits(tx, movie.ID, movie.Director)
+}
+
+// createDirectorCredits credits every name in a director string as Director
+// of the movie, creating the people that don't exist yet.
+func createDirectorCredits(tx *gorm.DB, movieID uint, director string) error {
+	for order, name := range directorSeparator.Split(director, -1) {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+
+		person, err := findOrCreatePerson(tx, name)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where(domain.Credit{
+			MovieID:    movieID,
+			PersonID:   person.ID,
+			Department: domain.DepartmentDirecting,
+			Job:        domain.JobDirector,
+		}).Attrs(domain.Credit{BillingOrder: order}).
+			FirstOrCreate(&domain.Credit{}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
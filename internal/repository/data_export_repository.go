@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"movie_app/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCreateDataExport = errors.New("failed to create data export")
+	ErrUpdateDataExport = errors.New("failed to update data export")
+)
+
+type DataExportRepository interface {
+	Create(ctx context.Context, export *domain.DataExport) error
+	GetLatest(ctx context.Context, userID uint) (*domain.DataExport, error)
+	Claim(ctx context.Context, staleBefore time.Time) (*domain.DataExport, error)
+	Complete(ctx context.Context, export *domain.DataExport) error
+	ListExpired(ctx context.Context, now time.Time) ([]domain.DataExport, error)
+	MarkExpired(ctx context.Context, id uint) error
+	CollectUserData(ctx context.Context, userID uint) (*domain.UserData, error)
+}
+
+type dataExportRepository struct {
+	db *gorm.DB
+}
+
+func NewDataExportRepository(db *gorm.DB) *dataExportRepository {
+	return &dataExportRepository{db: db}
+}
+
+func (r *dataExportRepository) Create(ctx context.Context, export *domain.DataExport) error {
+	if err := r.db.WithContext(ctx).Create(export).Error; err != nil {
+		return ErrCreateDataExport
+	}
+
+	return nil
+}
+
+// GetLatest returns the user's most recently requested export.
+func (r *dataExportRepository) GetLatest(ctx context.Context, userID uint) (*domain.DataExport, error) {
+	var export domain.DataExport
+
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("id DESC").First(&export).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data export: %w", err)
+	}
+
+	return &export, nil
+}
+
+// Claim marks the oldest pending export as processing and returns it, or
+// gorm.ErrRecordNotFound when there is none. Exports that started processing
+// before the stale time are claimed again, as their worker has likely
+// stopped. Concurrent workers never claim the same export.
+func (r *dataExportRepository) Claim(ctx context.Context, staleBefore time.Time) (*domain.DataExport, error) {
+	var export domain.DataExport
+
+	result := r.db.WithContext(ctx).Raw(`
+		UPDATE data_exports SET status = @processing, started_at = @now
+		WHERE id = (
+			SELECT id FROM data_exports
+			WHERE status = @pending OR (status = @processing AND started_at < @staleBefore)
+			ORDER BY id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		map[string]any{
+			"pending":     domain.DataExportPending,
+			"processing":  domain.DataExportProcessing,
+			"now":         time.Now(),
+			"staleBefore": staleBefore,
+		},
+	).Scan(&export)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to claim data export: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return &export, nil
+}
+
+// Complete records the outcome of building the export.
+func (r *dataExportRepository) Complete(ctx context.Context, export *domain.DataExport) error {
+	err := r.db.WithContext(ctx).
+		Model(export).
+		Select("status", "file_name", "size", "error", "completed_at", "expires_at").
+		Updates(export).Error
+	if err != nil {
+		return ErrUpdateDataExport
+	}
+
+	return nil
+}
+
+// ListExpired returns the ready exports whose archives expired by the time.
+func (r *dataExportRepository) ListExpired(ctx context.Context, now time.Time) ([]domain.DataExport, error) {
+	var exports []domain.DataExport
+
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND expires_at <= ?", domain.DataExportReady, now).
+		Find(&exports).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired data exports: %w", err)
+	}
+
+	return exports, nil
+}
+
+func (r *dataExportRepository) MarkExpired(ctx context.Context, id uint) error {
+	err := r.db.WithContext(ctx).
+		Model(&domain.DataExport{ID: id}).
+		Update("status", domain.DataExportExpired).Error
+	if err != nil {
+		return ErrUpdateDataExport
+	}
+
+	return nil
+}
+
+// CollectUserData loads everything stored about the user in one consistent
+// snapshot.
+func (r *dataExportRepository) CollectUserData(ctx context.Context, userID uint) (*domain.UserData, error) {
+	data := domain.UserData{MovieTitles: make(map[uint]string)}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&data.User, userID).Error; err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+
+		byUser := tx.Where("user_id = ?", userID).Order("id")
+
+		for _, rows := range []any{
+			&data.Ratings,
+			&data.Reviews,
+			&data.ReviewVotes,
+			&data.Watchlist,
+			&data.Diary,
+			&data.Sessions,
+			&data.Identities,
+			&data.APIKeys,
+		} {
+			if err := byUser.Session(&gorm.Session{}).Find(rows).Error; err != nil {
+				return fmt.Errorf("failed to load user data: %w", err)
+			}
+		}
+
+		err := tx.Where("owner_id = ?", userID).
+			Preload("Collaborators").
+			Order("id").
+			Find(&data.Lists).Error
+		if err != nil {
+			return fmt.Errorf("failed to load lists: %w", err)
+		}
+
+		lists := tx.Model(&domain.CuratedList{}).Select("id").Where("owner_id = ?", userID)
+
+		err = tx.Where("list_id IN (?)", lists).
+			Order("list_id").
+			Order("position").
+			Find(&data.ListEntries).Error
+		if err != nil {
+			return fmt.Errorf("failed to load list entries: %w", err)
+		}
+
+		err = tx.Where("user_id = ? OR actor_id = ?", userID, userID).
+			Order("id").
+			Find(&data.AuditEntries).Error
+		if err != nil {
+			return fmt.Errorf("failed to load audit entries: %w", err)
+		}
+
+		ids := movieIDs(&data)
+		if len(ids) == 0 {
+			return nil
+		}
+
+		var movies []domain.Movie
+		if err := tx.Unscoped().Select("id", "title").Where("id IN ?", ids).Find(&movies).Error; err != nil {
+			return fmt.Errorf("failed to load movie titles: %w", err)
+		}
+
+		for _, movie := range movies {
+			data.MovieTitles[movie.ID] = movie.Title
+		}
+
+		return nil
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+
+	return &data, nil
+}
+
+// movieIDs returns the IDs of the movies the user data refers to.
+func movieIDs(data *domain.UserData) []uint {
+	var ids []uint
+
+	for _, rating := range data.Ratings {
+		ids = append(ids, rating.MovieID)
+	}
+
+	for _, review := range data.Reviews {
+		ids = append(ids, review.MovieID)
+	}
+
+	for _, entry := range data.ListEntries {
+		ids = append(ids, entry.MovieID)
+	}
+
+	for _, entry := range data.Watchlist {
+		ids = append(ids, entry.MovieID)
+	}
+
+	for _, entry := range data.Diary {
+		ids = append(ids, entry.MovieID)
+	}
+
+	return ids
+}
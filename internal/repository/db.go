@@ -17,10 +17,42 @@ func NewDatabase(cfg *config.Config) (*gorm.DB, error) {
 	}
 
 	// Apply auto-migrations for schema updates
-	if err := db.AutoMigrate(&domain.Movie{}, &domain.User{}); err != nil {
+	if err := db.AutoMigrate(
+		&domain.Genre{},
+		&domain.Movie{},
+		&domain.User{},
+		&domain.Person{},
+		&domain.Credit{},
+		&domain.SigningKey{},
+		&domain.Session{},
+		&domain.RefreshToken{},
+		&domain.PasswordResetToken{},
+		&domain.EmailVerificationToken{},
+		&domain.TOTPSecret{},
+		&domain.RecoveryCode{},
+		&domain.LoginThrottle{},
+		&domain.AuditEntry{},
+		&domain.DataExport{},
+		&domain.APIKey{},
+		&domain.UserIdentity{},
+		&domain.OIDCLoginState{},
+		&domain.UserRating{},
+		&domain.Review{},
+		&domain.ReviewVote{},
+		&domain.WatchlistEntry{},
+		&domain.DiaryEntry{},
+		&domain.CuratedList{},
+		&domain.ListEntry{},
+		&domain.ListCollaborator{},
+	); err != nil {
 		return nil, fmt.Errorf("failed to auto-migrate database: %w", err)
 	}
 
+	// Apply migrations for changes that auto-migrate can't express
+	if err := runMigrations(db); err != nil {
+		return nil, err
+	}
+
 	// Get the underlying SQL database connection
 	sqlDB, err := db.DB()
 	if err != nil {
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movie_app/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCreateDiaryEntry = errors.New("failed to create diary entry")
+	ErrUpdateDiaryEntry = errors.New("failed to update diary entry")
+	ErrDeleteDiaryEntry = errors.New("failed to delete diary entry")
+)
+
+type DiaryRepository interface {
+	Create(ctx context.Context, entry *domain.DiaryEntry) (*domain.DiaryEntry, error)
+	GetByID(ctx context.Context, userID, id uint) (*domain.DiaryEntry, error)
+	HasWatched(ctx context.Context, userID, movieID uint) (bool, error)
+	List(ctx context.Context, userID uint, query domain.DiaryQuery) (*domain.DiaryPage, error)
+	Update(ctx context.Context, entry *domain.DiaryEntry) (*domain.DiaryEntry, error)
+	Delete(ctx context.Context, userID, id uint) error
+}
+
+type diaryRepository struct {
+	db *gorm.DB
+}
+
+func NewDiaryRepository(db *gorm.DB) *diaryRepository {
+	return &diaryRepository{db: db}
+}
+
+// Create logs the viewing and takes the movie off the user's watchlist in the
+// same transaction.
+func (r *diaryRepository) Create(ctx context.Context, entry *domain.DiaryEntry) (*domain.DiaryEntry, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Movie").Create(entry).Error; err != nil {
+			return ErrCreateDiaryEntry
+		}
+
+		err := tx.Where("user_id = ? AND movie_id = ?", entry.UserID, entry.MovieID).
+			Delete(&domain.WatchlistEntry{}).Error
+		if err != nil {
+			return ErrCreateDiaryEntry
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetByID(ctx, entry.UserID, entry.ID)
+}
+
+func (r *diaryRepository) GetByID(ctx context.Context, userID, id uint) (*domain.DiaryEntry, error) {
+	var entry domain.DiaryEntry
+
+	err := r.db.WithContext(ctx).
+		Preload("Movie.Genres").
+		Where("user_id = ?", userID).
+		First(&entry, id).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get diary entry: %w", err)
+	}
+
+	return &entry, nil
+}
+
+func (r *diaryRepository) HasWatched(ctx context.Context, userID, movieID uint) (bool, error) {
+	var count int64
+
+	err := r.db.WithContext(ctx).Model(&domain.DiaryEntry{}).
+		Where("user_id = ? AND movie_id = ?", userID, movieID).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to check diary: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+func (r *diaryRepository) List(ctx context.Context, userID uint, query domain.DiaryQuery) (*domain.DiaryPage, error) {
+	db := r.db.WithContext(ctx).Model(&domain.DiaryEntry{}).
+		Where("user_id = ? AND movie_id IN (?)", userID, activeMovieIDs(r.db))
+
+	if query.MovieID != 0 {
+		db = db.Where("movie_id = ?", query.MovieID)
+	}
+
+	if query.From != "" {
+		db = db.Where("watched_on >= ?", query.From)
+	}
+
+	if query.To != "" {
+		db = db.Where("watched_on <= ?", query.To)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count diary entries: %w", err)
+	}
+
+	var entries []domain.DiaryEntry
+
+	err := db.Preload("Movie.Genres").
+		Order("watched_on DESC").Order("id DESC").
+		Offset((query.Page - 1) * query.Limit).
+		Limit(query.Limit).
+		Find(&entries).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list diary entries: %w", err)
+	}
+
+	return &domain.DiaryPage{Entries: entries, Total: total}, nil
+}
+
+func (r *diaryRepository) Update(ctx context.Context, entry *domain.DiaryEntry) (*domain.DiaryEntry, error) {
+	if err := r.db.WithContext(ctx).Omit("Movie").Save(entry).Error; err != nil {
+		return nil, ErrUpdateDiaryEntry
+	}
+
+	return r.GetByID(ctx, entry.UserID, entry.ID)
+}
+
+func (r *diaryRepository) Delete(ctx context.Context, userID, id uint) error {
+	result := r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&domain.DiaryEntry{}, id)
+	if result.Error != nil {
+		return ErrDeleteDiaryEntry
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movie_app/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrCreateEmailVerification = errors.New("failed to create email verification token")
+
+	// ErrEmailVerificationInvalid is returned for unknown, expired and used
+	// tokens, and for tokens of an address the user no longer has.
+	ErrEmailVerificationInvalid = errors.New("email verification token is invalid")
+
+	// ErrEmailTaken is returned when verifying an email change to an address
+	// another user has taken in the meantime.
+	ErrEmailTaken = errors.New("email is taken by another user")
+)
+
+type EmailVerificationRepository interface {
+	Create(ctx context.Context, token *domain.EmailVerificationToken) error
+	LastCreatedAt(ctx context.Context, userID uint) (*time.Time, error)
+	Verify(ctx context.Context, tokenHash string) (uint, error)
+}
+
+type emailVerificationRepository struct {
+	db *gorm.DB
+}
+
+func NewEmailVerificationRepository(db *gorm.DB) *emailVerificationRepository {
+	return &emailVerificationRepository{db: db}
+}
+
+// Create stores the token. A token for an email change supersedes the
+// user's outstanding ones, so that only the latest requested address can
+// become their email.
+func (r *emailVerificationRepository) Create(ctx context.Context, token *domain.EmailVerificationToken) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if token.EmailChange {
+			err := tx.Model(&domain.EmailVerificationToken{}).
+				Where("user_id = ? AND email_change AND used_at IS NULL", token.UserID).
+				Update("used_at", time.Now()).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Create(token).Error
+	})
+	if err != nil {
+		return ErrCreateEmailVerification
+	}
+
+	return nil
+}
+
+// LastCreatedAt returns when the user's latest token was created, or nil
+// when the user has none.
+func (r *emailVerificationRepository) LastCreatedAt(ctx context.Context, userID uint) (*time.Time, error) {
+	var createdAt *time.Time
+
+	err := r.db.WithContext(ctx).
+		Model(&domain.EmailVerificationToken{}).
+		Where("user_id = ?", userID).
+		Select("MAX(created_at)").
+		Scan(&createdAt).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email verification token: %w", err)
+	}
+
+	return createdAt, nil
+}
+
+// Verify marks the email of the user the token belongs to as verified, or
+// for an email change replaces it with the verified address, and uses up the
+// token along with every other outstanding token of the user. It returns the
+// user's ID.
+func (r *emailVerificationRepository) Verify(ctx context.Context, tokenHash string) (uint, error) {
+	var token domain.EmailVerificationToken
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", tokenHash).
+			First(&token).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrEmailVerificationInvalid
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to get email verification token: %w", err)
+		}
+
+		now := time.Now()
+
+		if token.UsedAt != nil || !token.ExpiresAt.After(now) {
+			return ErrEmailVerificationInvalid
+		}
+
+		var result *gorm.DB
+
+		if token.EmailChange {
+			var taken int64
+
+			err := tx.Unscoped().Model(&domain.User{}).
+				Where("email = ? AND id <> ?", token.Email, token.UserID).
+				Count(&taken).Error
+			if err != nil {
+				return fmt.Errorf("failed to count users: %w", err)
+			}
+
+			if taken > 0 {
+				return ErrEmailTaken
+			}
+
+			result = tx.Model(&domain.User{}).
+				Where("id = ?", token.UserID).
+				Updates(map[string]any{"email": token.Email, "email_verified_at": now})
+		} else {
+			result = tx.Model(&domain.User{}).
+				Where("id = ? AND email = ?", token.UserID, token.Email).
+				Update("email_verified_at", gorm.Expr("COALESCE(email_verified_at, ?)", now))
+		}
+
+		if result.Error != nil {
+			return ErrUpdateUser
+		}
+
+		if result.RowsAffected == 0 {
+			return ErrEmailVerificationInvalid
+		}
+
+		return tx.Model(&domain.EmailVerificationToken{}).
+			Where("user_id = ? AND used_at IS NULL", token.UserID).
+			Update("used_at", now).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return token.UserID, nil
+}
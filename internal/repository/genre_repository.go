@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movie_app/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCreateGenre = errors.New("failed to create genre")
+	ErrUpdateGenre = errors.New("failed to update genre")
+	ErrDeleteGenre = errors.New("failed to delete genre")
+)
+
+type GenreRepository interface {
+	Create(ctx context.Context, genre *domain.Genre) (*domain.Genre, error)
+	GetByID(ctx context.Context, id uint) (*domain.Genre, error)
+	GetBySlug(ctx context.Context, slug string) (*domain.Genre, error)
+	GetAll(ctx context.Context) ([]domain.Genre, error)
+	FindByIDs(ctx context.Context, ids []uint) ([]domain.Genre, error)
+	FindBySlugs(ctx context.Context, slugs []string) ([]domain.Genre, error)
+	CountMovies(ctx context.Context, id uint) (int64, error)
+	Update(ctx context.Context, genre *domain.Genre) (*domain.Genre, error)
+	Delete(ctx context.Context, id uint) error
+}
+
+type genreRepository struct {
+	db *gorm.DB
+}
+
+func NewGenreRepository(db *gorm.DB) *genreRepository {
+	return &genreRepository{db: db}
+}
+
+func (r *genreRepository) Create(ctx context.Context, genre *domain.Genre) (*domain.Genre, error) {
+	if err := r.db.WithContext(ctx).Create(genre).Error; err != nil {
+		return nil, ErrCreateGenre
+	}
+
+	return genre, nil
+}
+
+func (r *genreRepository) GetByID(ctx context.Context, id uint) (*domain.Genre, error) {
+	var genre domain.Genre
+	if err := r.db.WithContext(ctx).First(&genre, id).Error; err != nil {
+		return nil, fmt.Errorf("failed to get genre: %w", err)
+	}
+
+	return &genre, nil
+}
+
+func (r *genreRepository) GetBySlug(ctx context.Context, slug string) (*domain.Genre, error) {
+	var genre domain.Genre
+	if err := r.db.WithContext(ctx).Where("slug = ?", slug).First(&genre).Error; err != nil {
+		return nil, fmt.Errorf("failed to get genre: %w", err)
+	}
+
+	return &genre, nil
+}
+
+func (r *genreRepository) GetAll(ctx context.Context) ([]domain.Genre, error) {
+	var genres []domain.Genre
+	if err := r.db.WithContext(ctx).Order("name").Find(&genres).Error; err != nil {
+		return nil, fmt.Errorf("failed to get genres: %w", err)
+	}
+
+	return genres, nil
+}
+
+func (r *genreRepository) FindByIDs(ctx context.Context, ids []uint) ([]domain.Genre, error) {
+	var genres []domain.Genre
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&genres).Error; err != nil {
+		return nil, fmt.Errorf("failed to get genres: %w", err)
+	}
+
+	return genres, nil
+}
+
+func (r *genreRepository) FindBySlugs(ctx context.Context, slugs []string) ([]domain.Genre, error) {
+	var genres []domain.Genre
+	if err := r.db.WithContext(ctx).Where("slug IN ?", slugs).Find(&genres).Error; err != nil {
+		return nil, fmt.Errorf("failed to get genres: %w", err)
+	}
+
+	return genres, nil
+}
+
+func (r *genreRepository) CountMovies(ctx context.Context, id uint) (int64, error) {
+	var count int64
+
+	err := r.db.WithContext(ctx).Table("movie_genres").Where("genre_id = ?", id).Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count genre movies: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *genreRepository) Update(ctx context.Context, genre *domain.Genre) (*domain.Genre, error) {
+	if err := r.db.WithContext(ctx).Save(genre).Error; err != nil {
+		return nil, ErrUpdateGenre
+	}
+
+	return genre, nil
+}
+
+func (r *genreRepository) Delete(ctx context.Context, id uint) error {
+	if err := r.db.WithContext(ctx).Delete(&domain.Genre{}, id).Error; err != nil {
+		return ErrDeleteGenre
+	}
+
+	return nil
+}
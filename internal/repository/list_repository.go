@@ -0,0 +1,425 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"movie_app/internal/domain"
+	"slices"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrCreateList         = errors.New("failed to create list")
+	ErrUpdateList         = errors.New("failed to update list")
+	ErrDeleteList         = errors.New("failed to delete list")
+	ErrSaveListEntry      = errors.New("failed to save list entry")
+	ErrAddCollaborator    = errors.New("failed to add collaborator")
+	ErrRemoveCollaborator = errors.New("failed to remove collaborator")
+
+	// ErrListVersionMismatch is returned by entry changes based on an
+	// outdated version of the list.
+	ErrListVersionMismatch = errors.New("list version mismatch")
+	ErrDuplicateListEntry  = errors.New("movie already in list")
+	ErrReorderMismatch     = errors.New("entries do not match the list")
+)
+
+type ListRepository interface {
+	Create(ctx context.Context, list *domain.CuratedList) (*domain.CuratedList, error)
+	GetByID(ctx context.Context, id uint) (*domain.CuratedList, error)
+	GetBySlug(ctx context.Context, slug string) (*domain.CuratedList, error)
+	ListPublic(ctx context.Context, query domain.ListQuery) (*domain.ListPage, error)
+	ListByMember(ctx context.Context, userID uint, query domain.ListQuery) (*domain.ListPage, error)
+	Update(ctx context.Context, list *domain.CuratedList) (*domain.CuratedList, error)
+	Delete(ctx context.Context, id uint) error
+	Entries(ctx context.Context, listID uint, query domain.MovieQuery) (*domain.ListEntryPage, error)
+	GetEntry(ctx context.Context, listID, entryID uint) (*domain.ListEntry, error)
+	AddEntry(ctx context.Context, entry *domain.ListEntry, version *int) (int, error)
+	UpdateEntryNote(ctx context.Context, listID, entryID uint, note string, version *int) (int, error)
+	RemoveEntry(ctx context.Context, listID, entryID uint, version *int) (int, error)
+	MoveEntry(ctx context.Context, listID, entryID uint, position int, version *int) (int, error)
+	Reorder(ctx context.Context, listID uint, entryIDs []uint, version int) (int, error)
+	AddCollaborator(ctx context.Context, collaborator *domain.ListCollaborator) error
+	RemoveCollaborator(ctx context.Context, listID, userID uint) error
+}
+
+type listRepository struct {
+	db *gorm.DB
+}
+
+func NewListRepository(db *gorm.DB) *listRepository {
+	return &listRepository{db: db}
+}
+
+func (r *listRepository) Create(ctx context.Context, list *domain.CuratedList) (*domain.CuratedList, error) {
+	if err := r.db.WithContext(ctx).Omit("Collaborators").Create(list).Error; err != nil {
+		return nil, ErrCreateList
+	}
+
+	return r.GetByID(ctx, list.ID)
+}
+
+func (r *listRepository) GetByID(ctx context.Context, id uint) (*domain.CuratedList, error) {
+	var list domain.CuratedList
+	if err := withEntryCount(r.db.WithContext(ctx)).Preload("Collaborators").First(&list, id).Error; err != nil {
+		return nil, fmt.Errorf("failed to get list: %w", err)
+	}
+
+	return &list, nil
+}
+
+func (r *listRepository) GetBySlug(ctx context.Context, slug string) (*domain.CuratedList, error) {
+	var list domain.CuratedList
+
+	err := withEntryCount(r.db.WithContext(ctx)).
+		Preload("Collaborators").
+		Where("curated_lists.slug = ?", slug).
+		First(&list).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get list: %w", err)
+	}
+
+	return &list, nil
+}
+
+func (r *listRepository) ListPublic(ctx context.Context, query domain.ListQuery) (*domain.ListPage, error) {
+	db := r.db.WithContext(ctx).Model(&domain.CuratedList{}).Where("visibility = ?", domain.ListPublic)
+
+	return r.page(db, query)
+}
+
+// ListByMember returns the lists the user owns or collaborates on.
+func (r *listRepository) ListByMember(
+	ctx context.Context, userID uint, query domain.ListQuery,
+) (*domain.ListPage, error) {
+	db := r.db.WithContext(ctx).Model(&domain.CuratedList{}).Where(
+		"owner_id = ? OR EXISTS (SELECT 1 FROM list_collaborators "+
+			"WHERE list_collaborators.list_id = curated_lists.id AND list_collaborators.user_id = ?)",
+		userID, userID,
+	)
+
+	return r.page(db, query)
+}
+
+func (r *listRepository) page(db *gorm.DB, query domain.ListQuery) (*domain.ListPage, error) {
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count lists: %w", err)
+	}
+
+	var lists []domain.CuratedList
+
+	err := withEntryCount(db).
+		Order("curated_lists.updated_at DESC").Order("curated_lists.id DESC").
+		Offset((query.Page - 1) * query.Limit).
+		Limit(query.Limit).
+		Find(&lists).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list lists: %w", err)
+	}
+
+	return &domain.ListPage{Lists: lists, Total: total}, nil
+}
+
+// Update saves the list's details; entries and the version are left alone.
+func (r *listRepository) Update(ctx context.Context, list *domain.CuratedList) (*domain.CuratedList, error) {
+	err := r.db.WithContext(ctx).Model(list).
+		Select("name", "description", "visibility", "updated_at").
+		Updates(list).Error
+	if err != nil {
+		return nil, ErrUpdateList
+	}
+
+	return r.GetByID(ctx, list.ID)
+}
+
+func (r *listRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("list_id = ?", id).Delete(&domain.ListEntry{}).Error; err != nil {
+			return ErrDeleteList
+		}
+
+		if err := tx.Where("list_id = ?", id).Delete(&domain.ListCollaborator{}).Error; err != nil {
+			return ErrDeleteList
+		}
+
+		if err := tx.Delete(&domain.CuratedList{}, id).Error; err != nil {
+			return ErrDeleteList
+		}
+
+		return nil
+	})
+}
+
+// listEntrySortColumns maps domain.ListEntrySortableFields to their columns.
+var listEntrySortColumns = func() map[string]string {
+	columns := maps.Clone(movieSortColumns)
+	columns["position"] = "list_entries.position"
+
+	return columns
+}()
+
+// Entries returns a page of the list's entries, filtered and sorted like
+// movie listings, in list order by default.
+func (r *listRepository) Entries(
+	ctx context.Context, listID uint, query domain.MovieQuery,
+) (*domain.ListEntryPage, error) {
+	db := r.db.WithContext(ctx).Model(&domain.ListEntry{}).
+		Joins("JOIN movies ON movies.id = list_entries.movie_id AND movies.deleted_at IS NULL").
+		Where("list_entries.list_id = ?", listID)
+	db = applyMovieFilters(db, query)
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count list entries: %w", err)
+	}
+
+	var entries []domain.ListEntry
+
+	err := applySort(db, query.SortFields, listEntrySortColumns, "position", "list_entries.position").
+		Preload("Movie.Genres").
+		Offset(query.Offset()).
+		Limit(query.Limit).
+		Find(&entries).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list list entries: %w", err)
+	}
+
+	return &domain.ListEntryPage{Entries: entries, Total: total}, nil
+}
+
+func (r *listRepository) GetEntry(ctx context.Context, listID, entryID uint) (*domain.ListEntry, error) {
+	var entry domain.ListEntry
+
+	err := r.db.WithContext(ctx).
+		Preload("Movie.Genres").
+		Where("list_id = ?", listID).
+		First(&entry, entryID).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get list entry: %w", err)
+	}
+
+	return &entry, nil
+}
+
+// AddEntry inserts the movie at the entry's position, or appends it when the
+// position is zero or past the end of the list.
+func (r *listRepository) AddEntry(ctx context.Context, entry *domain.ListEntry, version *int) (int, error) {
+	return r.changeEntries(ctx, entry.ListID, version, func(tx *gorm.DB, count int) error {
+		var existing int64
+		if err := tx.Model(&domain.ListEntry{}).
+			Where("list_id = ? AND movie_id = ?", entry.ListID, entry.MovieID).
+			Count(&existing).Error; err != nil {
+			return ErrSaveListEntry
+		}
+
+		if existing > 0 {
+			return ErrDuplicateListEntry
+		}
+
+		if entry.Position == 0 || entry.Position > count {
+			entry.Position = count + 1
+		}
+
+		if err := shiftEntries(tx, entry.ListID, entry.Position, count, 1); err != nil {
+			return ErrSaveListEntry
+		}
+
+		if err := tx.Omit("Movie").Create(entry).Error; err != nil {
+			return ErrSaveListEntry
+		}
+
+		return nil
+	})
+}
+
+func (r *listRepository) UpdateEntryNote(
+	ctx context.Context, listID, entryID uint, note string, version *int,
+) (int, error) {
+	return r.changeEntries(ctx, listID, version, func(tx *gorm.DB, _ int) error {
+		result := tx.Model(&domain.ListEntry{}).
+			Where("id = ? AND list_id = ?", entryID, listID).
+			Update("note", note)
+		if result.Error != nil {
+			return ErrSaveListEntry
+		}
+
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return nil
+	})
+}
+
+// RemoveEntry deletes the entry and closes the gap it leaves in the positions.
+func (r *listRepository) RemoveEntry(ctx context.Context, listID, entryID uint, version *int) (int, error) {
+	return r.changeEntries(ctx, listID, version, func(tx *gorm.DB, count int) error {
+		var entry domain.ListEntry
+		if err := tx.Where("list_id = ?", listID).First(&entry, entryID).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&entry).Error; err != nil {
+			return ErrSaveListEntry
+		}
+
+		if err := shiftEntries(tx, listID, entry.Position+1, count, -1); err != nil {
+			return ErrSaveListEntry
+		}
+
+		return nil
+	})
+}
+
+// MoveEntry moves the entry to the position, clamped to the end of the list,
+// shifting the entries in between by one.
+func (r *listRepository) MoveEntry(
+	ctx context.Context, listID, entryID uint, position int, version *int,
+) (int, error) {
+	return r.changeEntries(ctx, listID, version, func(tx *gorm.DB, count int) error {
+		var entry domain.ListEntry
+		if err := tx.Where("list_id = ?", listID).First(&entry, entryID).Error; err != nil {
+			return err
+		}
+
+		position = min(position, count)
+
+		var err error
+
+		switch {
+		case position > entry.Position:
+			err = shiftEntries(tx, listID, entry.Position+1, position, -1)
+		case position < entry.Position:
+			err = shiftEntries(tx, listID, position, entry.Position-1, 1)
+		default:
+			return nil
+		}
+
+		if err != nil {
+			return ErrSaveListEntry
+		}
+
+		if err := tx.Model(&entry).Update("position", position).Error; err != nil {
+			return ErrSaveListEntry
+		}
+
+		return nil
+	})
+}
+
+// Reorder sets the positions of the list's entries to the given order, which
+// must contain every entry of the list exactly once.
+func (r *listRepository) Reorder(ctx context.Context, listID uint, entryIDs []uint, version int) (int, error) {
+	return r.changeEntries(ctx, listID, &version, func(tx *gorm.DB, count int) error {
+		var current []uint
+		if err := tx.Model(&domain.ListEntry{}).Where("list_id = ?", listID).Pluck("id", &current).Error; err != nil {
+			return ErrSaveListEntry
+		}
+
+		requested := slices.Clone(entryIDs)
+		slices.Sort(requested)
+		slices.Sort(current)
+
+		if len(requested) != count || !slices.Equal(slices.Compact(requested), current) {
+			return ErrReorderMismatch
+		}
+
+		for i, id := range entryIDs {
+			if err := tx.Model(&domain.ListEntry{}).Where("id = ?", id).Update("position", i+1).Error; err != nil {
+				return ErrSaveListEntry
+			}
+		}
+
+		return nil
+	})
+}
+
+func (r *listRepository) AddCollaborator(ctx context.Context, collaborator *domain.ListCollaborator) error {
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(collaborator).Error
+	if err != nil {
+		return ErrAddCollaborator
+	}
+
+	return nil
+}
+
+func (r *listRepository) RemoveCollaborator(ctx context.Context, listID, userID uint) error {
+	result := r.db.WithContext(ctx).
+		Where("list_id = ? AND user_id = ?", listID, userID).
+		Delete(&domain.ListCollaborator{})
+	if result.Error != nil {
+		return ErrRemoveCollaborator
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// changeEntries runs a change to the list's entries in a transaction holding
+// the list's row lock, so concurrent changes are applied one after another
+// and positions stay contiguous. The change is rejected when the version is
+// given and outdated, and bumps the version otherwise, returning the new one.
+func (r *listRepository) changeEntries(
+	ctx context.Context, listID uint, version *int, change func(tx *gorm.DB, count int) error,
+) (int, error) {
+	var newVersion int
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var list domain.CuratedList
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "version").
+			First(&list, listID).Error; err != nil {
+			return err
+		}
+
+		if version != nil && *version != list.Version {
+			return ErrListVersionMismatch
+		}
+
+		var count int64
+		if err := tx.Model(&domain.ListEntry{}).Where("list_id = ?", listID).Count(&count).Error; err != nil {
+			return ErrSaveListEntry
+		}
+
+		if err := change(tx, int(count)); err != nil {
+			return err
+		}
+
+		newVersion = list.Version + 1
+
+		return tx.Model(&list).UpdateColumns(map[string]any{
+			"version":    newVersion,
+			"updated_at": gorm.Expr("NOW()"),
+		}).Error
+	})
+
+	return newVersion, err
+}
+
+// shiftEntries moves the entries at positions from to through by delta.
+func shiftEntries(tx *gorm.DB, listID uint, from, through, delta int) error {
+	if from > through {
+		return nil
+	}
+
+	return tx.Model(&domain.ListEntry{}).
+		Where("list_id = ? AND position BETWEEN ? AND ?", listID, from, through).
+		Update("position", gorm.Expr("position + ?", delta)).Error
+}
+
+// withEntryCount selects lists along with their number of entries.
+func withEntryCount(db *gorm.DB) *gorm.DB {
+	return db.Model(&domain.CuratedList{}).Select(
+		"curated_lists.*, " +
+			"(SELECT COUNT(*) FROM list_entries WHERE list_entries.list_id = curated_lists.id) AS entry_count",
+	)
+}
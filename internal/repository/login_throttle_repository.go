@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movie_app/internal/domain"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrUpdateLoginThrottle = errors.New("failed to update login throttle")
+
+// memoryMaxSize is how many keys the in-memory store holds at most. Once
+// full, it forgets keys whose failures still count to make room, so that a
+// spray of unique emails or addresses cannot use up the memory.
+const memoryMaxSize = 100000
+
+// LoginThrottleStore keeps the failed login counts and lockouts. Failures
+// count from 1 again once the window passed since the last one.
+type LoginThrottleStore interface {
+	Get(ctx context.Context, key string) (*domain.LoginThrottle, error)
+	RecordFailure(ctx context.Context, key string, now time.Time, window time.Duration) (int, error)
+	Lock(ctx context.Context, key string, until time.Time) error
+	Reset(ctx context.Context, key string) error
+}
+
+// loginThrottleRepository keeps the login throttles in Postgres, so that
+// every instance of a cluster shares them.
+type loginThrottleRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginThrottleRepository(db *gorm.DB) *loginThrottleRepository {
+	return &loginThrottleRepository{db: db}
+}
+
+// Get returns the key's throttle, which is empty when the key has none.
+func (r *loginThrottleRepository) Get(ctx context.Context, key string) (*domain.LoginThrottle, error) {
+	var throttle domain.LoginThrottle
+
+	err := r.db.WithContext(ctx).Where(`"key" = ?`, key).First(&throttle).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &domain.LoginThrottle{Key: key}, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get login throttle: %w", err)
+	}
+
+	return &throttle, nil
+}
+
+// RecordFailure counts a failed login and returns the key's failure count.
+func (r *loginThrottleRepository) RecordFailure(
+	ctx context.Context, key string, now time.Time, window time.Duration,
+) (int, error) {
+	var failures int
+
+	err := r.db.WithContext(ctx).Raw(`
+		INSERT INTO login_throttles ("key", failures, last_failure_at)
+		VALUES (?, 1, ?)
+		ON CONFLICT ("key") DO UPDATE SET
+			failures = CASE
+				WHEN login_throttles.last_failure_at < ? THEN 1
+				ELSE login_throttles.failures + 1
+			END,
+			last_failure_at = EXCLUDED.last_failure_at
+		RETURNING failures
+	`, key, now, now.Add(-window)).Scan(&failures).Error
+	if err != nil {
+		return 0, ErrUpdateLoginThrottle
+	}
+
+	return failures, nil
+}
+
+func (r *loginThrottleRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	err := r.db.WithContext(ctx).
+		Model(&domain.LoginThrottle{}).
+		Where(`"key" = ?`, key).
+		Update("locked_until", until).Error
+	if err != nil {
+		return ErrUpdateLoginThrottle
+	}
+
+	return nil
+}
+
+func (r *loginThrottleRepository) Reset(ctx context.Context, key string) error {
+	if err := r.db.WithContext(ctx).Where(`"key" = ?`, key).Delete(&domain.LoginThrottle{}).Error; err != nil {
+		return ErrUpdateLoginThrottle
+	}
+
+	return nil
+}
+
+// MemoryLoginThrottleStore keeps the login throttles in memory, for a single
+// instance.
+type MemoryLoginThrottleStore struct {
+	mu        sync.Mutex
+	throttles map[string]domain.LoginThrottle
+	window    time.Duration // Longest window seen, to tell which keys are stale
+	prunedAt  time.Time
+}
+
+func NewMemoryLoginThrottleStore() *MemoryLoginThrottleStore {
+	return &MemoryLoginThrottleStore{throttles: make(map[string]domain.LoginThrottle)}
+}
+
+func (s *MemoryLoginThrottleStore) Get(_ context.Context, key string) (*domain.LoginThrottle, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	throttle, ok := s.throttles[key]
+	if !ok {
+		throttle = domain.LoginThrottle{Key: key}
+	}
+
+	return &throttle, nil
+}
+
+func (s *MemoryLoginThrottleStore) RecordFailure(
+	_ context.Context, key string, now time.Time, window time.Duration,
+) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.window = max(s.window, window)
+
+	// Stale keys are forgotten once per window, however many there are
+	if now.Sub(s.prunedAt) >= s.window {
+		s.prune(now)
+	}
+
+	throttle, ok := s.throttles[key]
+	if !ok && len(s.throttles) >= memoryMaxSize {
+		s.prune(now)
+		s.evict(now)
+	}
+
+	if !ok || throttle.LastFailureAt.Before(now.Add(-window)) {
+		throttle.Key = key
+		throttle.Failures = 0
+	}
+
+	throttle.Failures++
+	throttle.LastFailureAt = now
+	s.throttles[key] = throttle
+
+	return throttle.Failures, nil
+}
+
+func (s *MemoryLoginThrottleStore) Lock(_ context.Context, key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if throttle, ok := s.throttles[key]; ok {
+		throttle.LockedUntil = &until
+		s.throttles[key] = throttle
+	}
+
+	return nil
+}
+
+func (s *MemoryLoginThrottleStore) Reset(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.throttles, key)
+
+	return nil
+}
+
+// prune forgets the keys that are not locked and whose failures no longer
+// count.
+func (s *MemoryLoginThrottleStore) prune(now time.Time) {
+	s.prunedAt = now
+
+	for key, throttle := range s.throttles {
+		if !throttle.LockedAt(now) && throttle.LastFailureAt.Before(now.Add(-s.window)) {
+			delete(s.throttles, key)
+		}
+	}
+}
+
+// evict forgets a tenth of the keys once the store is full, unlocked ones
+// before locked ones, which an attacker has to fail far more often to fill
+// the store with.
+func (s *MemoryLoginThrottleStore) evict(now time.Time) {
+	target := memoryMaxSize - memoryMaxSize/10
+
+	for _, locked := range []bool{false, true} {
+		for key, throttle := range s.throttles {
+			if len(s.throttles) <= target {
+				return
+			}
+
+			if throttle.LockedAt(now) == locked {
+				delete(s.throttles, key)
+			}
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movie_app/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrSaveTOTPSecret       = errors.New("failed to save TOTP secret")
+	ErrConfirmTOTPSecret    = errors.New("failed to confirm TOTP secret")
+	ErrDeleteMFA            = errors.New("failed to delete two-factor authentication")
+	ErrReplaceRecoveryCodes = errors.New("failed to replace recovery codes")
+)
+
+type MFARepository interface {
+	GetTOTPSecret(ctx context.Context, userID uint) (*domain.TOTPSecret, error)
+	SaveTOTPSecret(ctx context.Context, secret *domain.TOTPSecret) error
+	ConfirmTOTPSecret(ctx context.Context, userID uint, step int64, codeHashes []string) error
+	UseStep(ctx context.Context, userID uint, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userID uint, codeHash string) (bool, error)
+	CountRecoveryCodes(ctx context.Context, userID uint) (int64, error)
+	ReplaceRecoveryCodes(ctx context.Context, userID uint, codeHashes []string) error
+	Delete(ctx context.Context, userID uint) error
+}
+
+type mfaRepository struct {
+	db *gorm.DB
+}
+
+func NewMFARepository(db *gorm.DB) *mfaRepository {
+	return &mfaRepository{db: db}
+}
+
+func (r *mfaRepository) GetTOTPSecret(ctx context.Context, userID uint) (*domain.TOTPSecret, error) {
+	var secret domain.TOTPSecret
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&secret).Error; err != nil {
+		return nil, fmt.Errorf("failed to get TOTP secret: %w", err)
+	}
+
+	return &secret, nil
+}
+
+// SaveTOTPSecret stores an unconfirmed secret, replacing any unconfirmed one
+// left by an earlier enrollment. A confirmed secret is never replaced.
+func (r *mfaRepository) SaveTOTPSecret(ctx context.Context, secret *domain.TOTPSecret) error {
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"secret", "last_used_step", "created_at", "updated_at"}),
+			Where: clause.Where{Exprs: []clause.Expression{
+				clause.Expr{SQL: "totp_secrets.confirmed_at IS NULL"},
+			}},
+		}).
+		Create(secret)
+	if result.Error != nil {
+		return ErrSaveTOTPSecret
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrSaveTOTPSecret
+	}
+
+	return nil
+}
+
+// ConfirmTOTPSecret enables the user's secret after a code at the step was
+// checked, and gives the user a fresh set of recovery codes.
+func (r *mfaRepository) ConfirmTOTPSecret(ctx context.Context, userID uint, step int64, codeHashes []string) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&domain.TOTPSecret{}).
+			Where("user_id = ? AND confirmed_at IS NULL AND last_used_step < ?", userID, step).
+			Updates(map[string]any{"confirmed_at": time.Now(), "last_used_step": step})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return ErrConfirmTOTPSecret
+		}
+
+		return replaceRecoveryCodes(tx, userID, codeHashes)
+	})
+	if err != nil {
+		return ErrConfirmTOTPSecret
+	}
+
+	return nil
+}
+
+// UseStep records that the code at the step was used, and reports false when
+// it or a later one already was.
+func (r *mfaRepository) UseStep(ctx context.Context, userID uint, step int64) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&domain.TOTPSecret{}).
+		Where("user_id = ? AND last_used_step < ?", userID, step).
+		Update("last_used_step", step)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to update TOTP secret: %w", result.Error)
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// UseRecoveryCode uses up the user's recovery code with the hash, and reports
+// false when there is no such unused code.
+func (r *mfaRepository) UseRecoveryCode(ctx context.Context, userID uint, codeHash string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&domain.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to update recovery code: %w", result.Error)
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+func (r *mfaRepository) CountRecoveryCodes(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+
+	err := r.db.WithContext(ctx).
+		Model(&domain.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *mfaRepository) ReplaceRecoveryCodes(ctx context.Context, userID uint, codeHashes []string) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userID, codeHashes)
+	})
+	if err != nil {
+		return ErrReplaceRecoveryCodes
+	}
+
+	return nil
+}
+
+// Delete turns two-factor authentication off by removing the user's secret
+// and recovery codes.
+func (r *mfaRepository) Delete(ctx context.Context, userID uint) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&domain.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("user_id = ?", userID).Delete(&domain.TOTPSecret{}).Error
+	})
+	if err != nil {
+		return ErrDeleteMFA
+	}
+
+	return nil
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID uint, codeHashes []string) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&domain.RecoveryCode{}).Error; err != nil {
+		return err
+	}
+
+	codes := make([]domain.RecoveryCode, 0, len(codeHashes))
+	for _, hash := range codeHashes {
+		codes = append(codes, domain.RecoveryCode{UserID: userID, CodeHash: hash})
+	}
+
+	return tx.Create(&codes).Error
+}
@@ -0,0 +1,246 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"movie_app/internal/domain"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// migration is a schema or data change that AutoMigrate cannot express,
+// such as triggers, indexes on expressions or backfills. Each migration runs
+// once, in order, inside its own transaction.
+type migration struct {
+	Name string
+	Up   func(tx *gorm.DB) error
+}
+
+type schemaMigration struct {
+	Name      string `gorm:"primaryKey"`
+	AppliedAt time.Time
+}
+
+var migrations = []migration{
+	{Name: "0001_movies_search_vector", Up: migrateMoviesSearchVector},
+	{Name: "0002_movies_title_trigram", Up: migrateMoviesTitleTrigram},
+	{Name: "0003_normalize_genres", Up: migrateNormalizeGenres},
+	{Name: "0004_directors_to_people", Up: migrateDirectorsToPeople},
+	{Name: "0005_user_roles", Up: migrateUserRoles},
+	{Name: "0006_verify_existing_users", Up: migrateVerifyExistingUsers},
+}
+
+func runMigrations(db *gorm.DB) error {
+	if err := db.AutoMigrate(&schemaMigration{}); err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	for _, m := range migrations {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var applied schemaMigration
+
+			err := tx.Where("name = ?", m.Name).First(&applied).Error
+			if err == nil {
+				return nil
+			}
+
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+
+			return tx.Create(&schemaMigration{Name: m.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("failed to apply migration %s: %w", m.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func execAll(tx *gorm.DB, statements ...string) error {
+	for _, statement := range statements {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migrateMoviesSearchVector adds a weighted tsvector over title, director and
+// plot that a trigger keeps current on every insert and update.
+func migrateMoviesSearchVector(tx *gorm.DB) error {
+	return execAll(tx,
+		`ALTER TABLE movies ADD COLUMN IF NOT EXISTS search_vector tsvector`,
+		`CREATE OR REPLACE FUNCTION movies_search_vector_update() RETURNS trigger AS $$
+BEGIN
+	NEW.search_vector :=
+		setweight(to_tsvector('english', coalesce(NEW.title, '')), 'A') ||
+		setweight(to_tsvector('english', coalesce(NEW.director, '')), 'B') ||
+		setweight(to_tsvector('english', coalesce(NEW.plot, '')), 'C');
+	RETURN NEW;
+END
+$$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS movies_search_vector_trigger ON movies`,
+		`CREATE TRIGGER movies_search_vector_trigger
+	BEFORE INSERT OR UPDATE ON movies
+	FOR EACH ROW EXECUTE FUNCTION movies_search_vector_update()`,
+		`UPDATE movies SET title = title`,
+		`CREATE INDEX IF NOT EXISTS idx_movies_search_vector ON movies USING GIN (search_vector)`,
+	)
+}
+
+// migrateMoviesTitleTrigram enables pg_trgm and indexes titles for prefix and
+// similarity lookups.
+func migrateMoviesTitleTrigram(tx *gorm.DB) error {
+	return execAll(tx,
+		`CREATE EXTENSION IF NOT EXISTS pg_trgm`,
+		`CREATE INDEX IF NOT EXISTS idx_movies_title_trgm ON movies USING GIN (lower(title) gin_trgm_ops)`,
+	)
+}
+
+// genreSeparator splits free-text genres such as "Action, Sci-Fi" or
+// "Crime/Drama" into their parts.
+var genreSeparator = regexp.MustCompile(`(?i)\s*(?:,|/|\||;|&|\band\b)\s*`)
+
+// genreAliases maps common spellings of the same genre to one slug.
+var genreAliases = map[string]string{
+	"sci-fi":     "science-fiction",
+	"scifi":      "science-fiction",
+	"sf":         "science-fiction",
+	"animated":   "animation",
+	"biopic":     "biography",
+	"docu":       "documentary",
+	"rom-com":    "romantic-comedy",
+	"romcom":     "romantic-comedy",
+	"musical":    "music",
+	"war-film":   "war",
+	"film-noir":  "noir",
+	"historical": "history",
+}
+
+// genreNames is the display name of aliased slugs.
+var genreNames = map[string]string{
+	"science-fiction": "Science Fiction",
+	"animation":       "Animation",
+	"biography":       "Biography",
+	"documentary":     "Documentary",
+	"romantic-comedy": "Romantic Comedy",
+	"music":           "Music",
+	"war":             "War",
+	"noir":            "Noir",
+	"history":         "History",
+}
+
+// migrateNormalizeGenres splits the legacy free-text movies.genre column into
+// Genre records linked through movie_genres, then drops the column.
+func migrateNormalizeGenres(tx *gorm.DB) error {
+	if !tx.Migrator().HasColumn("movies", "genre") {
+		return nil
+	}
+
+	var rows []struct {
+		ID    uint
+		Genre string
+	}
+
+	if err := tx.Table("movies").Select("id, genre").Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	genres := make(map[string]*domain.Genre)
+
+	for _, row := range rows {
+		for _, part := range genreSeparator.Split(row.Genre, -1) {
+			part = strings.TrimSpace(part)
+
+			slug := domain.Slugify(part)
+			if slug == "" {
+				continue
+			}
+
+			if alias, ok := genreAliases[slug]; ok {
+				slug = alias
+			}
+
+			genre, ok := genres[slug]
+			if !ok {
+				name := part
+				if canonical, ok := genreNames[slug]; ok {
+					name = canonical
+				}
+
+				genre = &domain.Genre{Slug: slug, Name: name}
+
+				err := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "slug"}}, DoNothing: true}).
+					Create(genre).Error
+				if err != nil {
+					return err
+				}
+
+				if err := tx.Where("slug = ?", slug).First(genre).Error; err != nil {
+					return err
+				}
+
+				genres[slug] = genre
+			}
+
+			err := tx.Exec(
+				"INSERT INTO movie_genres (movie_id, genre_id) VALUES (?, ?) ON CONFLICT DO NOTHING",
+				row.ID, genre.ID,
+			).Error
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Migrator().DropColumn("movies", "genre")
+}
+
+// migrateDirectorsToPeople creates a Person and a Director credit for every
+// name in the movies.director column.
+func migrateDirectorsToPeople(tx *gorm.DB) error {
+	var movies []domain.Movie
+	if err := tx.Select("id", "director").Find(&movies).Error; err != nil {
+		return err
+	}
+
+	for _, movie := range movies {
+		if err := createDirectorCredits(tx, movie.ID, movie.Director); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migrateUserRoles turns the is_admin flag into the admin role; every other
+// user keeps the viewer role the column defaults to.
+func migrateUserRoles(tx *gorm.DB) error {
+	if !tx.Migrator().HasColumn("users", "is_admin") {
+		return nil
+	}
+
+	err := tx.Exec("UPDATE users SET role = ? WHERE is_admin", domain.RoleAdmin).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Migrator().DropColumn("users", "is_admin")
+}
+
+// migrateVerifyExistingUsers treats the accounts created before email
+// verification existed as verified, so that they keep working.
+func migrateVerifyExistingUsers(tx *gorm.DB) error {
+	return tx.Exec("UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL").Error
+}
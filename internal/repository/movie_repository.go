@@ -4,9 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"html"
 	"movie_app/internal/domain"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var (
@@ -14,14 +18,23 @@ var (
 	ErrUpdateMovie = errors.New("failed to update movie")
 	ErrDeleteMovie = errors.New("failed to delete movie")
 	ErrFetchMovie  = errors.New("failed to fetch movie")
+	ErrPurgeMovie  = errors.New("failed to purge movie")
 )
 
 type MovieRepository interface {
 	Create(ctx context.Context, movie *domain.Movie) (*domain.Movie, error)
 	GetByID(ctx context.Context, id uint) (*domain.Movie, error)
 	GetAll(ctx context.Context) ([]domain.Movie, error)
+	List(ctx context.Context, query domain.MovieQuery) (*domain.MoviePage, error)
+	Search(ctx context.Context, query domain.MovieSearchQuery) (*domain.MovieSearchPage, error)
+	Suggest(ctx context.Context, prefix string, limit int, threshold float64) ([]domain.MovieSuggestion, error)
+	Facets(ctx context.Context, query domain.MovieSearchQuery) (*domain.MovieFacets, error)
 	Update(ctx context.Context, movie *domain.Movie) (*domain.Movie, error)
 	Delete(ctx context.Context, id uint) error
+	ListTrashed(ctx context.Context, page, limit int) ([]domain.Movie, int64, error)
+	Restore(ctx context.Context, id uint) error
+	Purge(ctx context.Context, id uint) error
+	PurgeTrashed(ctx context.Context, deletedBefore time.Time) (int, error)
 }
 
 type movieRepository struct {
@@ -34,12 +47,18 @@ func NewMovieRepository(db *gorm.DB) *movieRepository {
 
 func (r *movieRepository) Create(ctx context.Context, movie *domain.Movie) (*domain.Movie, error) {
 	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		if err := tx.Create(movie).Error; err != nil {
+		// Genres are resolved beforehand, so only the join rows are created
+		if err := tx.Omit("Genres.*", "Credits").Create(movie).Error; err != nil {
 			return ErrCreateMovie
 		}
 
-		// If the movie has related entities (e.g., genres, actors),we can save them here
-		// Example: tx.Create(&movie.Actors)
+		if err := createDirectorCredits(tx, movie.ID, movie.Director); err != nil {
+			return ErrCreateMovie
+		}
+
+		if err := saveCredits(tx, movie); err != nil {
+			return ErrCreateMovie
+		}
 
 		return nil
 	})
@@ -53,7 +72,12 @@ func (r *movieRepository) Create(ctx context.Context, movie *domain.Movie) (*dom
 
 func (r *movieRepository) GetByID(ctx context.Context, id uint) (*domain.Movie, error) {
 	var movie domain.Movie
-	if err := r.db.WithContext(ctx).First(&movie, id).Error; err != nil {
+	err := r.db.WithContext(ctx).
+		Preload("Genres").
+		Preload("Credits", orderCredits).
+		Preload("Credits.Person").
+		First(&movie, id).Error
+	if err != nil {
 		return nil, fmt.Errorf("failed to get movie: %w", err)
 	}
 
@@ -62,21 +86,277 @@ func (r *movieRepository) GetByID(ctx context.Context, id uint) (*domain.Movie,
 
 func (r *movieRepository) GetAll(ctx context.Context) ([]domain.Movie, error) {
 	var movies []domain.Movie
-	if err := r.db.WithContext(ctx).Find(&movies).Error; err != nil {
+	if err := r.db.WithContext(ctx).Preload("Genres").Find(&movies).Error; err != nil {
 		return nil, fmt.Errorf("failed to get movies: %w", err)
 	}
 
 	return movies, nil
 }
 
+func (r *movieRepository) List(ctx context.Context, query domain.MovieQuery) (*domain.MoviePage, error) {
+	db := applyMovieFilters(r.db.WithContext(ctx).Model(&domain.Movie{}), query)
+
+	var total int64
+	if !query.IsCursorMode() {
+		if err := db.Count(&total).Error; err != nil {
+			return nil, fmt.Errorf("failed to count movies: %w", err)
+		}
+	}
+
+	if query.After != nil {
+		db = applyKeyset(db, query.SortFields, query.After)
+	}
+
+	var movies []domain.Movie
+	err := applyMovieSort(db, query.SortFields).
+		Preload("Genres").
+		Offset(query.Offset()).
+		Limit(query.Limit).
+		Find(&movies).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list movies: %w", err)
+	}
+
+	return &domain.MoviePage{Movies: movies, Total: total}, nil
+}
+
+// Matched terms are delimited with private-use characters, which are removed
+// from the movie text beforehand, so that the text can be HTML-escaped before
+// the delimiters become <mark> tags.
+const (
+	highlightStart      = "\uE000"
+	highlightStop       = "\uE001"
+	highlightDelimiters = highlightStart + highlightStop
+	headlineOptions     = `StartSel="` + highlightStart + `", StopSel="` + highlightStop + `", ` +
+		"MaxFragments=2, MaxWords=30, MinWords=10"
+)
+
+var highlightMarks = strings.NewReplacer(highlightStart, "<mark>", highlightStop, "</mark>")
+
+// markHighlights escapes a ts_headline result and wraps its matched terms in
+// <mark> tags.
+func markHighlights(headline string) string {
+	return highlightMarks.Replace(html.EscapeString(headline))
+}
+
+func (r *movieRepository) Search(ctx context.Context, query domain.MovieSearchQuery) (*domain.MovieSearchPage, error) {
+	db := r.searchScope(ctx, query)
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count search results: %w", err)
+	}
+
+	var rows []struct {
+		ID             uint
+		Rank           float64
+		TitleHighlight string
+		PlotHighlight  string
+	}
+
+	db = db.Select(
+		"movies.id, "+
+			"ts_rank(movies.search_vector, to_tsquery('english', ?)) AS rank, "+
+			"ts_headline('english', translate(movies.title, ?, ''), to_tsquery('english', ?), ?) AS title_highlight, "+
+			"ts_headline('english', translate(coalesce(movies.plot, ''), ?, ''), to_tsquery('english', ?), ?) AS plot_highlight",
+		query.TSQuery, highlightDelimiters, query.TSQuery, headlineOptions, highlightDelimiters, query.TSQuery, headlineOptions,
+	)
+
+	if len(query.SortFields) == 0 {
+		db = db.Order("rank DESC")
+	}
+
+	err := applyMovieSort(db, query.SortFields).
+		Offset(query.Offset()).
+		Limit(query.Limit).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to search movies: %w", err)
+	}
+
+	ids := make([]uint, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.ID)
+	}
+
+	movies, err := r.findByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]domain.MovieSearchHit, 0, len(rows))
+
+	for _, row := range rows {
+		movie, ok := movies[row.ID]
+		if !ok {
+			continue
+		}
+
+		hits = append(hits, domain.MovieSearchHit{
+			Movie: movie,
+			Rank:  row.Rank,
+			Highlights: domain.MovieHighlights{
+				Title: markHighlights(row.TitleHighlight),
+				Plot:  markHighlights(row.PlotHighlight),
+			},
+		})
+	}
+
+	return &domain.MovieSearchPage{Hits: hits, Total: total}, nil
+}
+
+// Suggest returns titles starting with the prefix first, followed by titles
+// whose trigram similarity to it reaches the threshold, so that typos like
+// "Godfater" still find "The Godfather". The filters use the trigram
+// operators, which idx_movies_title_trgm serves, with the threshold set for
+// the transaction only.
+func (r *movieRepository) Suggest(
+	ctx context.Context, prefix string, limit int, threshold float64,
+) ([]domain.MovieSuggestion, error) {
+	prefix = strings.ToLower(prefix)
+	pattern := escapeLike(prefix) + "%"
+	similarity := "GREATEST(similarity(lower(movies.title), ?), word_similarity(?, lower(movies.title)))"
+
+	var suggestions []domain.MovieSuggestion
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(
+			"SELECT set_config('pg_trgm.similarity_threshold', ?, true), "+
+				"set_config('pg_trgm.word_similarity_threshold', ?, true)",
+			fmt.Sprint(threshold), fmt.Sprint(threshold),
+		).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&domain.Movie{}).
+			Select("movies.id, movies.title, movies.year, "+similarity+" AS similarity", prefix, prefix).
+			Where("lower(movies.title) LIKE ? OR lower(movies.title) % ? OR lower(movies.title) %> ?", pattern, prefix, prefix).
+			Order(clause.OrderBy{Expression: clause.Expr{
+				SQL:                "lower(movies.title) LIKE ? DESC, similarity DESC, movies.rating DESC, movies.id",
+				Vars:               []any{pattern},
+				WithoutParentheses: true,
+			}}).
+			Limit(limit).
+			Scan(&suggestions).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest movies: %w", err)
+	}
+
+	return suggestions, nil
+}
+
+// facetExpressions are the SQL expressions movies are grouped by for each
+// facet, along with the order their buckets are returned in.
+var facetExpressions = map[string]struct {
+	join  string
+	value string
+	order string
+}{
+	"genres": {
+		join: "JOIN movie_genres ON movie_genres.movie_id = movies.id " +
+			"JOIN genres ON genres.id = movie_genres.genre_id",
+		value: "genres.slug",
+		order: "count DESC, value",
+	},
+	"decades": {
+		value: "((movies.year / 10) * 10)::text || 's'",
+		order: "value",
+	},
+	"ratings": {
+		value: "LEAST(FLOOR(movies.rating), 9)::int || '-' || (LEAST(FLOOR(movies.rating), 9)::int + 1)",
+		order: "MIN(movies.rating)",
+	},
+	"durations": {
+		value: "CASE WHEN movies.duration < 90 THEN 'under-90' " +
+			"WHEN movies.duration < 120 THEN '90-119' " +
+			"WHEN movies.duration < 150 THEN '120-149' " +
+			"ELSE '150-plus' END",
+		order: "MIN(movies.duration)",
+	},
+}
+
+func (r *movieRepository) Facets(ctx context.Context, query domain.MovieSearchQuery) (*domain.MovieFacets, error) {
+	facets := &domain.MovieFacets{}
+	targets := map[string]*[]domain.FacetBucket{
+		"genres":    &facets.Genres,
+		"decades":   &facets.Decades,
+		"ratings":   &facets.Ratings,
+		"durations": &facets.Durations,
+	}
+
+	for name, target := range targets {
+		expression := facetExpressions[name]
+		buckets := []domain.FacetBucket{}
+
+		db := r.searchScope(ctx, query)
+		if expression.join != "" {
+			db = db.Joins(expression.join)
+		}
+
+		err := db.Select(expression.value + " AS value, COUNT(*) AS count").
+			Group("value").
+			Order(expression.order).
+			Scan(&buckets).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to aggregate %s facet: %w", name, err)
+		}
+
+		*target = buckets
+	}
+
+	return facets, nil
+}
+
+// searchScope selects the movies matching the query's filters and, when
+// present, its full-text search.
+func (r *movieRepository) searchScope(ctx context.Context, query domain.MovieSearchQuery) *gorm.DB {
+	db := applyMovieFilters(r.db.WithContext(ctx).Model(&domain.Movie{}), query.MovieQuery)
+	if query.TSQuery != "" {
+		db = db.Where("movies.search_vector @@ to_tsquery('english', ?)", query.TSQuery)
+	}
+
+	return db
+}
+
+// findByIDs loads the given movies keyed by ID, for queries that select IDs
+// first and need the full rows afterwards.
+func (r *movieRepository) findByIDs(ctx context.Context, ids []uint) (map[uint]domain.Movie, error) {
+	result := make(map[uint]domain.Movie, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	var movies []domain.Movie
+	if err := r.db.WithContext(ctx).Preload("Genres").Where("id IN ?", ids).Find(&movies).Error; err != nil {
+		return nil, fmt.Errorf("failed to get movies: %w", err)
+	}
+
+	for _, movie := range movies {
+		result[movie.ID] = movie
+	}
+
+	return result, nil
+}
+
 func (r *movieRepository) Update(ctx context.Context, movie *domain.Movie) (*domain.Movie, error) {
 	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		if err := tx.Save(movie).Error; err != nil {
+		if err := syncDirectorCredits(tx, movie); err != nil {
+			return ErrUpdateMovie
+		}
+
+		// Whoever added the movie stays its creator
+		omit := append(
+			[]string{"Genres", "Credits", "CreatedBy", "CreatedByID", "UpdatedBy"}, domain.CommunityRatingColumns...,
+		)
+		if err := tx.Omit(omit...).Save(movie).Error; err != nil {
 			return ErrUpdateMovie
 		}
 
-		// If we need to update related entities, we can do it here
-		// Example: tx.Model(&movie).Association("Actors").Replace(movie.Actors)
+		if err := tx.Model(movie).Omit("Genres.*").Association("Genres").Replace(movie.Genres); err != nil {
+			return ErrUpdateMovie
+		}
 
 		return nil
 	})
@@ -88,6 +368,279 @@ func (r *movieRepository) Update(ctx context.Context, movie *domain.Movie) (*dom
 	return movie, nil
 }
 
+// Delete moves the movie to the trash, hiding it from every listing until
+// Restore brings it back or Purge removes it.
 func (r *movieRepository) Delete(ctx context.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&domain.Movie{}, id).Error
+	result := r.db.WithContext(ctx).Delete(&domain.Movie{}, id)
+	if result.Error != nil {
+		return ErrDeleteMovie
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("failed to delete movie: %w", gorm.ErrRecordNotFound)
+	}
+
+	return nil
+}
+
+// ListTrashed returns a page of the deleted movies, most recently deleted
+// first, along with how many there are.
+func (r *movieRepository) ListTrashed(ctx context.Context, page, limit int) ([]domain.Movie, int64, error) {
+	db := r.db.WithContext(ctx).Unscoped().Model(&domain.Movie{}).Where("deleted_at IS NOT NULL")
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count deleted movies: %w", err)
+	}
+
+	var movies []domain.Movie
+
+	err := db.Preload("Genres").
+		Order("deleted_at DESC").Order("id DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&movies).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list deleted movies: %w", err)
+	}
+
+	return movies, total, nil
+}
+
+// Restore takes the movie out of the trash.
+func (r *movieRepository) Restore(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Unscoped().
+		Model(&domain.Movie{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return ErrUpdateMovie
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("failed to restore movie: %w", gorm.ErrRecordNotFound)
+	}
+
+	return nil
+}
+
+// Purge permanently deletes the movie from the trash along with its genres,
+// reviews and ratings. Its credits, and every user's watchlist, diary and
+// list entries for it, go by foreign key cascade, so a purge also removes
+// the movie from users' watch history.
+func (r *movieRepository) Purge(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return purgeMovie(tx, id)
+	})
+}
+
+// PurgeTrashed permanently deletes the movies deleted before the time and
+// returns how many it deleted. Each movie is purged in a transaction of its
+// own, so that a failure keeps the movies purged so far.
+func (r *movieRepository) PurgeTrashed(ctx context.Context, deletedBefore time.Time) (int, error) {
+	var ids []uint
+
+	err := r.db.WithContext(ctx).Unscoped().
+		Model(&domain.Movie{}).
+		Where("deleted_at < ?", deletedBefore).
+		Order("id").
+		Pluck("id", &ids).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to list deleted movies: %w", err)
+	}
+
+	for i, id := range ids {
+		if err := r.Purge(ctx, id); err != nil {
+			return i, fmt.Errorf("%w %d: %w", ErrPurgeMovie, id, err)
+		}
+	}
+
+	return len(ids), nil
+}
+
+func purgeMovie(tx *gorm.DB, id uint) error {
+	// Locking the row keeps a concurrent restore from bringing back a movie
+	// that is half purged
+	var movie domain.Movie
+
+	err := tx.Unscoped().
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").
+		Where("deleted_at IS NOT NULL").
+		First(&movie, id).Error
+	if err != nil {
+		return fmt.Errorf("failed to get movie: %w", err)
+	}
+
+	if err := tx.Model(&movie).Association("Genres").Clear(); err != nil {
+		return ErrPurgeMovie
+	}
+
+	if err := deleteReviews(tx, "movie_id = ?", id); err != nil {
+		return ErrPurgeMovie
+	}
+
+	if err := tx.Where("movie_id = ?", id).Delete(&domain.UserRating{}).Error; err != nil {
+		return ErrPurgeMovie
+	}
+
+	// Credits and watchlist, diary and list entries cascade
+	if err := tx.Unscoped().Delete(&domain.Movie{}, id).Error; err != nil {
+		return ErrPurgeMovie
+	}
+
+	return nil
+}
+
+// activeMovieIDs selects the IDs of the movies that are not in the trash,
+// for hiding rows that refer to deleted movies.
+func activeMovieIDs(db *gorm.DB) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true}).Model(&domain.Movie{}).Select("id")
+}
+
+// movieSortColumns maps the sortable fields of domain.MovieSortableFields to
+// their database columns.
+var movieSortColumns = map[string]string{
+	"title":           "movies.title",
+	"director":        "movies.director",
+	"year":            "movies.year",
+	"rating":          "movies.rating",
+	"communityRating": "movies.community_rating_weighted",
+	"ratingCount":     "movies.community_rating_count",
+	"duration":        "movies.duration",
+	"createdAt":       "movies.created_at",
+	"id":              "movies.id",
+}
+
+func applyMovieFilters(db *gorm.DB, query domain.MovieQuery) *gorm.DB {
+	if query.Genre != "" {
+		db = db.Where(
+			"EXISTS (SELECT 1 FROM movie_genres JOIN genres ON genres.id = movie_genres.genre_id "+
+				"WHERE movie_genres.movie_id = movies.id AND genres.slug = ?)",
+			query.Genre,
+		)
+	}
+
+	if query.Director != "" {
+		db = db.Where("movies.director ILIKE ?", "%"+escapeLike(query.Director)+"%")
+	}
+
+	if query.YearFrom != nil {
+		db = db.Where("movies.year >= ?", *query.YearFrom)
+	}
+
+	if query.YearTo != nil {
+		db = db.Where("movies.year <= ?", *query.YearTo)
+	}
+
+	if query.RatingMin != nil {
+		db = db.Where("movies.rating >= ?", *query.RatingMin)
+	}
+
+	if query.RatingMax != nil {
+		db = db.Where("movies.rating <= ?", *query.RatingMax)
+	}
+
+	if query.DurationMin != nil {
+		db = db.Where("movies.duration >= ?", *query.DurationMin)
+	}
+
+	if query.DurationMax != nil {
+		db = db.Where("movies.duration <= ?", *query.DurationMax)
+	}
+
+	return db
+}
+
+// applyMovieSort orders by the requested fields, always finishing with the
+// primary key so that pages are stable for rows with equal sort values.
+func applyMovieSort(db *gorm.DB, fields []domain.SortField) *gorm.DB {
+	return applySort(db, fields, movieSortColumns, "id", "movies.id")
+}
+
+// applySort orders by the fields' columns, then by the ID column unless the
+// fields already include the ID, so that the order is total.
+func applySort(db *gorm.DB, fields []domain.SortField, columns map[string]string, idField, idColumn string) *gorm.DB {
+	sortedByID := false
+
+	for _, field := range fields {
+		column, ok := columns[field.Field]
+		if !ok {
+			continue
+		}
+
+		if field.Field == idField {
+			sortedByID = true
+		}
+
+		if field.Desc {
+			column += " DESC"
+		}
+
+		db = db.Order(column)
+	}
+
+	if !sortedByID {
+		db = db.Order(idColumn)
+	}
+
+	return db
+}
+
+// applyKeyset restricts the query to rows that sort after the given position,
+// expanding (a, b, id) > (x, y, z) so that every key can have its own
+// direction: a > x OR (a = x AND b > y) OR (a = x AND b = y AND id > z).
+func applyKeyset(db *gorm.DB, fields []domain.SortField, after *domain.CursorPosition) *gorm.DB {
+	columns := make([]string, 0, len(fields)+1)
+	operators := make([]string, 0, len(fields)+1)
+	values := make([]any, 0, len(fields)+1)
+	sortedByID := false
+
+	for i, field := range fields {
+		column, ok := movieSortColumns[field.Field]
+		if !ok || i >= len(after.Values) {
+			continue
+		}
+
+		if field.Field == "id" {
+			sortedByID = true
+		}
+
+		operator := ">"
+		if field.Desc {
+			operator = "<"
+		}
+
+		columns = append(columns, column)
+		operators = append(operators, operator)
+		values = append(values, after.Values[i])
+	}
+
+	if !sortedByID {
+		columns = append(columns, "movies.id")
+		operators = append(operators, ">")
+		values = append(values, after.ID)
+	}
+
+	clauses := make([]string, 0, len(columns))
+	args := make([]any, 0, len(columns)*(len(columns)+1)/2)
+
+	for i := range columns {
+		parts := make([]string, 0, i+1)
+
+		for j := 0; j < i; j++ {
+			parts = append(parts, columns[j]+" = ?")
+			args = append(args, values[j])
+		}
+
+		parts = append(parts, columns[i]+" "+operators[i]+" ?")
+		args = append(args, values[i])
+		clauses = append(clauses, "("+strings.Join(parts, " AND ")+")")
+	}
+
+	return db.Where(strings.Join(clauses, " OR "), args...)
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
 }
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movie_app/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrCreateOIDCState = errors.New("failed to create login state")
+	ErrCreateIdentity  = errors.New("failed to link identity")
+
+	// ErrOIDCStateInvalid is returned for unknown, expired and used states.
+	ErrOIDCStateInvalid = errors.New("login state is invalid")
+	// ErrIdentityConflict is returned when linking a user to an identity at
+	// an issuer they are already linked to under another subject.
+	ErrIdentityConflict = errors.New("user is already linked to another identity at this issuer")
+)
+
+// OIDCRepository stores logins started at identity providers and the
+// identities users are linked to there.
+type OIDCRepository interface {
+	CreateState(ctx context.Context, state *domain.OIDCLoginState) error
+	ConsumeState(ctx context.Context, stateHash string) (*domain.OIDCLoginState, error)
+	GetIdentity(ctx context.Context, issuer, subject string) (*domain.UserIdentity, error)
+	TouchIdentity(ctx context.Context, identity *domain.UserIdentity) error
+	LinkIdentity(ctx context.Context, identity *domain.UserIdentity) error
+	CreateUser(ctx context.Context, user *domain.User, identity *domain.UserIdentity) error
+}
+
+type oidcRepository struct {
+	db *gorm.DB
+}
+
+func NewOIDCRepository(db *gorm.DB) *oidcRepository {
+	return &oidcRepository{db: db}
+}
+
+// CreateState stores the state, clearing out expired ones along the way.
+func (r *oidcRepository) CreateState(ctx context.Context, state *domain.OIDCLoginState) error {
+	db := r.db.WithContext(ctx)
+
+	if err := db.Where("expires_at <= ?", time.Now()).Delete(&domain.OIDCLoginState{}).Error; err != nil {
+		return ErrCreateOIDCState
+	}
+
+	if err := db.Create(state).Error; err != nil {
+		return ErrCreateOIDCState
+	}
+
+	return nil
+}
+
+// ConsumeState deletes the unexpired state with the hash and returns it, so
+// that every state is used at most once.
+func (r *oidcRepository) ConsumeState(ctx context.Context, stateHash string) (*domain.OIDCLoginState, error) {
+	var states []domain.OIDCLoginState
+
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Returning{}).
+		Where("state_hash = ? AND expires_at > ?", stateHash, time.Now()).
+		Delete(&states).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume login state: %w", err)
+	}
+
+	if len(states) == 0 {
+		return nil, ErrOIDCStateInvalid
+	}
+
+	return &states[0], nil
+}
+
+// GetIdentity returns the identity along with the user it is linked to,
+// unless the user deleted their account.
+func (r *oidcRepository) GetIdentity(ctx context.Context, issuer, subject string) (*domain.UserIdentity, error) {
+	var identity domain.UserIdentity
+
+	err := r.db.WithContext(ctx).
+		InnerJoins("User").
+		Where("issuer = ? AND subject = ?", issuer, subject).
+		First(&identity).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get identity: %w", err)
+	}
+
+	return &identity, nil
+}
+
+// TouchIdentity records a login with the identity and the email it had.
+func (r *oidcRepository) TouchIdentity(ctx context.Context, identity *domain.UserIdentity) error {
+	return r.db.WithContext(ctx).Model(identity).
+		Select("email", "last_login_at").
+		Updates(identity).Error
+}
+
+// LinkIdentity links the identity to its user, whose email is then taken as
+// verified, since the identity provider verified it.
+func (r *oidcRepository) LinkIdentity(ctx context.Context, identity *domain.UserIdentity) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var linked int64
+
+		err := tx.Model(&domain.UserIdentity{}).
+			Where("user_id = ? AND issuer = ?", identity.UserID, identity.Issuer).
+			Count(&linked).Error
+		if err != nil {
+			return ErrCreateIdentity
+		}
+
+		if linked > 0 {
+			return ErrIdentityConflict
+		}
+
+		if err := tx.Create(identity).Error; err != nil {
+			return ErrCreateIdentity
+		}
+
+		err = tx.Model(&domain.User{}).
+			Where("id = ? AND email_verified_at IS NULL", identity.UserID).
+			Update("email_verified_at", identity.CreatedAt).Error
+		if err != nil {
+			return ErrUpdateUser
+		}
+
+		return nil
+	})
+}
+
+// CreateUser registers the user along with the identity they logged in with.
+func (r *oidcRepository) CreateUser(ctx context.Context, user *domain.User, identity *domain.UserIdentity) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return ErrCreateUser
+		}
+
+		identity.UserID = user.ID
+
+		if err := tx.Create(identity).Error; err != nil {
+			return ErrCreateIdentity
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movie_app/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrCreatePasswordReset = errors.New("failed to create password reset token")
+
+	// ErrPasswordResetInvalid is returned for unknown, expired and used tokens.
+	ErrPasswordResetInvalid = errors.New("password reset token is invalid")
+)
+
+type PasswordResetRepository interface {
+	Create(ctx context.Context, token *domain.PasswordResetToken) error
+	LastCreatedAt(ctx context.Context, userID uint) (*time.Time, error)
+	Reset(ctx context.Context, tokenHash, passwordHash string) (uint, error)
+}
+
+type passwordResetRepository struct {
+	db *gorm.DB
+}
+
+func NewPasswordResetRepository(db *gorm.DB) *passwordResetRepository {
+	return &passwordResetRepository{db: db}
+}
+
+func (r *passwordResetRepository) Create(ctx context.Context, token *domain.PasswordResetToken) error {
+	if err := r.db.WithContext(ctx).Create(token).Error; err != nil {
+		return ErrCreatePasswordReset
+	}
+
+	return nil
+}
+
+// LastCreatedAt returns when the user's latest token was created, or nil
+// when the user has none.
+func (r *passwordResetRepository) LastCreatedAt(ctx context.Context, userID uint) (*time.Time, error) {
+	var createdAt *time.Time
+
+	err := r.db.WithContext(ctx).
+		Model(&domain.PasswordResetToken{}).
+		Where("user_id = ?", userID).
+		Select("MAX(created_at)").
+		Scan(&createdAt).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get password reset token: %w", err)
+	}
+
+	return createdAt, nil
+}
+
+// Reset sets the password of the user the token belongs to and uses up the
+// token along with every other outstanding token of the user. It returns the
+// user's ID, or ErrPasswordResetInvalid when the user has been deleted.
+func (r *passwordResetRepository) Reset(ctx context.Context, tokenHash, passwordHash string) (uint, error) {
+	var token domain.PasswordResetToken
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", tokenHash).
+			First(&token).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPasswordResetInvalid
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to get password reset token: %w", err)
+		}
+
+		now := time.Now()
+
+		if token.UsedAt != nil || !token.ExpiresAt.After(now) {
+			return ErrPasswordResetInvalid
+		}
+
+		// Deleted accounts are left out by the soft delete scope
+		result := tx.Model(&domain.User{}).
+			Where("id = ?", token.UserID).
+			Updates(map[string]any{"password": passwordHash, "updated_at": now})
+		if result.Error != nil {
+			return ErrUpdateUser
+		}
+
+		if result.RowsAffected == 0 {
+			return ErrPasswordResetInvalid
+		}
+
+		return tx.Model(&domain.PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", token.UserID).
+			Update("used_at", now).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return token.UserID, nil
+}
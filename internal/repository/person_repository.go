@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movie_app/internal/domain"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCreatePerson = errors.New("failed to create person")
+	ErrUpdatePerson = errors.New("failed to update person")
+	ErrDeletePerson = errors.New("failed to delete person")
+)
+
+type PersonRepository interface {
+	Create(ctx context.Context, person *domain.Person) (*domain.Person, error)
+	GetByID(ctx context.Context, id uint) (*domain.Person, error)
+	List(ctx context.Context, query domain.PersonQuery) (*domain.PersonPage, error)
+	Filmography(ctx context.Context, id uint) ([]domain.Credit, error)
+	CountCredits(ctx context.Context, id uint) (int64, error)
+	Update(ctx context.Context, person *domain.Person) (*domain.Person, error)
+	Delete(ctx context.Context, id uint) error
+}
+
+type personRepository struct {
+	db *gorm.DB
+}
+
+func NewPersonRepository(db *gorm.DB) *personRepository {
+	return &personRepository{db: db}
+}
+
+func (r *personRepository) Create(ctx context.Context, person *domain.Person) (*domain.Person, error) {
+	if err := r.db.WithContext(ctx).Create(person).Error; err != nil {
+		return nil, ErrCreatePerson
+	}
+
+	return person, nil
+}
+
+func (r *personRepository) GetByID(ctx context.Context, id uint) (*domain.Person, error) {
+	var person domain.Person
+	if err := r.db.WithContext(ctx).First(&person, id).Error; err != nil {
+		return nil, fmt.Errorf("failed to get person: %w", err)
+	}
+
+	return &person, nil
+}
+
+func (r *personRepository) List(ctx context.Context, query domain.PersonQuery) (*domain.PersonPage, error) {
+	db := r.db.WithContext(ctx).Model(&domain.Person{})
+	if query.Name != "" {
+		db = db.Where("name ILIKE ?", "%"+escapeLike(query.Name)+"%")
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count people: %w", err)
+	}
+
+	var people []domain.Person
+
+	err := db.Order("name").Order("id").
+		Offset((query.Page - 1) * query.Limit).
+		Limit(query.Limit).
+		Find(&people).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list people: %w", err)
+	}
+
+	return &domain.PersonPage{People: people, Total: total}, nil
+}
+
+func (r *personRepository) Filmography(ctx context.Context, id uint) ([]domain.Credit, error) {
+	var credits []domain.Credit
+
+	err := r.db.WithContext(ctx).
+		InnerJoins("Movie").
+		Where("credits.person_id = ?", id).
+		Order(`"Movie".year DESC`).Order("credits.department").Order("credits.billing_order").
+		Find(&credits).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get filmography: %w", err)
+	}
+
+	return credits, nil
+}
+
+func (r *personRepository) CountCredits(ctx context.Context, id uint) (int64, error) {
+	var count int64
+
+	err := r.db.WithContext(ctx).Model(&domain.Credit{}).Where("person_id = ?", id).Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count person credits: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *personRepository) Update(ctx context.Context, person *domain.Person) (*domain.Person, error) {
+	if err := r.db.WithContext(ctx).Save(person).Error; err != nil {
+		return nil, ErrUpdatePerson
+	}
+
+	return person, nil
+}
+
+func (r *personRepository) Delete(ctx context.Context, id uint) error {
+	if err := r.db.WithContext(ctx).Delete(&domain.Person{}, id).Error; err != nil {
+		return ErrDeletePerson
+	}
+
+	return nil
+}
+
+// findOrCreatePerson returns the person with the given name, matched
+// case-insensitively, creating them if nobody has that name yet.
+func findOrCreatePerson(tx *gorm.DB, name string) (*domain.Person, error) {
+	name = strings.TrimSpace(name)
+
+	var person domain.Person
+
+	err := tx.Where("LOWER(name) = LOWER(?)", name).Order("id").First(&person).Error
+	if err == nil {
+		return &person, nil
+	}
+
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	person = domain.Person{Name: name}
+	if err := tx.Create(&person).Error; err != nil {
+		return nil, err
+	}
+
+	return &person, nil
+}
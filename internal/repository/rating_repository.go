@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movie_app/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrSaveRating   = errors.New("failed to save rating")
+	ErrDeleteRating = errors.New("failed to delete rating")
+)
+
+type RatingRepository interface {
+	Upsert(ctx context.Context, rating *domain.UserRating, minVotes int) (*domain.UserRating, error)
+	Get(ctx context.Context, userID, movieID uint) (*domain.UserRating, error)
+	Delete(ctx context.Context, userID, movieID uint, minVotes int) error
+	Summary(ctx context.Context, movieID uint) (*domain.RatingSummary, error)
+}
+
+type ratingRepository struct {
+	db *gorm.DB
+}
+
+func NewRatingRepository(db *gorm.DB) *ratingRepository {
+	return &ratingRepository{db: db}
+}
+
+// Upsert creates or replaces the user's rating of the movie and refreshes the
+// movie's community rating in the same transaction.
+func (r *ratingRepository) Upsert(
+	ctx context.Context, rating *domain.UserRating, minVotes int,
+) (*domain.UserRating, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockMovie(tx, rating.MovieID); err != nil {
+			return err
+		}
+
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "movie_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"score", "updated_at"}),
+		}).Create(rating).Error
+		if err != nil {
+			return ErrSaveRating
+		}
+
+		return refreshCommunityRating(tx, rating.MovieID, minVotes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.Get(ctx, rating.UserID, rating.MovieID)
+}
+
+func (r *ratingRepository) Get(ctx context.Context, userID, movieID uint) (*domain.UserRating, error) {
+	var rating domain.UserRating
+
+	err := r.db.WithContext(ctx).Where("user_id = ? AND movie_id = ?", userID, movieID).First(&rating).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rating: %w", err)
+	}
+
+	return &rating, nil
+}
+
+func (r *ratingRepository) Delete(ctx context.Context, userID, movieID uint, minVotes int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockMovie(tx, movieID); err != nil {
+			return err
+		}
+
+		result := tx.Where("user_id = ? AND movie_id = ?", userID, movieID).Delete(&domain.UserRating{})
+		if result.Error != nil {
+			return ErrDeleteRating
+		}
+
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return refreshCommunityRating(tx, movieID, minVotes)
+	})
+}
+
+func (r *ratingRepository) Summary(ctx context.Context, movieID uint) (*domain.RatingSummary, error) {
+	var movie domain.Movie
+	columns := append([]string{"id"}, domain.CommunityRatingColumns...)
+	if err := r.db.WithContext(ctx).Select(columns).First(&movie, movieID).Error; err != nil {
+		return nil, fmt.Errorf("failed to get movie: %w", err)
+	}
+
+	histogram := []domain.RatingBucket{}
+
+	err := r.db.WithContext(ctx).Model(&domain.UserRating{}).
+		Select("ROUND(score)::int AS score, COUNT(*) AS count").
+		Where("movie_id = ?", movieID).
+		Group("ROUND(score)").
+		Order("score").
+		Scan(&histogram).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rating histogram: %w", err)
+	}
+
+	return &domain.RatingSummary{
+		MovieID:         movieID,
+		CommunityRating: movie.Community,
+		Histogram:       histogram,
+	}, nil
+}
+
+// lockMovie serializes rating writes per movie so that concurrent writers
+// can't overwrite each other's aggregates with stale ones.
+func lockMovie(tx *gorm.DB, movieID uint) error {
+	var movie domain.Movie
+
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&movie, movieID).Error
+}
+
+// refreshCommunityRating recomputes the movie's rating aggregates. The
+// weighted score is the Bayesian average (v*R + m*C) / (v + m), where v and
+// R are the movie's rating count and mean, C is the mean of all ratings and
+// m is the number of votes needed before R outweighs C.
+func refreshCommunityRating(tx *gorm.DB, movieID uint, minVotes int) error {
+	return tx.Exec(`
+		UPDATE movies SET
+			community_rating_count = movie.count,
+			community_rating_average = movie.average,
+			community_rating_weighted = CASE WHEN movie.count = 0 THEN 0
+				ELSE (movie.count * movie.average + @minVotes * global.average) / (movie.count + @minVotes) END
+		FROM
+			(SELECT COUNT(*) AS count, COALESCE(AVG(score), 0) AS average FROM user_ratings WHERE movie_id = @movieID) AS movie,
+			(SELECT COALESCE(AVG(score), 0) AS average FROM user_ratings) AS global
+		WHERE movies.id = @movieID`,
+		map[string]any{"movieID": movieID, "minVotes": minVotes},
+	).Error
+}
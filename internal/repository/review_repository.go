@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movie_app/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrCreateReview = errors.New("failed to create review")
+	ErrUpdateReview = errors.New("failed to update review")
+	ErrDeleteReview = errors.New("failed to delete review")
+	ErrSaveVote     = errors.New("failed to save vote")
+	ErrDeleteVote   = errors.New("failed to delete vote")
+)
+
+type ReviewRepository interface {
+	Create(ctx context.Context, review *domain.Review) (*domain.Review, error)
+	GetByID(ctx context.Context, id uint) (*domain.Review, error)
+	GetByAuthor(ctx context.Context, movieID, userID uint) (*domain.Review, error)
+	List(ctx context.Context, movieID uint, query domain.ReviewQuery) (*domain.ReviewPage, error)
+	Update(ctx context.Context, review *domain.Review) (*domain.Review, error)
+	Delete(ctx context.Context, id uint) error
+	Vote(ctx context.Context, vote *domain.ReviewVote) error
+	DeleteVote(ctx context.Context, reviewID, userID uint) error
+}
+
+type reviewRepository struct {
+	db *gorm.DB
+}
+
+func NewReviewRepository(db *gorm.DB) *reviewRepository {
+	return &reviewRepository{db: db}
+}
+
+func (r *reviewRepository) Create(ctx context.Context, review *domain.Review) (*domain.Review, error) {
+	if err := r.db.WithContext(ctx).Create(review).Error; err != nil {
+		return nil, ErrCreateReview
+	}
+
+	return r.GetByID(ctx, review.ID)
+}
+
+func (r *reviewRepository) GetByID(ctx context.Context, id uint) (*domain.Review, error) {
+	var review domain.Review
+	if err := withAuthorRating(r.db.WithContext(ctx)).First(&review, "reviews.id = ?", id).Error; err != nil {
+		return nil, fmt.Errorf("failed to get review: %w", err)
+	}
+
+	return &review, nil
+}
+
+func (r *reviewRepository) GetByAuthor(ctx context.Context, movieID, userID uint) (*domain.Review, error) {
+	var review domain.Review
+
+	err := withAuthorRating(r.db.WithContext(ctx)).
+		Where("reviews.movie_id = ? AND reviews.user_id = ?", movieID, userID).
+		First(&review).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get review: %w", err)
+	}
+
+	return &review, nil
+}
+
+// List returns a page of reviews in the query's status, of one movie or of
+// every movie when movieID is zero.
+func (r *reviewRepository) List(
+	ctx context.Context, movieID uint, query domain.ReviewQuery,
+) (*domain.ReviewPage, error) {
+	db := r.db.WithContext(ctx).Model(&domain.Review{}).Where("reviews.status = ?", query.Status)
+	if movieID != 0 {
+		db = db.Where("reviews.movie_id = ?", movieID)
+	} else {
+		db = db.Where("reviews.movie_id IN (?)", activeMovieIDs(r.db))
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count reviews: %w", err)
+	}
+
+	db = withAuthorRating(db)
+	if query.Sort == domain.ReviewSortHelpful {
+		db = db.Order("reviews.helpful_count - reviews.unhelpful_count DESC").Order("reviews.helpful_count DESC")
+	}
+
+	reviews := []domain.Review{}
+
+	err := db.Order("reviews.created_at DESC").Order("reviews.id DESC").
+		Offset((query.Page - 1) * query.Limit).
+		Limit(query.Limit).
+		Find(&reviews).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reviews: %w", err)
+	}
+
+	return &domain.ReviewPage{Reviews: reviews, Total: total}, nil
+}
+
+func (r *reviewRepository) Update(ctx context.Context, review *domain.Review) (*domain.Review, error) {
+	err := r.db.WithContext(ctx).
+		Omit("helpful_count", "unhelpful_count").
+		Save(review).Error
+	if err != nil {
+		return nil, ErrUpdateReview
+	}
+
+	return r.GetByID(ctx, review.ID)
+}
+
+func (r *reviewRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := deleteReviews(tx, "id = ?", id); err != nil {
+			return ErrDeleteReview
+		}
+
+		return nil
+	})
+}
+
+// Vote creates or replaces the user's vote on the review and refreshes the
+// review's vote counts in the same transaction.
+func (r *reviewRepository) Vote(ctx context.Context, vote *domain.ReviewVote) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockReview(tx, vote.ReviewID); err != nil {
+			return err
+		}
+
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "review_id"}, {Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"helpful", "updated_at"}),
+		}).Create(vote).Error
+		if err != nil {
+			return ErrSaveVote
+		}
+
+		return refreshVoteCounts(tx, vote.ReviewID)
+	})
+}
+
+func (r *reviewRepository) DeleteVote(ctx context.Context, reviewID, userID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockReview(tx, reviewID); err != nil {
+			return err
+		}
+
+		result := tx.Where("review_id = ? AND user_id = ?", reviewID, userID).Delete(&domain.ReviewVote{})
+		if result.Error != nil {
+			return ErrDeleteVote
+		}
+
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return refreshVoteCounts(tx, reviewID)
+	})
+}
+
+// withAuthorRating selects reviews along with their author's rating of the
+// reviewed movie.
+func withAuthorRating(db *gorm.DB) *gorm.DB {
+	return db.Model(&domain.Review{}).
+		Select("reviews.*, user_ratings.score AS author_rating").
+		Joins("LEFT JOIN user_ratings ON user_ratings.user_id = reviews.user_id AND user_ratings.movie_id = reviews.movie_id")
+}
+
+// deleteReviews deletes the reviews matching the condition along with their votes.
+func deleteReviews(tx *gorm.DB, query string, args ...any) error {
+	reviews := tx.Model(&domain.Review{}).Select("id").Where(query, args...)
+	if err := tx.Where("review_id IN (?)", reviews).Delete(&domain.ReviewVote{}).Error; err != nil {
+		return err
+	}
+
+	return tx.Where(query, args...).Delete(&domain.Review{}).Error
+}
+
+// lockReview serializes votes per review so that concurrent voters can't
+// overwrite each other's counts with stale ones.
+func lockReview(tx *gorm.DB, reviewID uint) error {
+	var review domain.Review
+
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&review, reviewID).Error
+}
+
+// refreshVoteCounts recomputes the review's helpful and unhelpful counts.
+func refreshVoteCounts(tx *gorm.DB, reviewID uint) error {
+	return tx.Exec(`
+		UPDATE reviews SET
+			helpful_count = (SELECT COUNT(*) FROM review_votes WHERE review_id = @reviewID AND helpful),
+			unhelpful_count = (SELECT COUNT(*) FROM review_votes WHERE review_id = @reviewID AND NOT helpful)
+		WHERE id = @reviewID`,
+		map[string]any{"reviewID": reviewID},
+	).Error
+}
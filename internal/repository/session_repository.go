@@ -0,0 +1,256 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movie_app/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrCreateSession = errors.New("failed to create session")
+	ErrRevokeSession = errors.New("failed to revoke session")
+	ErrListSessions  = errors.New("failed to list sessions")
+
+	// ErrRefreshTokenInvalid is returned for unknown, expired and revoked tokens.
+	ErrRefreshTokenInvalid = errors.New("refresh token is invalid")
+	// ErrRefreshTokenReused is returned when an already rotated token is
+	// presented again, after its whole session has been revoked.
+	ErrRefreshTokenReused = errors.New("refresh token was already used")
+)
+
+// SessionRepository stores sessions along with the refresh tokens that keep
+// them alive.
+type SessionRepository interface {
+	Create(ctx context.Context, session *domain.Session, token *domain.RefreshToken) error
+	Rotate(
+		ctx context.Context, tokenHash string, next *domain.RefreshToken, client domain.ClientInfo,
+	) (*domain.Session, error)
+	ListActive(ctx context.Context, userID uint) ([]domain.Session, error)
+	Revoke(ctx context.Context, userID, id uint) (*domain.Session, error)
+	RevokeAll(ctx context.Context, userID, exceptID uint) ([]domain.Session, error)
+	ListRevokedSince(ctx context.Context, since time.Time) ([]domain.Session, error)
+}
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) *sessionRepository {
+	return &sessionRepository{db: db}
+}
+
+// Create starts the session with its first refresh token.
+func (r *sessionRepository) Create(ctx context.Context, session *domain.Session, token *domain.RefreshToken) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(session).Error; err != nil {
+			return err
+		}
+
+		token.UserID = session.UserID
+		token.FamilyID = session.FamilyID
+
+		return tx.Create(token).Error
+	})
+	if err != nil {
+		return ErrCreateSession
+	}
+
+	return nil
+}
+
+// Rotate exchanges the token with the hash for next, which joins the same
+// session, and returns the refreshed session. Presenting a token that was
+// already rotated revokes the session; the revoked session is then returned
+// along with ErrRefreshTokenReused.
+func (r *sessionRepository) Rotate(
+	ctx context.Context, tokenHash string, next *domain.RefreshToken, client domain.ClientInfo,
+) (*domain.Session, error) {
+	var (
+		session domain.Session
+		reused  bool
+	)
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current domain.RefreshToken
+
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", tokenHash).
+			First(&current).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRefreshTokenInvalid
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to get refresh token: %w", err)
+		}
+
+		now := time.Now()
+
+		if current.RevokedAt != nil || !current.ExpiresAt.After(now) {
+			return ErrRefreshTokenInvalid
+		}
+
+		err = tx.Where("family_id = ?", current.FamilyID).First(&session).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRefreshTokenInvalid
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to get session: %w", err)
+		}
+
+		if current.UsedAt != nil {
+			// Committing the revocation, so the error is reported after the transaction
+			reused = true
+			session.RevokedAt = &now
+
+			return revokeSession(tx, &session, now)
+		}
+
+		next.UserID = current.UserID
+		next.FamilyID = current.FamilyID
+
+		if err := tx.Create(next).Error; err != nil {
+			return ErrCreateSession
+		}
+
+		err = tx.Model(&current).Updates(map[string]any{
+			"used_at":        now,
+			"replaced_by_id": next.ID,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		session.UserAgent = client.UserAgent
+		session.IPAddress = client.IPAddress
+		session.LastSeenAt = now
+		session.ExpiresAt = next.ExpiresAt
+
+		return tx.Model(&session).Updates(map[string]any{
+			"user_agent":   session.UserAgent,
+			"ip_address":   session.IPAddress,
+			"last_seen_at": session.LastSeenAt,
+			"expires_at":   session.ExpiresAt,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if reused {
+		return &session, ErrRefreshTokenReused
+	}
+
+	return &session, nil
+}
+
+// ListActive returns the user's sessions that are neither revoked nor
+// expired, most recently seen first.
+func (r *sessionRepository) ListActive(ctx context.Context, userID uint) ([]domain.Session, error) {
+	var sessions []domain.Session
+
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Order("id DESC").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, ErrListSessions
+	}
+
+	return sessions, nil
+}
+
+// Revoke ends the user's active session and its refresh tokens.
+func (r *sessionRepository) Revoke(ctx context.Context, userID, id uint) (*domain.Session, error) {
+	var session domain.Session
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+			First(&session).Error
+		if err != nil {
+			return fmt.Errorf("failed to get session: %w", err)
+		}
+
+		now := time.Now()
+		session.RevokedAt = &now
+
+		if err := revokeSession(tx, &session, now); err != nil {
+			return ErrRevokeSession
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// RevokeAll ends every active session of the user but the one with the
+// except ID, if any, and returns them.
+func (r *sessionRepository) RevokeAll(ctx context.Context, userID, exceptID uint) ([]domain.Session, error) {
+	var sessions []domain.Session
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, exceptID).
+			Find(&sessions).Error
+		if err != nil {
+			return ErrListSessions
+		}
+
+		now := time.Now()
+
+		for i := range sessions {
+			sessions[i].RevokedAt = &now
+
+			if err := revokeSession(tx, &sessions[i], now); err != nil {
+				return ErrRevokeSession
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// ListRevokedSince returns the sessions revoked after the time.
+func (r *sessionRepository) ListRevokedSince(ctx context.Context, since time.Time) ([]domain.Session, error) {
+	var sessions []domain.Session
+
+	err := r.db.WithContext(ctx).
+		Select("id", "revoked_at").
+		Where("revoked_at > ?", since).
+		Find(&sessions).Error
+	if err != nil {
+		return nil, ErrListSessions
+	}
+
+	return sessions, nil
+}
+
+func revokeSession(tx *gorm.DB, session *domain.Session, now time.Time) error {
+	err := tx.Model(&domain.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", session.FamilyID).
+		Update("revoked_at", now).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Model(&domain.Session{}).
+		Where("id = ? AND revoked_at IS NULL", session.ID).
+		Update("revoked_at", now).Error
+}
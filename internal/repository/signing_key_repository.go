@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"movie_app/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrCreateSigningKey = errors.New("failed to create signing key")
+	ErrListSigningKeys  = errors.New("failed to list signing keys")
+)
+
+type SigningKeyRepository interface {
+	Create(ctx context.Context, key *domain.SigningKey) (bool, error)
+	ListUnexpired(ctx context.Context, now time.Time) ([]domain.SigningKey, error)
+}
+
+type signingKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewSigningKeyRepository(db *gorm.DB) *signingKeyRepository {
+	return &signingKeyRepository{db: db}
+}
+
+// Create stores the key unless another key activates at the same time, which
+// happens when several instances rotate at once. It reports whether the key
+// was stored.
+func (r *signingKeyRepository) Create(ctx context.Context, key *domain.SigningKey) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "activates_at"}}, DoNothing: true}).
+		Create(key)
+	if result.Error != nil {
+		return false, ErrCreateSigningKey
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// ListUnexpired returns the keys that still verify tokens, in activation order.
+func (r *signingKeyRepository) ListUnexpired(ctx context.Context, now time.Time) ([]domain.SigningKey, error) {
+	var keys []domain.SigningKey
+
+	err := r.db.WithContext(ctx).
+		Where("expires_at > ?", now).
+		Order("activates_at").
+		Find(&keys).Error
+	if err != nil {
+		return nil, ErrListSigningKeys
+	}
+
+	return keys, nil
+}
@@ -3,21 +3,38 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
 	"movie_app/internal/domain"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var (
 	ErrCreateUser = errors.New("failed to create user")
 	ErrUpdateUser = errors.New("failed to update user")
 	ErrFetchUser  = errors.New("failed to fetch user")
+	ErrDeleteUser = errors.New("failed to delete user")
+	ErrPurgeUser  = errors.New("failed to purge user")
+
+	// ErrLastAdmin is returned instead of demoting or deleting the last admin.
+	ErrLastAdmin = errors.New("the last admin cannot be removed")
 )
 
 type UserRepository interface {
 	Create(ctx context.Context, user *domain.User) (*domain.User, error)
 	GetByID(ctx context.Context, id uint) (*domain.User, error)
 	GetByEmail(ctx context.Context, email string) (*domain.User, error)
+	List(ctx context.Context, query domain.UserQuery) (*domain.UserPage, error)
+	UpdateRole(ctx context.Context, id uint, role domain.Role) error
+	EmailTaken(ctx context.Context, email string) (bool, error)
+	UpdateProfile(ctx context.Context, user *domain.User) error
+	UpdatePassword(ctx context.Context, id uint, passwordHash string) error
+	Delete(ctx context.Context, id uint) error
+	Purge(
+		ctx context.Context, deletedBefore time.Time, minVotes int, deleteFiles func(fileNames []string) error,
+	) (int, error)
 }
 
 type userRepository struct {
@@ -54,10 +71,248 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*domain.
 	return &user, nil
 }
 
-func (r *userRepository) Update(user *domain.User) error {
-	return r.db.Save(user).Error
+func (r *userRepository) List(ctx context.Context, query domain.UserQuery) (*domain.UserPage, error) {
+	db := r.db.WithContext(ctx).Model(&domain.User{})
+	if query.Role != "" {
+		db = db.Where("role = ?", query.Role)
+	}
+
+	if query.Email != "" {
+		db = db.Where("email ILIKE ?", "%"+escapeLike(query.Email)+"%")
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	var users []domain.User
+
+	err := db.Order("email").Order("id").
+		Offset((query.Page - 1) * query.Limit).
+		Limit(query.Limit).
+		Find(&users).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	return &domain.UserPage{Users: users, Total: total}, nil
+}
+
+// UpdateRole assigns the role to the user, unless that would demote the last
+// admin.
+func (r *userRepository) UpdateRole(ctx context.Context, id uint, role domain.Role) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if role != domain.RoleAdmin {
+			if err := checkNotLastAdmin(tx, id); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&domain.User{ID: id}).Update("role", role).Error; err != nil {
+			return ErrUpdateUser
+		}
+
+		return nil
+	})
+}
+
+// checkNotLastAdmin returns ErrLastAdmin if the user is the only admin. The
+// admins stay locked until the transaction ends, so that concurrent
+// demotions and deletions cannot remove the last two admins at once.
+func checkNotLastAdmin(tx *gorm.DB, id uint) error {
+	var admins []uint
+
+	err := tx.Model(&domain.User{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("role = ?", domain.RoleAdmin).
+		Order("id").
+		Pluck("id", &admins).Error
+	if err != nil {
+		return fmt.Errorf("failed to lock admins: %w", err)
+	}
+
+	if len(admins) == 1 && admins[0] == id {
+		return ErrLastAdmin
+	}
+
+	return nil
+}
+
+// EmailTaken reports whether a user has the email, including users whose
+// deleted accounts have not been purged yet.
+func (r *userRepository) EmailTaken(ctx context.Context, email string) (bool, error) {
+	var count int64
+
+	err := r.db.WithContext(ctx).Unscoped().Model(&domain.User{}).Where("email = ?", email).Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+func (r *userRepository) UpdateProfile(ctx context.Context, user *domain.User) error {
+	err := r.db.WithContext(ctx).
+		Model(user).
+		Select("display_name", "avatar_url", "preferences", "updated_at").
+		Updates(user).Error
+	if err != nil {
+		return ErrUpdateUser
+	}
+
+	return nil
+}
+
+func (r *userRepository) UpdatePassword(ctx context.Context, id uint, passwordHash string) error {
+	if err := r.db.WithContext(ctx).Model(&domain.User{ID: id}).Update("password", passwordHash).Error; err != nil {
+		return ErrUpdateUser
+	}
+
+	return nil
+}
+
+// Delete soft-deletes the user, who can no longer log in, unless they are the
+// last admin. Their outstanding password reset tokens go right away, and the
+// rest of their data is kept until Purge removes it.
+func (r *userRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkNotLastAdmin(tx, id); err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&domain.User{}, id).Error; err != nil {
+			return ErrDeleteUser
+		}
+
+		if err := tx.Where("user_id = ?", id).Delete(&domain.PasswordResetToken{}).Error; err != nil {
+			return ErrDeleteUser
+		}
+
+		return nil
+	})
+}
+
+// Purge permanently deletes the users deleted before the time along with
+// their ratings, reviews, votes, watchlists, diaries, lists, sessions and
+// data exports, and returns how many it deleted. The archives of the exports
+// are kept in storage, outside the database, so deleteFiles is given their
+// names to delete before the rows go. Each user is purged in a transaction
+// of its own, so that a failure keeps the users purged so far, and those that
+// failed are tried again by the next purge.
+func (r *userRepository) Purge(
+	ctx context.Context, deletedBefore time.Time, minVotes int, deleteFiles func(fileNames []string) error,
+) (int, error) {
+	var ids []uint
+
+	err := r.db.WithContext(ctx).Unscoped().
+		Model(&domain.User{}).
+		Where("deleted_at < ?", deletedBefore).
+		Order("id").
+		Pluck("id", &ids).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to list deleted users: %w", err)
+	}
+
+	for i, id := range ids {
+		if err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return purgeUser(tx, id, minVotes, deleteFiles)
+		}); err != nil {
+			return i, fmt.Errorf("%w %d: %w", ErrPurgeUser, id, err)
+		}
+	}
+
+	return len(ids), nil
 }
 
-func (r *userRepository) Delete(id uint) error {
-	return r.db.Delete(&domain.User{}, id).Error
+func purgeUser(tx *gorm.DB, id uint, minVotes int, deleteFiles func(fileNames []string) error) error {
+	// Ratings and votes feed the aggregates of movies and reviews, which have
+	// to be recomputed without them
+	var ratedMovieIDs []uint
+
+	err := tx.Model(&domain.UserRating{}).
+		Where("user_id = ?", id).
+		Order("movie_id").
+		Pluck("movie_id", &ratedMovieIDs).Error
+	if err != nil {
+		return err
+	}
+
+	// Movies in the trash still hold the user's ratings
+	for _, movieID := range ratedMovieIDs {
+		if err := lockMovie(tx.Unscoped(), movieID); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Where("user_id = ?", id).Delete(&domain.UserRating{}).Error; err != nil {
+		return err
+	}
+
+	for _, movieID := range ratedMovieIDs {
+		if err := refreshCommunityRating(tx, movieID, minVotes); err != nil {
+			return err
+		}
+	}
+
+	var votedReviewIDs []uint
+	if err := tx.Model(&domain.ReviewVote{}).Where("user_id = ?", id).Pluck("review_id", &votedReviewIDs).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("user_id = ?", id).Delete(&domain.ReviewVote{}).Error; err != nil {
+		return err
+	}
+
+	for _, reviewID := range votedReviewIDs {
+		if err := refreshVoteCounts(tx, reviewID); err != nil {
+			return err
+		}
+	}
+
+	reviews := tx.Model(&domain.Review{}).Select("id").Where("user_id = ?", id)
+	if err := tx.Where("review_id IN (?)", reviews).Delete(&domain.ReviewVote{}).Error; err != nil {
+		return err
+	}
+
+	lists := tx.Model(&domain.CuratedList{}).Select("id").Where("owner_id = ?", id)
+	if err := tx.Where("list_id IN (?)", lists).Delete(&domain.ListEntry{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("list_id IN (?) OR user_id = ?", lists, id).Delete(&domain.ListCollaborator{}).Error; err != nil {
+		return err
+	}
+
+	for _, model := range []any{
+		&domain.Review{},
+		&domain.WatchlistEntry{},
+		&domain.DiaryEntry{},
+		&domain.RefreshToken{},
+		&domain.Session{},
+	} {
+		if err := tx.Where("user_id = ?", id).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Where("owner_id = ?", id).Delete(&domain.CuratedList{}).Error; err != nil {
+		return err
+	}
+
+	var exportFileNames []string
+
+	err = tx.Model(&domain.DataExport{}).
+		Where("user_id = ? AND file_name <> ''", id).
+		Pluck("file_name", &exportFileNames).Error
+	if err != nil {
+		return err
+	}
+
+	if err := deleteFiles(exportFileNames); err != nil {
+		return err
+	}
+
+	// Tokens, two-factor secrets, recovery codes and data exports cascade
+	return tx.Unscoped().Delete(&domain.User{}, id).Error
 }
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movie_app/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAddWatchlistEntry    = errors.New("failed to add movie to watchlist")
+	ErrRemoveWatchlistEntry = errors.New("failed to remove movie from watchlist")
+)
+
+type WatchlistRepository interface {
+	Add(ctx context.Context, entry *domain.WatchlistEntry) (*domain.WatchlistEntry, error)
+	Get(ctx context.Context, userID, movieID uint) (*domain.WatchlistEntry, error)
+	List(ctx context.Context, userID uint, query domain.WatchlistQuery) (*domain.WatchlistPage, error)
+	Remove(ctx context.Context, userID, movieID uint) error
+}
+
+type watchlistRepository struct {
+	db *gorm.DB
+}
+
+func NewWatchlistRepository(db *gorm.DB) *watchlistRepository {
+	return &watchlistRepository{db: db}
+}
+
+func (r *watchlistRepository) Add(ctx context.Context, entry *domain.WatchlistEntry) (*domain.WatchlistEntry, error) {
+	if err := r.db.WithContext(ctx).Create(entry).Error; err != nil {
+		return nil, ErrAddWatchlistEntry
+	}
+
+	return r.Get(ctx, entry.UserID, entry.MovieID)
+}
+
+func (r *watchlistRepository) Get(ctx context.Context, userID, movieID uint) (*domain.WatchlistEntry, error) {
+	var entry domain.WatchlistEntry
+
+	err := r.db.WithContext(ctx).
+		Preload("Movie.Genres").
+		Where("user_id = ? AND movie_id = ?", userID, movieID).
+		First(&entry).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get watchlist entry: %w", err)
+	}
+
+	return &entry, nil
+}
+
+func (r *watchlistRepository) List(
+	ctx context.Context, userID uint, query domain.WatchlistQuery,
+) (*domain.WatchlistPage, error) {
+	db := r.db.WithContext(ctx).Model(&domain.WatchlistEntry{}).
+		Where("user_id = ? AND movie_id IN (?)", userID, activeMovieIDs(r.db))
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count watchlist: %w", err)
+	}
+
+	var entries []domain.WatchlistEntry
+
+	err := db.Preload("Movie.Genres").
+		Order("created_at DESC").Order("id DESC").
+		Offset((query.Page - 1) * query.Limit).
+		Limit(query.Limit).
+		Find(&entries).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list watchlist: %w", err)
+	}
+
+	return &domain.WatchlistPage{Entries: entries, Total: total}, nil
+}
+
+func (r *watchlistRepository) Remove(ctx context.Context, userID, movieID uint) error {
+	result := r.db.WithContext(ctx).
+		Where("user_id = ? AND movie_id = ?", userID, movieID).
+		Delete(&domain.WatchlistEntry{})
+	if result.Error != nil {
+		return ErrRemoveWatchlistEntry
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
@@ -1,46 +1,185 @@
 package router
 
 import (
+	"fmt"
+	"movie_app/internal/config"
+	"movie_app/internal/domain"
 	"movie_app/internal/handler"
 	"movie_app/internal/middleware"
 
 	"github.com/gin-gonic/gin"
-	"github.com/swaggo/gin-swagger"
 	"github.com/swaggo/files"
+	"github.com/swaggo/gin-swagger"
 	uberfx "go.uber.org/fx"
 )
 
 type RouterParams struct {
 	uberfx.In
 
-	MovieHandler   *handler.MovieHandler
-	UserHandler    *handler.UserHandler
-	AuthMiddleware *middleware.AuthMiddleware
+	MovieHandler        *handler.MovieHandler
+	UserHandler         *handler.UserHandler
+	GenreHandler        *handler.GenreHandler
+	PersonHandler       *handler.PersonHandler
+	RatingHandler       *handler.RatingHandler
+	ReviewHandler       *handler.ReviewHandler
+	WatchlistHandler    *handler.WatchlistHandler
+	DiaryHandler        *handler.DiaryHandler
+	ListHandler         *handler.ListHandler
+	SessionHandler      *handler.SessionHandler
+	JWKSHandler         *handler.JWKSHandler
+	PasswordHandler     *handler.PasswordHandler
+	VerificationHandler *handler.VerificationHandler
+	MFAHandler          *handler.MFAHandler
+	DataExportHandler   *handler.DataExportHandler
+	APIKeyHandler       *handler.APIKeyHandler
+	OIDCHandler         *handler.OIDCHandler
+	AuthMiddleware      *middleware.AuthMiddleware
+	Config              *config.Config
 }
 
-func NewRouter(p RouterParams) *gin.Engine {
+func NewRouter(p RouterParams) (*gin.Engine, error) {
 	router := gin.Default()
 
+	// Client IP addresses key the login lockouts, so X-Forwarded-For is only
+	// believed when a trusted proxy sets it
+	if err := router.SetTrustedProxies(p.Config.Server.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("setting trusted proxies: %w", err)
+	}
+
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	router.GET("/.well-known/jwks.json", p.JWKSHandler.GetJWKS)
 
-	
 	// Public routes
 	router.POST("/api/v1/auth/register", p.UserHandler.Register)
 	router.POST("/api/v1/auth/login", p.UserHandler.Login)
+	router.POST("/api/v1/auth/mfa/verify", p.UserHandler.VerifyMFA)
+	router.POST("/api/v1/auth/refresh", p.UserHandler.Refresh)
+	router.POST("/api/v1/auth/password/forgot", p.PasswordHandler.ForgotPassword)
+	router.POST("/api/v1/auth/password/reset", p.PasswordHandler.ResetPassword)
+	router.GET("/api/v1/auth/verify", p.VerificationHandler.VerifyEmail)
+	router.POST("/api/v1/auth/verify/resend", p.VerificationHandler.ResendVerification)
+	router.GET("/api/v1/auth/oidc/authorize", p.OIDCHandler.Authorize)
+	router.POST("/api/v1/auth/oidc/callback", p.OIDCHandler.Callback)
+
+	// Account routes, available before the email is verified
+	account := router.Group("/api/v1")
+	account.Use(p.AuthMiddleware.Authenticate())
 
-	// Protected routes
+	account.POST("/auth/logout", p.SessionHandler.Logout)
+	account.GET("/users/me", p.UserHandler.GetUser)
+	account.DELETE("/users/me", p.UserHandler.DeleteAccount)
+	account.POST("/users/me/password", p.UserHandler.ChangePassword)
+	account.POST("/users/me/email", p.UserHandler.ChangeEmail)
+	account.GET("/users/me/sessions", p.SessionHandler.GetSessions)
+	account.DELETE("/users/me/sessions/:id", p.SessionHandler.RevokeSession)
+	account.GET("/users/me/mfa", p.MFAHandler.GetMFAStatus)
+	account.POST("/users/me/mfa/totp", p.MFAHandler.EnrollTOTP)
+	account.POST("/users/me/mfa/totp/confirm", p.MFAHandler.ConfirmTOTP)
+	account.POST("/users/me/mfa/disable", p.MFAHandler.DisableMFA)
+	account.POST("/users/me/mfa/recovery-codes", p.MFAHandler.RegenerateRecoveryCodes)
+	account.POST("/users/me/export", p.DataExportHandler.RequestExport)
+	account.GET("/users/me/export", p.DataExportHandler.GetExport)
+	account.GET("/users/me/export/download", p.DataExportHandler.DownloadExport)
+	account.POST("/users/me/api-keys", p.APIKeyHandler.CreateAPIKey)
+	account.GET("/users/me/api-keys", p.APIKeyHandler.GetAPIKeys)
+	account.DELETE("/users/me/api-keys/:id", p.APIKeyHandler.RevokeAPIKey)
+
+	// Protected routes, also available to API keys
 	protected := router.Group("/api/v1")
-	protected.Use(p.AuthMiddleware.Authenticate())
+	protected.Use(p.AuthMiddleware.AuthenticateWithAPIKey(), p.AuthMiddleware.RequireVerifiedEmail())
+
+	canWriteMovies := middleware.RequirePermission(domain.PermMoviesWrite)
+	canWritePeople := middleware.RequirePermission(domain.PermPeopleWrite)
+	canWriteGenres := middleware.RequirePermission(domain.PermGenresWrite)
+	canModerateReviews := middleware.RequirePermission(domain.PermReviewsModerate)
+	canAdminUsers := middleware.RequirePermission(domain.PermUsersAdmin)
+	canManageTrash := middleware.RequirePermission(domain.PermMoviesTrash)
 
 	// User routes
-	protected.GET("/users/me", p.UserHandler.GetUser)
+	protected.PATCH("/users/me", p.UserHandler.UpdateProfile)
+	protected.GET("/users/me/watchlist", p.WatchlistHandler.GetWatchlist)
+	protected.POST("/users/me/watchlist", p.WatchlistHandler.AddToWatchlist)
+	protected.DELETE("/users/me/watchlist/:movieId", p.WatchlistHandler.RemoveFromWatchlist)
+	protected.GET("/users/me/diary", p.DiaryHandler.GetDiary)
+	protected.POST("/users/me/diary", p.DiaryHandler.LogDiaryEntry)
+	protected.GET("/users/me/diary/:id", p.DiaryHandler.GetDiaryEntry)
+	protected.PUT("/users/me/diary/:id", p.DiaryHandler.UpdateDiaryEntry)
+	protected.DELETE("/users/me/diary/:id", p.DiaryHandler.DeleteDiaryEntry)
+	protected.GET("/users/me/lists", p.ListHandler.GetMyLists)
 
 	// Movie routes
-	protected.POST("/movies", p.MovieHandler.CreateMovie)
+	protected.POST("/movies", canWriteMovies, p.MovieHandler.CreateMovie)
+	protected.GET("/movies/search", p.MovieHandler.SearchMovies)
+	protected.GET("/movies/suggest", p.MovieHandler.SuggestMovies)
 	protected.GET("/movies/:id", p.MovieHandler.GetMovie)
 	protected.GET("/movies", p.MovieHandler.GetAllMovies)
+	// Movies can also be changed by whoever added them, which MovieService checks
 	protected.PUT("/movies/:id", p.MovieHandler.UpdateMovie)
 	protected.DELETE("/movies/:id", p.MovieHandler.DeleteMovie)
 
-	return router
+	// Rating routes
+	protected.GET("/movies/:id/ratings", p.RatingHandler.GetRatingSummary)
+	protected.GET("/movies/:id/my-rating", p.RatingHandler.GetMyRating)
+	protected.PUT("/movies/:id/my-rating", p.RatingHandler.RateMovie)
+	protected.DELETE("/movies/:id/my-rating", p.RatingHandler.DeleteMyRating)
+
+	// Review routes
+	protected.GET("/movies/:id/reviews", p.ReviewHandler.GetMovieReviews)
+	protected.POST("/movies/:id/reviews", p.ReviewHandler.CreateReview)
+	protected.GET("/reviews/:id", p.ReviewHandler.GetReview)
+	protected.PUT("/reviews/:id", p.ReviewHandler.UpdateReview)
+	protected.DELETE("/reviews/:id", p.ReviewHandler.DeleteReview)
+	protected.PUT("/reviews/:id/vote", p.ReviewHandler.VoteReview)
+	protected.DELETE("/reviews/:id/vote", p.ReviewHandler.DeleteReviewVote)
+
+	// List routes
+	protected.POST("/lists", p.ListHandler.CreateList)
+	protected.GET("/lists", p.ListHandler.GetPublicLists)
+	protected.GET("/lists/by-slug/:slug", p.ListHandler.GetListBySlug)
+	protected.GET("/lists/by-slug/:slug/entries", p.ListHandler.GetListEntriesBySlug)
+	protected.GET("/lists/:id", p.ListHandler.GetList)
+	protected.PUT("/lists/:id", p.ListHandler.UpdateList)
+	protected.DELETE("/lists/:id", p.ListHandler.DeleteList)
+	protected.GET("/lists/:id/entries", p.ListHandler.GetListEntries)
+	protected.POST("/lists/:id/entries", p.ListHandler.AddListEntry)
+	protected.PUT("/lists/:id/entries/:entryId", p.ListHandler.UpdateListEntry)
+	protected.DELETE("/lists/:id/entries/:entryId", p.ListHandler.RemoveListEntry)
+	protected.POST("/lists/:id/entries/:entryId/move", p.ListHandler.MoveListEntry)
+	protected.PUT("/lists/:id/order", p.ListHandler.ReorderList)
+	protected.POST("/lists/:id/collaborators", p.ListHandler.AddCollaborator)
+	protected.DELETE("/lists/:id/collaborators/:userId", p.ListHandler.RemoveCollaborator)
+
+	// Credit routes
+	protected.GET("/movies/:id/credits", p.PersonHandler.GetMovieCredits)
+	protected.POST("/movies/:id/credits", canWriteMovies, p.PersonHandler.AddMovieCredit)
+	protected.PUT("/movies/:id/credits/:creditId", canWriteMovies, p.PersonHandler.UpdateMovieCredit)
+	protected.DELETE("/movies/:id/credits/:creditId", canWriteMovies, p.PersonHandler.DeleteMovieCredit)
+
+	// People routes
+	protected.POST("/people", canWritePeople, p.PersonHandler.CreatePerson)
+	protected.GET("/people", p.PersonHandler.GetAllPeople)
+	protected.GET("/people/:id", p.PersonHandler.GetPerson)
+	protected.GET("/people/:id/filmography", p.PersonHandler.GetFilmography)
+	protected.PUT("/people/:id", canWritePeople, p.PersonHandler.UpdatePerson)
+	protected.DELETE("/people/:id", canWritePeople, p.PersonHandler.DeletePerson)
+
+	// Genre routes
+	protected.GET("/genres", p.GenreHandler.GetAllGenres)
+	protected.GET("/genres/:id", p.GenreHandler.GetGenre)
+
+	protected.POST("/genres", canWriteGenres, p.GenreHandler.CreateGenre)
+	protected.PUT("/genres/:id", canWriteGenres, p.GenreHandler.UpdateGenre)
+	protected.DELETE("/genres/:id", canWriteGenres, p.GenreHandler.DeleteGenre)
+
+	// Admin routes
+	protected.GET("/admin/users", canAdminUsers, p.UserHandler.GetUsers)
+	protected.PUT("/admin/users/:id/role", canAdminUsers, p.UserHandler.UpdateUserRole)
+	protected.POST("/admin/users/:id/unlock", canAdminUsers, p.UserHandler.UnlockUser)
+	protected.GET("/admin/reviews", canModerateReviews, p.ReviewHandler.GetReviewsForModeration)
+	protected.PUT("/admin/reviews/:id/status", canModerateReviews, p.ReviewHandler.ModerateReview)
+	protected.GET("/admin/movies/trash", canManageTrash, p.MovieHandler.GetTrashedMovies)
+	protected.POST("/admin/movies/trash/:id/restore", canManageTrash, p.MovieHandler.RestoreMovie)
+	protected.DELETE("/admin/movies/trash/:id", canManageTrash, p.MovieHandler.PurgeMovie)
+
+	return router, nil
 }
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"movie_app/internal/repository"
+	"movie_app/internal/storage"
+	"time"
+)
+
+type AccountPurgerOptions struct {
+	GracePeriod time.Duration // How long deleted accounts are kept before they are purged
+	MinVotes    int           // For recomputing the community ratings of movies the users rated
+}
+
+// AccountPurger permanently deletes accounts once their deletion grace
+// period is over, along with the users' data and data export archives.
+type AccountPurger struct {
+	users   repository.UserRepository
+	storage storage.Storage
+	options AccountPurgerOptions
+}
+
+func NewAccountPurger(
+	users repository.UserRepository, storage storage.Storage, options AccountPurgerOptions,
+) *AccountPurger {
+	return &AccountPurger{
+		users:   users,
+		storage: storage,
+		options: options,
+	}
+}
+
+// Purge deletes the accounts deleted longer than the grace period ago.
+func (p *AccountPurger) Purge(ctx context.Context) error {
+	purged, err := p.users.Purge(ctx, time.Now().Add(-p.options.GracePeriod), p.options.MinVotes, p.deleteFiles)
+	if purged > 0 {
+		log.Printf("Purged %d deleted accounts", purged)
+	}
+
+	if err != nil {
+		return fmt.Errorf("purging deleted accounts: %w", err)
+	}
+
+	return nil
+}
+
+// deleteFiles deletes the data export archives of a purged user.
+func (p *AccountPurger) deleteFiles(fileNames []string) error {
+	for _, fileName := range fileNames {
+		if err := p.storage.Delete(fileName); err != nil {
+			return fmt.Errorf("deleting data export: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Run purges deleted accounts every interval until the context is done.
+func (p *AccountPurger) Run(ctx context.Context, interval time.Duration) {
+	runPeriodically(ctx, interval, "purge deleted accounts", p.Purge)
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"movie_app/internal/domain"
+	"movie_app/internal/repository"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAPIKeyNotFound = errors.New("API key not found")
+	ErrInvalidAPIKey  = errors.New("API key is invalid, expired or revoked")
+	ErrInvalidScope   = errors.New("scopes must be permissions granted by your role")
+	ErrInvalidExpiry  = errors.New("expiry must be in the future")
+)
+
+type APIKeyService interface {
+	CreateAPIKey(
+		ctx context.Context, actor domain.Actor, req domain.CreateAPIKeyRequest, client domain.ClientInfo,
+	) (*domain.CreatedAPIKey, error)
+	ListAPIKeys(ctx context.Context, userID uint) ([]domain.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID, id uint, client domain.ClientInfo) error
+	AuthenticateAPIKey(ctx context.Context, key string) (*domain.APIKey, error)
+}
+
+type apiKeyService struct {
+	repo  repository.APIKeyRepository
+	audit repository.AuditRepository
+}
+
+func NewAPIKeyService(repo repository.APIKeyRepository, audit repository.AuditRepository) *apiKeyService {
+	return &apiKeyService{
+		repo:  repo,
+		audit: audit,
+	}
+}
+
+// CreateAPIKey mints a key for the actor, scoped to permissions their role
+// grants. The key itself is only ever returned here.
+func (s *apiKeyService) CreateAPIKey(
+	ctx context.Context, actor domain.Actor, req domain.CreateAPIKeyRequest, client domain.ClientInfo,
+) (*domain.CreatedAPIKey, error) {
+	for _, scope := range req.Scopes {
+		if !actor.Role.Can(scope) {
+			return nil, ErrInvalidScope
+		}
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, ErrInvalidExpiry
+	}
+
+	secret, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	key := domain.APIKeyPrefix + secret
+
+	apiKey := domain.APIKey{
+		UserID:    actor.UserID,
+		Name:      strings.TrimSpace(req.Name),
+		Prefix:    key[:domain.APIKeyShownLength],
+		KeyHash:   hashToken(key),
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+	}
+
+	if err := s.repo.Create(ctx, &apiKey); err != nil {
+		return nil, fmt.Errorf("creating API key: %w", err)
+	}
+
+	s.recordAudit(ctx, domain.AuditAPIKeyCreated, actor.UserID, client, fmt.Sprintf(
+		"API key %d (%s) created with scopes %s", apiKey.ID, apiKey.Prefix, formatScopes(apiKey.Scopes),
+	))
+
+	return &domain.CreatedAPIKey{APIKey: apiKey, Key: key}, nil
+}
+
+// ListAPIKeys returns the user's keys that are neither revoked nor expired.
+func (s *apiKeyService) ListAPIKeys(ctx context.Context, userID uint) ([]domain.APIKey, error) {
+	keys, err := s.repo.ListActive(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("listing API keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey disables one of the user's keys for good.
+func (s *apiKeyService) RevokeAPIKey(ctx context.Context, userID, id uint, client domain.ClientInfo) error {
+	apiKey, err := s.repo.Revoke(ctx, userID, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrAPIKeyNotFound
+	}
+
+	if err != nil {
+		return fmt.Errorf("revoking API key: %w", err)
+	}
+
+	s.recordAudit(ctx, domain.AuditAPIKeyRevoked, userID, client, fmt.Sprintf(
+		"API key %d (%s) revoked", apiKey.ID, apiKey.Prefix,
+	))
+
+	return nil
+}
+
+// AuthenticateAPIKey returns the active key along with its owner, and
+// records that it was used.
+func (s *apiKeyService) AuthenticateAPIKey(ctx context.Context, key string) (*domain.APIKey, error) {
+	if !strings.HasPrefix(key, domain.APIKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	apiKey, err := s.repo.GetByHash(ctx, hashToken(key))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("getting API key: %w", err)
+	}
+
+	now := time.Now()
+
+	if !apiKey.ActiveAt(now) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if err := s.repo.Touch(ctx, apiKey.ID, now); err != nil {
+		log.Printf("Failed to record use of API key %d: %v", apiKey.ID, err)
+	}
+
+	return apiKey, nil
+}
+
+func (s *apiKeyService) recordAudit(
+	ctx context.Context, action domain.AuditAction, userID uint, client domain.ClientInfo, details string,
+) {
+	err := s.audit.Create(ctx, &domain.AuditEntry{
+		Action:    action,
+		ActorID:   &userID,
+		UserID:    &userID,
+		IPAddress: client.IPAddress,
+		Details:   details,
+	})
+	if err != nil {
+		log.Printf("Failed to record %s audit entry: %v", action, err)
+	}
+}
+
+func formatScopes(scopes []domain.Permission) string {
+	names := make([]string, len(scopes))
+	for i, scope := range scopes {
+		names[i] = string(scope)
+	}
+
+	return strings.Join(names, ", ")
+}
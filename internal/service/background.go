@@ -0,0 +1,25 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// runPeriodically runs the task every interval until the context is done,
+// logging its failures.
+func runPeriodically(ctx context.Context, interval time.Duration, name string, task func(context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := task(ctx); err != nil {
+				log.Printf("Failed to %s: %v", name, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"movie_app/internal/domain"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidCursor     = errors.New("invalid cursor")
+	ErrCursorMismatch    = errors.New("cursor does not match the query's sort and filters")
+	ErrInvalidPagination = errors.New("pagination must be either offset or cursor")
+)
+
+// cursorPayload is the signed content of an opaque pagination cursor.
+type cursorPayload struct {
+	Query  string `json:"q"`
+	Values []any  `json:"v"`
+	ID     uint   `json:"id"`
+}
+
+// cursorCodec signs and verifies keyset pagination cursors so that clients
+// cannot forge positions or reuse a cursor with a different query.
+type cursorCodec struct {
+	secret []byte
+}
+
+func newCursorCodec(secret string) *cursorCodec {
+	return &cursorCodec{secret: []byte(secret)}
+}
+
+// Encode returns the cursor pointing after the given movie.
+func (c *cursorCodec) Encode(query domain.MovieQuery, last *domain.Movie) (string, error) {
+	payload := cursorPayload{
+		Query:  queryFingerprint(query),
+		Values: make([]any, 0, len(query.SortFields)),
+		ID:     last.ID,
+	}
+
+	for _, field := range query.SortFields {
+		payload.Values = append(payload.Values, last.SortValue(field.Field))
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("encoding cursor: %w", err)
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(data)
+
+	return encoded + "." + c.sign(encoded), nil
+}
+
+// Decode verifies the cursor and returns the position it points after.
+func (c *cursorCodec) Decode(query domain.MovieQuery, cursor string) (*domain.CursorPosition, error) {
+	encoded, signature, found := strings.Cut(cursor, ".")
+	if !found || !hmac.Equal([]byte(signature), []byte(c.sign(encoded))) {
+		return nil, ErrInvalidCursor
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var payload cursorPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	if payload.Query != queryFingerprint(query) || len(payload.Values) != len(query.SortFields) {
+		return nil, ErrCursorMismatch
+	}
+
+	for i, field := range query.SortFields {
+		if field.Field != "createdAt" {
+			continue
+		}
+
+		value, ok := payload.Values[i].(string)
+		if !ok {
+			return nil, ErrInvalidCursor
+		}
+
+		createdAt, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+
+		payload.Values[i] = createdAt
+	}
+
+	return &domain.CursorPosition{Values: payload.Values, ID: payload.ID}, nil
+}
+
+func (c *cursorCodec) sign(encoded string) string {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte(encoded))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// queryFingerprint hashes the normalized sort and filters of a query, so a
+// cursor is only accepted for the query that produced it.
+func queryFingerprint(query domain.MovieQuery) string {
+	values := url.Values{}
+
+	sortKeys := make([]string, 0, len(query.SortFields))
+	for _, field := range query.SortFields {
+		key := field.Field
+		if field.Desc {
+			key = "-" + key
+		}
+
+		sortKeys = append(sortKeys, key)
+	}
+
+	values.Set("sort", strings.Join(sortKeys, ","))
+	values.Set("genre", query.Genre)
+	values.Set("director", query.Director)
+	setOptional(values, "yearFrom", query.YearFrom)
+	setOptional(values, "yearTo", query.YearTo)
+	setOptional(values, "ratingMin", query.RatingMin)
+	setOptional(values, "ratingMax", query.RatingMax)
+	setOptional(values, "durationMin", query.DurationMin)
+	setOptional(values, "durationMax", query.DurationMax)
+
+	sum := sha256.Sum256([]byte(values.Encode()))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func setOptional[T int | float64](values url.Values, key string, value *T) {
+	if value == nil {
+		return
+	}
+
+	values.Set(key, fmt.Sprint(*value))
+}
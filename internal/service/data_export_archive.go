@@ -0,0 +1,247 @@
+package service
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"movie_app/internal/domain"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type exportedReview struct {
+	domain.Review
+	MovieTitle string `json:"movieTitle"`
+}
+
+type exportedList struct {
+	domain.CuratedList
+	Entries []exportedListEntry `json:"entries"`
+}
+
+type exportedListEntry struct {
+	Position   int       `json:"position"`
+	MovieID    uint      `json:"movieId"`
+	MovieTitle string    `json:"movieTitle"`
+	Note       string    `json:"note,omitempty"`
+	AddedByID  uint      `json:"addedById"`
+	AddedAt    time.Time `json:"addedAt"`
+}
+
+// writeArchive writes the user data as a ZIP archive of JSON files for
+// nested data and CSV files for tabular data.
+func writeArchive(w io.Writer, data *domain.UserData) error {
+	archive := zip.NewWriter(w)
+	title := func(movieID uint) string { return data.MovieTitles[movieID] }
+
+	reviews := make([]exportedReview, 0, len(data.Reviews))
+	for _, review := range data.Reviews {
+		reviews = append(reviews, exportedReview{Review: review, MovieTitle: title(review.MovieID)})
+	}
+
+	lists := make([]exportedList, 0, len(data.Lists))
+	for _, list := range data.Lists {
+		entries := []exportedListEntry{}
+
+		for _, entry := range data.ListEntries {
+			if entry.ListID != list.ID {
+				continue
+			}
+
+			entries = append(entries, exportedListEntry{
+				Position:   entry.Position,
+				MovieID:    entry.MovieID,
+				MovieTitle: title(entry.MovieID),
+				Note:       entry.Note,
+				AddedByID:  entry.AddedByID,
+				AddedAt:    entry.CreatedAt,
+			})
+		}
+
+		list.EntryCount = int64(len(entries))
+		lists = append(lists, exportedList{CuratedList: list, Entries: entries})
+	}
+
+	ratings := [][]string{{"movie_id", "movie_title", "score", "created_at", "updated_at"}}
+	for _, rating := range data.Ratings {
+		ratings = append(ratings, []string{
+			formatID(rating.MovieID),
+			title(rating.MovieID),
+			strconv.FormatFloat(rating.Score, 'f', 1, 64),
+			formatTime(rating.CreatedAt),
+			formatTime(rating.UpdatedAt),
+		})
+	}
+
+	votes := [][]string{{"review_id", "helpful", "created_at", "updated_at"}}
+	for _, vote := range data.ReviewVotes {
+		votes = append(votes, []string{
+			formatID(vote.ReviewID),
+			strconv.FormatBool(vote.Helpful),
+			formatTime(vote.CreatedAt),
+			formatTime(vote.UpdatedAt),
+		})
+	}
+
+	watchlist := [][]string{{"movie_id", "movie_title", "added_at"}}
+	for _, entry := range data.Watchlist {
+		watchlist = append(watchlist, []string{
+			formatID(entry.MovieID),
+			title(entry.MovieID),
+			formatTime(entry.CreatedAt),
+		})
+	}
+
+	diary := [][]string{{"movie_id", "movie_title", "watched_on", "rewatch", "rating", "note", "created_at"}}
+	for _, entry := range data.Diary {
+		rating := ""
+		if entry.Rating != nil {
+			rating = strconv.FormatFloat(*entry.Rating, 'f', 1, 64)
+		}
+
+		diary = append(diary, []string{
+			formatID(entry.MovieID),
+			title(entry.MovieID),
+			entry.WatchedOn.Format(time.DateOnly),
+			strconv.FormatBool(entry.Rewatch),
+			rating,
+			entry.Note,
+			formatTime(entry.CreatedAt),
+		})
+	}
+
+	sessions := [][]string{
+		{"id", "device", "user_agent", "ip_address", "created_at", "last_seen_at", "expires_at", "revoked_at"},
+	}
+	for _, session := range data.Sessions {
+		sessions = append(sessions, []string{
+			formatID(session.ID),
+			session.Device,
+			session.UserAgent,
+			session.IPAddress,
+			formatTime(session.CreatedAt),
+			formatTime(session.LastSeenAt),
+			formatTime(session.ExpiresAt),
+			formatOptionalTime(session.RevokedAt),
+		})
+	}
+
+	identities := [][]string{{"id", "issuer", "subject", "email", "created_at", "last_login_at"}}
+	for _, identity := range data.Identities {
+		identities = append(identities, []string{
+			formatID(identity.ID),
+			identity.Issuer,
+			identity.Subject,
+			identity.Email,
+			formatTime(identity.CreatedAt),
+			formatTime(identity.LastLoginAt),
+		})
+	}
+
+	// Key hashes are left out, as they would let the keys be brute forced
+	apiKeys := [][]string{
+		{"id", "name", "prefix", "scopes", "created_at", "expires_at", "last_used_at", "revoked_at"},
+	}
+	for _, key := range data.APIKeys {
+		scopes := make([]string, 0, len(key.Scopes))
+		for _, scope := range key.Scopes {
+			scopes = append(scopes, string(scope))
+		}
+
+		apiKeys = append(apiKeys, []string{
+			formatID(key.ID),
+			key.Name,
+			key.Prefix,
+			strings.Join(scopes, " "),
+			formatTime(key.CreatedAt),
+			formatOptionalTime(key.ExpiresAt),
+			formatOptionalTime(key.LastUsedAt),
+			formatOptionalTime(key.RevokedAt),
+		})
+	}
+
+	audit := [][]string{{"id", "action", "actor_id", "user_id", "ip_address", "details", "created_at"}}
+	for _, entry := range data.AuditEntries {
+		audit = append(audit, []string{
+			formatID(entry.ID),
+			string(entry.Action),
+			formatOptionalID(entry.ActorID),
+			formatOptionalID(entry.UserID),
+			entry.IPAddress,
+			entry.Details,
+			formatTime(entry.CreatedAt),
+		})
+	}
+
+	files := []struct {
+		name  string
+		write func(io.Writer) error
+	}{
+		{"profile.json", jsonFile(data.User)},
+		{"ratings.csv", csvFile(ratings)},
+		{"reviews.json", jsonFile(reviews)},
+		{"review_votes.csv", csvFile(votes)},
+		{"lists.json", jsonFile(lists)},
+		{"watchlist.csv", csvFile(watchlist)},
+		{"diary.csv", csvFile(diary)},
+		{"sessions.csv", csvFile(sessions)},
+		{"identities.csv", csvFile(identities)},
+		{"api_keys.csv", csvFile(apiKeys)},
+		{"audit_events.csv", csvFile(audit)},
+	}
+
+	for _, file := range files {
+		w, err := archive.Create(file.name)
+		if err != nil {
+			return fmt.Errorf("adding %s: %w", file.name, err)
+		}
+
+		if err := file.write(w); err != nil {
+			return fmt.Errorf("writing %s: %w", file.name, err)
+		}
+	}
+
+	return archive.Close()
+}
+
+func jsonFile(value any) func(io.Writer) error {
+	return func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(value)
+	}
+}
+
+func csvFile(records [][]string) func(io.Writer) error {
+	return func(w io.Writer) error {
+		return csv.NewWriter(w).WriteAll(records)
+	}
+}
+
+func formatID(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+func formatOptionalID(id *uint) string {
+	if id == nil {
+		return ""
+	}
+
+	return formatID(*id)
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return formatTime(*t)
+}
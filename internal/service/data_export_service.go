@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"movie_app/internal/domain"
+	"movie_app/internal/repository"
+	"movie_app/internal/storage"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrExportNotFound   = errors.New("no data export was requested")
+	ErrExportInProgress = errors.New("a data export is already in progress")
+	ErrExportNotReady   = errors.New("data export is not ready yet")
+	ErrExportFailed     = errors.New("data export failed, please request a new one")
+	ErrExportExpired    = errors.New("data export has expired, please request a new one")
+)
+
+type DataExportService interface {
+	RequestExport(ctx context.Context, userID uint, client domain.ClientInfo) (*domain.DataExport, error)
+	GetExport(ctx context.Context, userID uint) (*domain.DataExport, error)
+	OpenExport(ctx context.Context, userID uint) (*domain.DataExport, io.ReadSeekCloser, error)
+}
+
+type dataExportService struct {
+	repo    repository.DataExportRepository
+	audit   repository.AuditRepository
+	storage storage.Storage
+	worker  *DataExportWorker
+}
+
+func NewDataExportService(
+	repo repository.DataExportRepository,
+	audit repository.AuditRepository,
+	storage storage.Storage,
+	worker *DataExportWorker,
+) *dataExportService {
+	return &dataExportService{
+		repo:    repo,
+		audit:   audit,
+		storage: storage,
+		worker:  worker,
+	}
+}
+
+// RequestExport queues an export of the user's data, unless one is already
+// in progress.
+func (s *dataExportService) RequestExport(
+	ctx context.Context, userID uint, client domain.ClientInfo,
+) (*domain.DataExport, error) {
+	latest, err := s.GetExport(ctx, userID)
+	if err != nil && !errors.Is(err, ErrExportNotFound) {
+		return nil, err
+	}
+
+	if latest != nil && latest.InProgress() {
+		return nil, ErrExportInProgress
+	}
+
+	export := &domain.DataExport{
+		UserID: userID,
+		Status: domain.DataExportPending,
+	}
+
+	if err := s.repo.Create(ctx, export); err != nil {
+		return nil, fmt.Errorf("creating data export: %w", err)
+	}
+
+	err = s.audit.Create(ctx, &domain.AuditEntry{
+		Action:    domain.AuditDataExportRequested,
+		ActorID:   &userID,
+		UserID:    &userID,
+		IPAddress: client.IPAddress,
+		Details:   fmt.Sprintf("data export %d requested", export.ID),
+	})
+	if err != nil {
+		log.Printf("Failed to record %s audit entry: %v", domain.AuditDataExportRequested, err)
+	}
+
+	s.worker.Wake()
+
+	return export, nil
+}
+
+// GetExport returns the user's most recently requested export.
+func (s *dataExportService) GetExport(ctx context.Context, userID uint) (*domain.DataExport, error) {
+	export, err := s.repo.GetLatest(ctx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrExportNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("getting data export: %w", err)
+	}
+
+	return export, nil
+}
+
+// OpenExport returns the archive of the user's most recently requested
+// export, as long as it is ready and has not expired.
+func (s *dataExportService) OpenExport(
+	ctx context.Context, userID uint,
+) (*domain.DataExport, io.ReadSeekCloser, error) {
+	export, err := s.GetExport(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch {
+	case export.InProgress():
+		return nil, nil, ErrExportNotReady
+	case export.Status == domain.DataExportFailed:
+		return nil, nil, ErrExportFailed
+	case export.Status == domain.DataExportExpired, !export.ExpiresAt.After(time.Now()):
+		return nil, nil, ErrExportExpired
+	}
+
+	file, err := s.storage.Open(export.FileName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("opening data export: %w", err)
+	}
+
+	return export, file, nil
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"movie_app/internal/domain"
+	"movie_app/internal/repository"
+	"movie_app/internal/storage"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// dataExportStaleAfter is how long an export can be processing before
+// another worker assumes its worker stopped and builds it again.
+const dataExportStaleAfter = 15 * time.Minute
+
+// DataExportWorker builds the archives of requested data exports and deletes
+// them once they expire. Any number of instances can run a worker.
+type DataExportWorker struct {
+	repo    repository.DataExportRepository
+	storage storage.Storage
+	ttl     time.Duration
+	wake    chan struct{}
+}
+
+func NewDataExportWorker(
+	repo repository.DataExportRepository, storage storage.Storage, ttl time.Duration,
+) *DataExportWorker {
+	return &DataExportWorker{
+		repo:    repo,
+		storage: storage,
+		ttl:     ttl,
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// Wake makes a running worker process exports now rather than at its next
+// interval.
+func (w *DataExportWorker) Wake() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Process builds every pending export and deletes the expired archives.
+func (w *DataExportWorker) Process(ctx context.Context) error {
+	for {
+		export, err := w.repo.Claim(ctx, time.Now().Add(-dataExportStaleAfter))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			break
+		}
+
+		if err != nil {
+			return fmt.Errorf("claiming data export: %w", err)
+		}
+
+		if err := w.build(ctx, export); err != nil {
+			return err
+		}
+	}
+
+	expired, err := w.repo.ListExpired(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("listing expired data exports: %w", err)
+	}
+
+	for _, export := range expired {
+		if err := w.storage.Delete(export.FileName); err != nil {
+			return fmt.Errorf("deleting data export %d: %w", export.ID, err)
+		}
+
+		if err := w.repo.MarkExpired(ctx, export.ID); err != nil {
+			return fmt.Errorf("expiring data export %d: %w", export.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// Run processes exports every interval, and whenever woken, until the
+// context is done.
+func (w *DataExportWorker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-w.wake:
+		}
+
+		if err := w.Process(ctx); err != nil {
+			log.Printf("Failed to process data exports: %v", err)
+		}
+	}
+}
+
+// build writes the export's archive and records the outcome. A failure to
+// build the archive fails the export; only a failure to record the outcome
+// is returned.
+func (w *DataExportWorker) build(ctx context.Context, export *domain.DataExport) error {
+	fileName := fmt.Sprintf("data-export-%d.zip", export.ID)
+
+	size, err := w.storage.Save(fileName, func(file io.Writer) error {
+		data, err := w.repo.CollectUserData(ctx, export.UserID)
+		if err != nil {
+			return err
+		}
+
+		return writeArchive(file, data)
+	})
+
+	now := time.Now()
+	export.CompletedAt = &now
+
+	if err != nil {
+		log.Printf("Failed to build data export %d: %v", export.ID, err)
+
+		export.Status = domain.DataExportFailed
+		export.Error = "the archive could not be built, please request a new export"
+	} else {
+		expiresAt := now.Add(w.ttl)
+
+		export.Status = domain.DataExportReady
+		export.FileName = fileName
+		export.Size = size
+		export.ExpiresAt = &expiresAt
+	}
+
+	if err := w.repo.Complete(ctx, export); err != nil {
+		return fmt.Errorf("completing data export %d: %w", export.ID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movie_app/internal/domain"
+	"movie_app/internal/repository"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrDiaryEntryNotFound = errors.New("diary entry not found")
+	ErrInvalidWatchDate   = errors.New("watch date must be a date formatted as YYYY-MM-DD and not in the future")
+	ErrInvalidDateRange   = errors.New("from must not be after to")
+)
+
+type DiaryService interface {
+	Log(ctx context.Context, userID uint, req domain.CreateDiaryEntryRequest) (*domain.DiaryEntry, error)
+	GetByID(ctx context.Context, userID, id uint) (*domain.DiaryEntry, error)
+	List(ctx context.Context, userID uint, query domain.DiaryQuery) (*domain.DiaryPage, error)
+	Update(ctx context.Context, userID, id uint, req domain.UpdateDiaryEntryRequest) (*domain.DiaryEntry, error)
+	Delete(ctx context.Context, userID, id uint) error
+}
+
+type diaryService struct {
+	repo   repository.DiaryRepository
+	movies repository.MovieRepository
+}
+
+func NewDiaryService(repo repository.DiaryRepository, movies repository.MovieRepository) *diaryService {
+	return &diaryService{
+		repo:   repo,
+		movies: movies,
+	}
+}
+
+// Log records a viewing, which also takes the movie off the user's watchlist.
+func (s *diaryService) Log(
+	ctx context.Context, userID uint, req domain.CreateDiaryEntryRequest,
+) (*domain.DiaryEntry, error) {
+	if err := checkMovieExists(ctx, s.movies, req.MovieID); err != nil {
+		return nil, err
+	}
+
+	entry := &domain.DiaryEntry{
+		UserID:  userID,
+		MovieID: req.MovieID,
+		Rating:  req.Rating,
+		Note:    req.Note,
+	}
+
+	watchedOn, err := parseWatchDate(req.WatchedOn)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.WatchedOn = watchedOn
+
+	if req.Rating != nil {
+		if err := validateRating(*req.Rating); err != nil {
+			return nil, fmt.Errorf("validating rating: %w", err)
+		}
+	}
+
+	if req.Rewatch != nil {
+		entry.Rewatch = *req.Rewatch
+	} else {
+		entry.Rewatch, err = s.repo.HasWatched(ctx, userID, req.MovieID)
+		if err != nil {
+			return nil, fmt.Errorf("checking previous viewings: %w", err)
+		}
+	}
+
+	result, err := s.repo.Create(ctx, entry)
+	if err != nil {
+		return nil, fmt.Errorf("logging diary entry: %w", err)
+	}
+
+	return result, nil
+}
+
+func (s *diaryService) GetByID(ctx context.Context, userID, id uint) (*domain.DiaryEntry, error) {
+	result, err := s.repo.GetByID(ctx, userID, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrDiaryEntryNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("getting diary entry: %w", err)
+	}
+
+	return result, nil
+}
+
+func (s *diaryService) List(ctx context.Context, userID uint, query domain.DiaryQuery) (*domain.DiaryPage, error) {
+	if query.Page == 0 {
+		query.Page = 1
+	}
+
+	if query.Page < 0 || query.Page > MaxPage {
+		return nil, ErrInvalidPage
+	}
+
+	if query.Limit == 0 {
+		query.Limit = DefaultPageSize
+	}
+
+	if query.Limit < 0 || query.Limit > MaxPageSize {
+		return nil, ErrInvalidLimit
+	}
+
+	// Both dates are YYYY-MM-DD, so they compare lexically
+	if query.From != "" && query.To != "" && query.From > query.To {
+		return nil, ErrInvalidDateRange
+	}
+
+	result, err := s.repo.List(ctx, userID, query)
+	if err != nil {
+		return nil, fmt.Errorf("listing diary entries: %w", err)
+	}
+
+	return result, nil
+}
+
+func (s *diaryService) Update(
+	ctx context.Context, userID, id uint, req domain.UpdateDiaryEntryRequest,
+) (*domain.DiaryEntry, error) {
+	entry, err := s.GetByID(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.WatchedOn != nil {
+		entry.WatchedOn, err = parseWatchDate(*req.WatchedOn)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Rewatch != nil {
+		entry.Rewatch = *req.Rewatch
+	}
+
+	if req.Rating != nil {
+		if err := validateRating(*req.Rating); err != nil {
+			return nil, fmt.Errorf("validating rating: %w", err)
+		}
+
+		entry.Rating = req.Rating
+	}
+
+	if req.Note != nil {
+		entry.Note = *req.Note
+	}
+
+	result, err := s.repo.Update(ctx, entry)
+	if err != nil {
+		return nil, fmt.Errorf("updating diary entry: %w", err)
+	}
+
+	return result, nil
+}
+
+func (s *diaryService) Delete(ctx context.Context, userID, id uint) error {
+	err := s.repo.Delete(ctx, userID, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrDiaryEntryNotFound
+	}
+
+	if err != nil {
+		return fmt.Errorf("deleting diary entry: %w", err)
+	}
+
+	return nil
+}
+
+// parseWatchDate parses a YYYY-MM-DD watch date, defaulting to today.
+func parseWatchDate(value string) (time.Time, error) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	if value == "" {
+		return today, nil
+	}
+
+	watchedOn, err := time.Parse(domain.DateLayout, value)
+	// A day of leeway, since it may already be tomorrow in the user's time zone
+	if err != nil || watchedOn.After(today.AddDate(0, 0, 1)) {
+		return time.Time{}, ErrInvalidWatchDate
+	}
+
+	return watchedOn, nil
+}
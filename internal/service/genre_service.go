@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movie_app/internal/domain"
+	"movie_app/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrGenreNotFound = errors.New("genre not found")
+	ErrGenreExists   = errors.New("genre already exists")
+	ErrGenreInUse    = errors.New("genre is still assigned to movies")
+	ErrInvalidSlug   = errors.New("slug must contain letters or digits")
+)
+
+type GenreService interface {
+	Create(ctx context.Context, req domain.CreateGenreRequest) (*domain.Genre, error)
+	GetByID(ctx context.Context, id uint) (*domain.Genre, error)
+	GetAll(ctx context.Context) ([]domain.Genre, error)
+	Update(ctx context.Context, id uint, req domain.UpdateGenreRequest) (*domain.Genre, error)
+	Delete(ctx context.Context, id uint) error
+}
+
+type genreService struct {
+	repo repository.GenreRepository
+}
+
+func NewGenreService(repo repository.GenreRepository) *genreService {
+	return &genreService{
+		repo: repo,
+	}
+}
+
+func (s *genreService) Create(ctx context.Context, req domain.CreateGenreRequest) (*domain.Genre, error) {
+	slug := req.Slug
+	if slug == "" {
+		slug = req.Name
+	}
+
+	genre := &domain.Genre{
+		Name: req.Name,
+		Slug: domain.Slugify(slug),
+	}
+
+	if err := s.checkSlug(ctx, genre); err != nil {
+		return nil, err
+	}
+
+	result, err := s.repo.Create(ctx, genre)
+	if err != nil {
+		return nil, fmt.Errorf("creating genre: %w", err)
+	}
+
+	return result, nil
+}
+
+func (s *genreService) GetByID(ctx context.Context, id uint) (*domain.Genre, error) {
+	result, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrGenreNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("getting genre by ID: %w", err)
+	}
+
+	return result, nil
+}
+
+func (s *genreService) GetAll(ctx context.Context) ([]domain.Genre, error) {
+	result, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting all genres: %w", err)
+	}
+
+	return result, nil
+}
+
+func (s *genreService) Update(ctx context.Context, id uint, req domain.UpdateGenreRequest) (*domain.Genre, error) {
+	genre, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		genre.Name = *req.Name
+	}
+
+	if req.Slug != nil {
+		genre.Slug = domain.Slugify(*req.Slug)
+	}
+
+	if err := s.checkSlug(ctx, genre); err != nil {
+		return nil, err
+	}
+
+	result, err := s.repo.Update(ctx, genre)
+	if err != nil {
+		return nil, fmt.Errorf("updating genre: %w", err)
+	}
+
+	return result, nil
+}
+
+func (s *genreService) Delete(ctx context.Context, id uint) error {
+	if _, err := s.GetByID(ctx, id); err != nil {
+		return err
+	}
+
+	count, err := s.repo.CountMovies(ctx, id)
+	if err != nil {
+		return fmt.Errorf("counting genre movies: %w", err)
+	}
+
+	if count > 0 {
+		return ErrGenreInUse
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("deleting genre: %w", err)
+	}
+
+	return nil
+}
+
+// checkSlug rejects empty slugs and slugs taken by another genre.
+func (s *genreService) checkSlug(ctx context.Context, genre *domain.Genre) error {
+	if genre.Slug == "" {
+		return ErrInvalidSlug
+	}
+
+	existing, _ := s.repo.GetBySlug(ctx, genre.Slug)
+	if existing != nil && existing.ID != genre.ID {
+		return ErrGenreExists
+	}
+
+	return nil
+}
@@ -0,0 +1,389 @@
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"movie_app/internal/domain"
+	"movie_app/internal/repository"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgorithmEdDSA = "EdDSA"
+	AlgorithmRS256 = "RS256"
+
+	rsaKeyBits = 2048
+
+	// How often a token signed with an unknown key can trigger a sync, which
+	// loads keys other instances created since the last one
+	unknownKeySyncInterval = time.Minute
+)
+
+// SigningAlgorithms are the algorithms access tokens can be signed with.
+var SigningAlgorithms = []string{AlgorithmEdDSA, AlgorithmRS256}
+
+var (
+	ErrNoSigningKey      = errors.New("no active signing key")
+	ErrUnknownSigningKey = errors.New("unknown signing key")
+	ErrAlgorithmMismatch = errors.New("token algorithm does not match its signing key")
+)
+
+type KeySetOptions struct {
+	Algorithm string
+	// How long each key signs tokens
+	RotationInterval time.Duration
+	// How long a key is published before it signs, and verifies after it
+	// retires. It must exceed the access token lifetime.
+	GracePeriod time.Duration
+	// Encrypts the private keys at rest
+	EncryptionSecret string
+	// Still decrypts private keys encrypted before EncryptionSecret changed
+	PreviousEncryptionSecret string
+}
+
+// KeySet signs access tokens with the current signing key and verifies them
+// with any published key. The keys live in the database, so every instance
+// shares them, and are cached in memory between syncs.
+type KeySet struct {
+	repo    repository.SigningKeyRepository
+	options KeySetOptions
+	box     secretBox
+
+	mu   sync.RWMutex
+	keys []signingKey // In activation order
+
+	syncMu       sync.Mutex
+	lastSyncedAt time.Time // Of a sync for an unknown key
+}
+
+type signingKey struct {
+	id          string
+	method      jwt.SigningMethod
+	private     crypto.Signer
+	public      crypto.PublicKey
+	activatesAt time.Time
+	retiresAt   time.Time
+	expiresAt   time.Time
+}
+
+func NewKeySet(repo repository.SigningKeyRepository, options KeySetOptions) *KeySet {
+	return &KeySet{
+		repo:    repo,
+		options: options,
+		box:     newSecretBox(options.EncryptionSecret, options.PreviousEncryptionSecret),
+	}
+}
+
+// Sync creates the next signing key when the latest one is about to retire,
+// or a key that signs right away when none does, and reloads the keys.
+func (k *KeySet) Sync(ctx context.Context) error {
+	now := time.Now()
+
+	records, err := k.repo.ListUnexpired(ctx, now)
+	if err != nil {
+		return fmt.Errorf("listing signing keys: %w", err)
+	}
+
+	if activatesAt, due := k.nextActivation(records, now); due {
+		key, err := k.generate(activatesAt)
+		if err != nil {
+			return err
+		}
+
+		// Another instance may have created the successor first, either way
+		// the reload below picks it up
+		if _, err := k.repo.Create(ctx, key); err != nil {
+			return fmt.Errorf("creating signing key: %w", err)
+		}
+
+		records, err = k.repo.ListUnexpired(ctx, now)
+		if err != nil {
+			return fmt.Errorf("listing signing keys: %w", err)
+		}
+	}
+
+	keys := make([]signingKey, 0, len(records))
+
+	for _, record := range records {
+		key, err := k.decode(record)
+		if err != nil {
+			return fmt.Errorf("decoding signing key %s: %w", record.ID, err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.keys = keys
+
+	return nil
+}
+
+// Run syncs the key set every interval until the context is done. The
+// interval must be well below the grace period, so that every instance loads
+// a key before it starts signing.
+func (k *KeySet) Run(ctx context.Context, interval time.Duration) {
+	runPeriodically(ctx, interval, "sync signing keys", k.Sync)
+}
+
+// Sign signs the claims with the current signing key, identified by the
+// token's kid header.
+func (k *KeySet) Sign(claims jwt.Claims) (string, error) {
+	now := time.Now()
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	for i := len(k.keys) - 1; i >= 0; i-- {
+		key := k.keys[i]
+		if key.activatesAt.After(now) || !key.retiresAt.After(now) {
+			continue
+		}
+
+		token := jwt.NewWithClaims(key.method, claims)
+		token.Header["kid"] = key.id
+
+		signed, err := token.SignedString(key.private)
+		if err != nil {
+			return "", fmt.Errorf("signing token: %w", err)
+		}
+
+		return signed, nil
+	}
+
+	return "", ErrNoSigningKey
+}
+
+// VerificationKey returns the public key named by the token's kid header. It
+// is a jwt.Keyfunc, and rejects tokens whose algorithm differs from the key's.
+// An unknown kid may name a key another instance just created, so it syncs
+// the key set, at most once per unknownKeySyncInterval, and looks again.
+func (k *KeySet) VerificationKey(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	key, ok := k.find(kid)
+	if !ok && k.syncForUnknownKey() {
+		key, ok = k.find(kid)
+	}
+
+	if !ok {
+		return nil, ErrUnknownSigningKey
+	}
+
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, ErrAlgorithmMismatch
+	}
+
+	return key.public, nil
+}
+
+// find returns the unexpired key with the ID.
+func (k *KeySet) find(id string) (signingKey, bool) {
+	now := time.Now()
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	for _, key := range k.keys {
+		if key.id == id && key.expiresAt.After(now) {
+			return key, true
+		}
+	}
+
+	return signingKey{}, false
+}
+
+// syncForUnknownKey syncs the key set unless it did within the last
+// unknownKeySyncInterval, so that tokens with made up kids cannot flood the
+// database, and reports whether it did.
+func (k *KeySet) syncForUnknownKey() bool {
+	k.syncMu.Lock()
+	defer k.syncMu.Unlock()
+
+	if time.Since(k.lastSyncedAt) < unknownKeySyncInterval {
+		return false
+	}
+
+	k.lastSyncedAt = time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := k.Sync(ctx); err != nil {
+		log.Printf("Failed to sync signing keys for an unknown key: %v", err)
+
+		return false
+	}
+
+	return true
+}
+
+// JWKS returns the public keys that verify tokens, including the upcoming key.
+func (k *KeySet) JWKS() domain.JWKS {
+	now := time.Now()
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	jwks := domain.JWKS{Keys: []domain.JWK{}}
+
+	for _, key := range k.keys {
+		if !key.expiresAt.After(now) {
+			continue
+		}
+
+		jwk := domain.JWK{
+			Use: "sig",
+			Alg: key.method.Alg(),
+			Kid: key.id,
+		}
+
+		switch public := key.public.(type) {
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		}
+
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	return jwks
+}
+
+// nextActivation reports whether a key has to be created, and when it should
+// start signing: right away when no key signs now, otherwise when the latest
+// key retires, once that is within the grace period. A key that signs right
+// away activates at the start of the current grace period, rather than now,
+// so that instances starting together pick the same time and all but the
+// first one's key are dropped as duplicates.
+func (k *KeySet) nextActivation(records []domain.SigningKey, now time.Time) (time.Time, bool) {
+	immediate := now.Truncate(k.options.GracePeriod)
+
+	if len(records) == 0 {
+		return immediate, true
+	}
+
+	latest := records[len(records)-1]
+
+	if !latest.RetiresAt.After(now) {
+		if latest.RetiresAt.After(immediate) {
+			return latest.RetiresAt, true
+		}
+
+		return immediate, true
+	}
+
+	if latest.RetiresAt.Sub(now) <= k.options.GracePeriod {
+		return latest.RetiresAt, true
+	}
+
+	return time.Time{}, false
+}
+
+func (k *KeySet) generate(activatesAt time.Time) (*domain.SigningKey, error) {
+	var (
+		private crypto.Signer
+		err     error
+	)
+
+	switch k.options.Algorithm {
+	case AlgorithmEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	case AlgorithmRS256:
+		private, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", k.options.Algorithm)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("generating signing key: %w", err)
+	}
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, fmt.Errorf("encoding private key: %w", err)
+	}
+
+	publicDER, err := x509.MarshalPKIXPublicKey(private.Public())
+	if err != nil {
+		return nil, fmt.Errorf("encoding public key: %w", err)
+	}
+
+	encrypted, err := k.box.seal(privateDER)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := randomToken(12)
+	if err != nil {
+		return nil, err
+	}
+
+	retiresAt := activatesAt.Add(k.options.RotationInterval)
+
+	return &domain.SigningKey{
+		ID:          id,
+		Algorithm:   k.options.Algorithm,
+		PrivateKey:  encrypted,
+		PublicKey:   publicDER,
+		ActivatesAt: activatesAt,
+		RetiresAt:   retiresAt,
+		ExpiresAt:   retiresAt.Add(k.options.GracePeriod),
+	}, nil
+}
+
+func (k *KeySet) decode(record domain.SigningKey) (signingKey, error) {
+	method := jwt.GetSigningMethod(record.Algorithm)
+	if method == nil {
+		return signingKey{}, fmt.Errorf("unsupported signing algorithm %q", record.Algorithm)
+	}
+
+	privateDER, err := k.box.open(record.PrivateKey)
+	if err != nil {
+		return signingKey{}, err
+	}
+
+	private, err := x509.ParsePKCS8PrivateKey(privateDER)
+	if err != nil {
+		return signingKey{}, fmt.Errorf("parsing private key: %w", err)
+	}
+
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return signingKey{}, errors.New("private key cannot sign")
+	}
+
+	public, err := x509.ParsePKIXPublicKey(record.PublicKey)
+	if err != nil {
+		return signingKey{}, fmt.Errorf("parsing public key: %w", err)
+	}
+
+	return signingKey{
+		id:          record.ID,
+		method:      method,
+		private:     signer,
+		public:      public,
+		activatesAt: record.ActivatesAt,
+		retiresAt:   record.RetiresAt,
+		expiresAt:   record.ExpiresAt,
+	}, nil
+}
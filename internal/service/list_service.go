@@ -0,0 +1,456 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"movie_app/internal/domain"
+	"movie_app/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrListNotFound         = errors.New("list not found")
+	ErrListForbidden        = errors.New("not allowed to change this list")
+	ErrListConflict         = errors.New("list was changed by someone else, reload it and retry")
+	ErrListEntryNotFound    = errors.New("list entry not found")
+	ErrMovieAlreadyInList   = errors.New("movie already in list")
+	ErrInvalidReorder       = errors.New("entryIds must contain every entry of the list exactly once")
+	ErrCollaboratorNotFound = errors.New("collaborator not found")
+	ErrOwnerCollaborator    = errors.New("the owner cannot be a collaborator")
+)
+
+type ListService interface {
+	Create(ctx context.Context, actor domain.Actor, req domain.CreateListRequest) (*domain.CuratedList, error)
+	Get(ctx context.Context, actor domain.Actor, ref domain.ListRef) (*domain.CuratedList, error)
+	ListPublic(ctx context.Context, query domain.ListQuery) (*domain.ListPage, error)
+	ListMine(ctx context.Context, actor domain.Actor, query domain.ListQuery) (*domain.ListPage, error)
+	Update(ctx context.Context, actor domain.Actor, id uint, req domain.UpdateListRequest) (*domain.CuratedList, error)
+	Delete(ctx context.Context, actor domain.Actor, id uint) error
+	Entries(
+		ctx context.Context, actor domain.Actor, ref domain.ListRef, query domain.MovieQuery,
+	) (*domain.ListEntryPage, error)
+	AddEntry(
+		ctx context.Context, actor domain.Actor, listID uint, req domain.AddListEntryRequest,
+	) (*domain.ListEntryChange, error)
+	UpdateEntry(
+		ctx context.Context, actor domain.Actor, listID, entryID uint, req domain.UpdateListEntryRequest,
+	) (*domain.ListEntryChange, error)
+	RemoveEntry(ctx context.Context, actor domain.Actor, listID, entryID uint, version *int) (*domain.ListEntryChange, error)
+	MoveEntry(
+		ctx context.Context, actor domain.Actor, listID, entryID uint, req domain.MoveListEntryRequest,
+	) (*domain.ListEntryChange, error)
+	Reorder(
+		ctx context.Context, actor domain.Actor, listID uint, req domain.ReorderListRequest,
+	) (*domain.ListEntryChange, error)
+	AddCollaborator(ctx context.Context, actor domain.Actor, listID uint, email string) (*domain.CuratedList, error)
+	RemoveCollaborator(ctx context.Context, actor domain.Actor, listID, userID uint) error
+}
+
+type listService struct {
+	repo   repository.ListRepository
+	movies repository.MovieRepository
+	users  repository.UserRepository
+}
+
+func NewListService(
+	repo repository.ListRepository, movies repository.MovieRepository, users repository.UserRepository,
+) *listService {
+	return &listService{
+		repo:   repo,
+		movies: movies,
+		users:  users,
+	}
+}
+
+func (s *listService) Create(
+	ctx context.Context, actor domain.Actor, req domain.CreateListRequest,
+) (*domain.CuratedList, error) {
+	slug, err := newListSlug(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &domain.CuratedList{
+		OwnerID:     actor.UserID,
+		Name:        req.Name,
+		Slug:        slug,
+		Description: req.Description,
+		Visibility:  req.Visibility,
+		Version:     1,
+	}
+
+	if list.Visibility == "" {
+		list.Visibility = domain.ListPrivate
+	}
+
+	result, err := s.repo.Create(ctx, list)
+	if err != nil {
+		return nil, fmt.Errorf("creating list: %w", err)
+	}
+
+	return result, nil
+}
+
+// Get returns the list if the actor may see it. Unlisted lists are only
+// shown to outsiders by slug, so that they can't be found by guessing IDs.
+func (s *listService) Get(ctx context.Context, actor domain.Actor, ref domain.ListRef) (*domain.CuratedList, error) {
+	var (
+		list *domain.CuratedList
+		err  error
+	)
+
+	if ref.Slug != "" {
+		list, err = s.repo.GetBySlug(ctx, ref.Slug)
+	} else {
+		list, err = s.repo.GetByID(ctx, ref.ID)
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrListNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("getting list: %w", err)
+	}
+
+	visible := list.Visibility == domain.ListPublic ||
+		(list.Visibility == domain.ListUnlisted && ref.Slug != "") ||
+		list.CanEdit(actor.UserID) || actor.Can(domain.PermListsModerate)
+	if !visible {
+		return nil, ErrListNotFound
+	}
+
+	return list, nil
+}
+
+func (s *listService) ListPublic(ctx context.Context, query domain.ListQuery) (*domain.ListPage, error) {
+	if err := normalizeListQuery(&query); err != nil {
+		return nil, err
+	}
+
+	result, err := s.repo.ListPublic(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("listing lists: %w", err)
+	}
+
+	return result, nil
+}
+
+// ListMine returns the lists the actor owns or collaborates on.
+func (s *listService) ListMine(
+	ctx context.Context, actor domain.Actor, query domain.ListQuery,
+) (*domain.ListPage, error) {
+	if err := normalizeListQuery(&query); err != nil {
+		return nil, err
+	}
+
+	result, err := s.repo.ListByMember(ctx, actor.UserID, query)
+	if err != nil {
+		return nil, fmt.Errorf("listing lists: %w", err)
+	}
+
+	return result, nil
+}
+
+func (s *listService) Update(
+	ctx context.Context, actor domain.Actor, id uint, req domain.UpdateListRequest,
+) (*domain.CuratedList, error) {
+	list, err := s.Get(ctx, actor, domain.ListRef{ID: id})
+	if err != nil {
+		return nil, err
+	}
+
+	if list.OwnerID != actor.UserID {
+		return nil, ErrListForbidden
+	}
+
+	if req.Name != nil {
+		list.Name = *req.Name
+	}
+
+	if req.Description != nil {
+		list.Description = *req.Description
+	}
+
+	if req.Visibility != nil {
+		list.Visibility = *req.Visibility
+	}
+
+	result, err := s.repo.Update(ctx, list)
+	if err != nil {
+		return nil, fmt.Errorf("updating list: %w", err)
+	}
+
+	return result, nil
+}
+
+func (s *listService) Delete(ctx context.Context, actor domain.Actor, id uint) error {
+	list, err := s.Get(ctx, actor, domain.ListRef{ID: id})
+	if err != nil {
+		return err
+	}
+
+	if list.OwnerID != actor.UserID && !actor.Can(domain.PermListsModerate) {
+		return ErrListForbidden
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("deleting list: %w", err)
+	}
+
+	return nil
+}
+
+// Entries returns a page of the list's entries. It takes the same paging,
+// filtering and sorting parameters as movie listings, plus sorting by
+// position, which is the default.
+func (s *listService) Entries(
+	ctx context.Context, actor domain.Actor, ref domain.ListRef, query domain.MovieQuery,
+) (*domain.ListEntryPage, error) {
+	if query.IsCursorMode() {
+		return nil, fmt.Errorf("%w: list entries only support offset pagination", ErrInvalidPagination)
+	}
+
+	if err := normalizeQuery(&query, domain.ListEntrySortableFields); err != nil {
+		return nil, err
+	}
+
+	list, err := s.Get(ctx, actor, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.repo.Entries(ctx, list.ID, query)
+	if err != nil {
+		return nil, fmt.Errorf("listing list entries: %w", err)
+	}
+
+	result.Version = list.Version
+
+	return result, nil
+}
+
+func (s *listService) AddEntry(
+	ctx context.Context, actor domain.Actor, listID uint, req domain.AddListEntryRequest,
+) (*domain.ListEntryChange, error) {
+	if err := s.checkEditable(ctx, actor, listID); err != nil {
+		return nil, err
+	}
+
+	if err := checkMovieExists(ctx, s.movies, req.MovieID); err != nil {
+		return nil, err
+	}
+
+	entry := &domain.ListEntry{
+		ListID:    listID,
+		MovieID:   req.MovieID,
+		Position:  req.Position,
+		Note:      req.Note,
+		AddedByID: actor.UserID,
+	}
+
+	version, err := s.repo.AddEntry(ctx, entry, req.Version)
+	if err != nil {
+		return nil, listEntryError("adding list entry", err)
+	}
+
+	return s.entryChange(ctx, listID, entry.ID, version)
+}
+
+func (s *listService) UpdateEntry(
+	ctx context.Context, actor domain.Actor, listID, entryID uint, req domain.UpdateListEntryRequest,
+) (*domain.ListEntryChange, error) {
+	if err := s.checkEditable(ctx, actor, listID); err != nil {
+		return nil, err
+	}
+
+	version, err := s.repo.UpdateEntryNote(ctx, listID, entryID, *req.Note, req.Version)
+	if err != nil {
+		return nil, listEntryError("updating list entry", err)
+	}
+
+	return s.entryChange(ctx, listID, entryID, version)
+}
+
+func (s *listService) RemoveEntry(
+	ctx context.Context, actor domain.Actor, listID, entryID uint, version *int,
+) (*domain.ListEntryChange, error) {
+	if err := s.checkEditable(ctx, actor, listID); err != nil {
+		return nil, err
+	}
+
+	newVersion, err := s.repo.RemoveEntry(ctx, listID, entryID, version)
+	if err != nil {
+		return nil, listEntryError("removing list entry", err)
+	}
+
+	return &domain.ListEntryChange{Version: newVersion}, nil
+}
+
+func (s *listService) MoveEntry(
+	ctx context.Context, actor domain.Actor, listID, entryID uint, req domain.MoveListEntryRequest,
+) (*domain.ListEntryChange, error) {
+	if err := s.checkEditable(ctx, actor, listID); err != nil {
+		return nil, err
+	}
+
+	version, err := s.repo.MoveEntry(ctx, listID, entryID, req.Position, req.Version)
+	if err != nil {
+		return nil, listEntryError("moving list entry", err)
+	}
+
+	return s.entryChange(ctx, listID, entryID, version)
+}
+
+func (s *listService) Reorder(
+	ctx context.Context, actor domain.Actor, listID uint, req domain.ReorderListRequest,
+) (*domain.ListEntryChange, error) {
+	if err := s.checkEditable(ctx, actor, listID); err != nil {
+		return nil, err
+	}
+
+	version, err := s.repo.Reorder(ctx, listID, req.EntryIDs, req.Version)
+	if err != nil {
+		return nil, listEntryError("reordering list", err)
+	}
+
+	return &domain.ListEntryChange{Version: version}, nil
+}
+
+func (s *listService) AddCollaborator(
+	ctx context.Context, actor domain.Actor, listID uint, email string,
+) (*domain.CuratedList, error) {
+	list, err := s.Get(ctx, actor, domain.ListRef{ID: listID})
+	if err != nil {
+		return nil, err
+	}
+
+	if list.OwnerID != actor.UserID {
+		return nil, ErrListForbidden
+	}
+
+	user, err := s.users.GetByEmail(ctx, email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUserNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("getting user: %w", err)
+	}
+
+	if user.ID == list.OwnerID {
+		return nil, ErrOwnerCollaborator
+	}
+
+	err = s.repo.AddCollaborator(ctx, &domain.ListCollaborator{ListID: listID, UserID: user.ID})
+	if err != nil {
+		return nil, fmt.Errorf("adding collaborator: %w", err)
+	}
+
+	return s.Get(ctx, actor, domain.ListRef{ID: listID})
+}
+
+// RemoveCollaborator lets the owner remove a collaborator, or a collaborator
+// leave the list.
+func (s *listService) RemoveCollaborator(ctx context.Context, actor domain.Actor, listID, userID uint) error {
+	list, err := s.Get(ctx, actor, domain.ListRef{ID: listID})
+	if err != nil {
+		return err
+	}
+
+	if list.OwnerID != actor.UserID && userID != actor.UserID {
+		return ErrListForbidden
+	}
+
+	err = s.repo.RemoveCollaborator(ctx, listID, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrCollaboratorNotFound
+	}
+
+	if err != nil {
+		return fmt.Errorf("removing collaborator: %w", err)
+	}
+
+	return nil
+}
+
+// checkEditable only lets the owner and collaborators change a list's entries.
+func (s *listService) checkEditable(ctx context.Context, actor domain.Actor, listID uint) error {
+	list, err := s.Get(ctx, actor, domain.ListRef{ID: listID})
+	if err != nil {
+		return err
+	}
+
+	if !list.CanEdit(actor.UserID) {
+		return ErrListForbidden
+	}
+
+	return nil
+}
+
+func (s *listService) entryChange(
+	ctx context.Context, listID, entryID uint, version int,
+) (*domain.ListEntryChange, error) {
+	entry, err := s.repo.GetEntry(ctx, listID, entryID)
+	if err != nil {
+		return nil, fmt.Errorf("getting list entry: %w", err)
+	}
+
+	return &domain.ListEntryChange{Entry: entry, Version: version}, nil
+}
+
+// listEntryError maps the repository's entry change errors to service errors.
+func listEntryError(action string, err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrListEntryNotFound
+	case errors.Is(err, repository.ErrListVersionMismatch):
+		return ErrListConflict
+	case errors.Is(err, repository.ErrDuplicateListEntry):
+		return ErrMovieAlreadyInList
+	case errors.Is(err, repository.ErrReorderMismatch):
+		return ErrInvalidReorder
+	default:
+		return fmt.Errorf("%s: %w", action, err)
+	}
+}
+
+func normalizeListQuery(query *domain.ListQuery) error {
+	if query.Page == 0 {
+		query.Page = 1
+	}
+
+	if query.Page < 0 || query.Page > MaxPage {
+		return ErrInvalidPage
+	}
+
+	if query.Limit == 0 {
+		query.Limit = DefaultPageSize
+	}
+
+	if query.Limit < 0 || query.Limit > MaxPageSize {
+		return ErrInvalidLimit
+	}
+
+	return nil
+}
+
+// newListSlug derives a list's slug from its name, with a random suffix so
+// that slugs are unique and unlisted lists can't be found by guessing.
+func newListSlug(name string) (string, error) {
+	suffix := make([]byte, 6)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", fmt.Errorf("generating slug: %w", err)
+	}
+
+	slug := domain.Slugify(name)
+	if slug == "" {
+		slug = "list"
+	}
+
+	return slug + "-" + hex.EncodeToString(suffix), nil
+}
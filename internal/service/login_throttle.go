@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"movie_app/internal/domain"
+	"movie_app/internal/repository"
+	"strings"
+	"time"
+)
+
+var ErrTooManyAttempts = errors.New("too many failed login attempts, try again later")
+
+// LoginLockedError is returned while logins are locked out. It matches
+// ErrTooManyAttempts.
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return ErrTooManyAttempts.Error()
+}
+
+func (e *LoginLockedError) Unwrap() error {
+	return ErrTooManyAttempts
+}
+
+// LoginThrottle slows password guessing down by locking out an account, or an
+// IP address, for exponentially longer after every failed login beyond the
+// allowed attempts.
+type LoginThrottle interface {
+	Check(ctx context.Context, email, ip string) error
+	RecordFailure(ctx context.Context, email, ip string, userID *uint) error
+	RecordSuccess(ctx context.Context, email string) error
+	Unlock(ctx context.Context, user *domain.User, actorID uint) error
+}
+
+type LoginThrottleOptions struct {
+	MaxAttempts      int           // Failed logins to an account before it is locked out
+	MaxAttemptsPerIP int           // Failed logins from an IP address before it is locked out
+	Window           time.Duration // How long failed logins count after the last one
+	LockoutBase      time.Duration // First lockout, doubled on every further failure
+	LockoutMax       time.Duration
+}
+
+type loginThrottle struct {
+	store   repository.LoginThrottleStore
+	audit   repository.AuditRepository
+	options LoginThrottleOptions
+}
+
+func NewLoginThrottle(
+	store repository.LoginThrottleStore, audit repository.AuditRepository, options LoginThrottleOptions,
+) *loginThrottle {
+	return &loginThrottle{
+		store:   store,
+		audit:   audit,
+		options: options,
+	}
+}
+
+// Check returns a LoginLockedError when logins to the account or from the IP
+// address are locked out.
+func (t *loginThrottle) Check(ctx context.Context, email, ip string) error {
+	now := time.Now()
+
+	var retryAfter time.Duration
+
+	for _, key := range t.keys(email, ip) {
+		throttle, err := t.store.Get(ctx, key)
+		if err != nil {
+			return fmt.Errorf("getting login throttle: %w", err)
+		}
+
+		if throttle.LockedAt(now) {
+			retryAfter = max(retryAfter, throttle.LockedUntil.Sub(now))
+		}
+	}
+
+	if retryAfter > 0 {
+		return &LoginLockedError{RetryAfter: retryAfter}
+	}
+
+	return nil
+}
+
+// RecordFailure counts a failed login to the account from the IP address,
+// locking either out once it has too many. The user is unset when no
+// account has the email.
+func (t *loginThrottle) RecordFailure(ctx context.Context, email, ip string, userID *uint) error {
+	now := time.Now()
+
+	for _, key := range t.keys(email, ip) {
+		failures, err := t.store.RecordFailure(ctx, key, now, t.options.Window)
+		if err != nil {
+			return fmt.Errorf("recording failed login: %w", err)
+		}
+
+		maxAttempts := t.options.MaxAttempts
+		if strings.HasPrefix(key, "ip:") {
+			maxAttempts = t.options.MaxAttemptsPerIP
+		}
+
+		if failures < maxAttempts {
+			continue
+		}
+
+		lockout := t.lockout(failures - maxAttempts)
+
+		if err := t.store.Lock(ctx, key, now.Add(lockout)); err != nil {
+			return fmt.Errorf("locking out logins: %w", err)
+		}
+
+		t.record(ctx, &domain.AuditEntry{
+			Action:    domain.AuditLoginLocked,
+			UserID:    userID,
+			IPAddress: ip,
+			Details:   fmt.Sprintf("%s locked out for %s after %d failed logins", key, lockout, failures),
+		})
+	}
+
+	return nil
+}
+
+// RecordSuccess forgets the failed logins to the account. Those from the IP
+// address keep counting, so that an attacker cannot reset them by logging in
+// to an account of their own.
+func (t *loginThrottle) RecordSuccess(ctx context.Context, email string) error {
+	if err := t.store.Reset(ctx, accountKey(email)); err != nil {
+		return fmt.Errorf("resetting login throttle: %w", err)
+	}
+
+	return nil
+}
+
+// Unlock lifts the lockout of the user's account on behalf of an admin.
+func (t *loginThrottle) Unlock(ctx context.Context, user *domain.User, actorID uint) error {
+	if err := t.store.Reset(ctx, accountKey(user.Email)); err != nil {
+		return fmt.Errorf("resetting login throttle: %w", err)
+	}
+
+	t.record(ctx, &domain.AuditEntry{
+		Action:  domain.AuditLoginUnlocked,
+		ActorID: &actorID,
+		UserID:  &user.ID,
+		Details: "account unlocked by an admin",
+	})
+
+	return nil
+}
+
+// lockout returns how long to lock out logins after the given number of
+// failures beyond the allowed attempts.
+func (t *loginThrottle) lockout(excess int) time.Duration {
+	lockout := t.options.LockoutBase
+
+	for i := 0; i < excess && lockout < t.options.LockoutMax; i++ {
+		lockout *= 2
+	}
+
+	return min(lockout, t.options.LockoutMax)
+}
+
+// record writes the audit entry. Failing to do so does not fail the login.
+func (t *loginThrottle) record(ctx context.Context, entry *domain.AuditEntry) {
+	if err := t.audit.Create(ctx, entry); err != nil {
+		log.Printf("Failed to record %s audit entry: %v", entry.Action, err)
+	}
+}
+
+func (t *loginThrottle) keys(email, ip string) []string {
+	keys := []string{accountKey(email)}
+	if ip != "" {
+		keys = append(keys, "ip:"+ip)
+	}
+
+	return keys
+}
+
+// accountKey identifies the account with the email, whether or not it exists,
+// so that lockouts do not tell which emails are registered.
+func accountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,403 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"image/png"
+	"movie_app/internal/domain"
+	"movie_app/internal/repository"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"gorm.io/gorm"
+)
+
+const (
+	totpPeriod = 30
+	totpSkew   = 1 // Steps accepted either side of the current one, for clock drift
+	qrCodeSize = 256
+
+	recoveryCodeCount  = 10
+	recoveryCodeLength = 10
+)
+
+var (
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrMFANotEnrolled    = errors.New("no authenticator app is waiting to be confirmed")
+	ErrInvalidMFACode    = errors.New("invalid two-factor authentication code")
+)
+
+var recoveryCodeEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+var totpOptions = totp.ValidateOpts{
+	Period:    totpPeriod,
+	Digits:    otp.DigitsSix,
+	Algorithm: otp.AlgorithmSHA1,
+}
+
+type MFAService interface {
+	GetStatus(ctx context.Context, userID uint) (*domain.MFAStatus, error)
+	Enabled(ctx context.Context, userID uint) (bool, error)
+	EnrollTOTP(ctx context.Context, userID uint) (*domain.TOTPEnrollment, error)
+	ConfirmTOTP(
+		ctx context.Context, userID uint, code string, client domain.ClientInfo,
+	) (*domain.RecoveryCodesResponse, error)
+	Disable(ctx context.Context, userID uint, code string, client domain.ClientInfo) error
+	RegenerateRecoveryCodes(
+		ctx context.Context, userID uint, code string, client domain.ClientInfo,
+	) (*domain.RecoveryCodesResponse, error)
+	VerifyCode(ctx context.Context, userID uint, code string) error
+}
+
+type MFAOptions struct {
+	Issuer                   string // Name authenticator apps show for the account
+	EncryptionSecret         string // Encrypts the TOTP secrets at rest
+	PreviousEncryptionSecret string // Still decrypts TOTP secrets encrypted before EncryptionSecret changed
+}
+
+type mfaService struct {
+	repo     repository.MFARepository
+	users    repository.UserRepository
+	throttle LoginThrottle
+	options  MFAOptions
+	box      secretBox
+}
+
+func NewMFAService(
+	repo repository.MFARepository, users repository.UserRepository, throttle LoginThrottle, options MFAOptions,
+) *mfaService {
+	return &mfaService{
+		repo:     repo,
+		users:    users,
+		throttle: throttle,
+		options:  options,
+		box:      newSecretBox(options.EncryptionSecret, options.PreviousEncryptionSecret),
+	}
+}
+
+func (s *mfaService) GetStatus(ctx context.Context, userID uint) (*domain.MFAStatus, error) {
+	enabled, err := s.Enabled(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !enabled {
+		return &domain.MFAStatus{}, nil
+	}
+
+	left, err := s.repo.CountRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("counting recovery codes: %w", err)
+	}
+
+	return &domain.MFAStatus{Enabled: true, RecoveryCodesLeft: left}, nil
+}
+
+// Enabled reports whether the user has a confirmed authenticator app.
+func (s *mfaService) Enabled(ctx context.Context, userID uint) (bool, error) {
+	secret, err := s.repo.GetTOTPSecret(ctx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("getting TOTP secret: %w", err)
+	}
+
+	return secret.ConfirmedAt != nil, nil
+}
+
+// EnrollTOTP generates a new authenticator app secret for the user. It takes
+// effect once ConfirmTOTP checks a code generated with it.
+func (s *mfaService) EnrollTOTP(ctx context.Context, userID uint) (*domain.TOTPEnrollment, error) {
+	enabled, err := s.Enabled(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if enabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("getting user by ID: %w", err)
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      s.options.Issuer,
+		AccountName: user.Email,
+		Period:      totpPeriod,
+		Digits:      otp.DigitsSix,
+		Algorithm:   otp.AlgorithmSHA1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("generating TOTP secret: %w", err)
+	}
+
+	encrypted, err := s.box.seal([]byte(key.Secret()))
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.SaveTOTPSecret(ctx, &domain.TOTPSecret{UserID: userID, Secret: encrypted})
+	if err != nil {
+		return nil, fmt.Errorf("saving TOTP secret: %w", err)
+	}
+
+	image, err := key.Image(qrCodeSize, qrCodeSize)
+	if err != nil {
+		return nil, fmt.Errorf("generating QR code: %w", err)
+	}
+
+	var qrCode bytes.Buffer
+	if err := png.Encode(&qrCode, image); err != nil {
+		return nil, fmt.Errorf("encoding QR code: %w", err)
+	}
+
+	return &domain.TOTPEnrollment{
+		Secret:     key.Secret(),
+		OTPAuthURI: key.URL(),
+		QRCodePNG:  qrCode.Bytes(),
+	}, nil
+}
+
+// ConfirmTOTP enables two-factor authentication with a code from the newly
+// set up authenticator app, and returns the user's recovery codes. They are
+// only ever shown here and by RegenerateRecoveryCodes.
+func (s *mfaService) ConfirmTOTP(
+	ctx context.Context, userID uint, code string, client domain.ClientInfo,
+) (*domain.RecoveryCodesResponse, error) {
+	user, err := s.checkThrottle(ctx, userID, client)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := s.repo.GetTOTPSecret(ctx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrMFANotEnrolled
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("getting TOTP secret: %w", err)
+	}
+
+	if secret.ConfirmedAt != nil {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	step, ok, err := s.matchStep(secret, code)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, s.codeFailed(ctx, user, client)
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.ConfirmTOTPSecret(ctx, userID, step, hashes)
+	if errors.Is(err, repository.ErrConfirmTOTPSecret) {
+		return nil, s.codeFailed(ctx, user, client)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("confirming TOTP secret: %w", err)
+	}
+
+	if err := s.throttle.RecordSuccess(ctx, user.Email); err != nil {
+		return nil, err
+	}
+
+	return &domain.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// Disable turns two-factor authentication off, given a valid code.
+func (s *mfaService) Disable(ctx context.Context, userID uint, code string, client domain.ClientInfo) error {
+	if err := s.verifyThrottled(ctx, userID, code, client); err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, userID); err != nil {
+		return fmt.Errorf("disabling two-factor authentication: %w", err)
+	}
+
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes, given a valid
+// code.
+func (s *mfaService) RegenerateRecoveryCodes(
+	ctx context.Context, userID uint, code string, client domain.ClientInfo,
+) (*domain.RecoveryCodesResponse, error) {
+	if err := s.verifyThrottled(ctx, userID, code, client); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, fmt.Errorf("replacing recovery codes: %w", err)
+	}
+
+	return &domain.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// verifyThrottled is VerifyCode for signed in users. Wrong codes count as
+// failed logins, as they do when logging in, so that a stolen access token
+// cannot be used to guess codes either.
+func (s *mfaService) verifyThrottled(ctx context.Context, userID uint, code string, client domain.ClientInfo) error {
+	user, err := s.checkThrottle(ctx, userID, client)
+	if err != nil {
+		return err
+	}
+
+	if err := s.VerifyCode(ctx, userID, code); err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			return s.codeFailed(ctx, user, client)
+		}
+
+		return err
+	}
+
+	return s.throttle.RecordSuccess(ctx, user.Email)
+}
+
+// checkThrottle returns the user unless their logins, or those from the
+// client's IP address, are locked out.
+func (s *mfaService) checkThrottle(ctx context.Context, userID uint, client domain.ClientInfo) (*domain.User, error) {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("getting user by ID: %w", err)
+	}
+
+	if err := s.throttle.Check(ctx, user.Email, client.IPAddress); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// codeFailed records the wrong code as a failed login and returns
+// ErrInvalidMFACode, or the error recording it.
+func (s *mfaService) codeFailed(ctx context.Context, user *domain.User, client domain.ClientInfo) error {
+	if err := s.throttle.RecordFailure(ctx, user.Email, client.IPAddress, &user.ID); err != nil {
+		return err
+	}
+
+	return ErrInvalidMFACode
+}
+
+// VerifyCode checks an authenticator app code or a recovery code. Either can
+// only be used once.
+func (s *mfaService) VerifyCode(ctx context.Context, userID uint, code string) error {
+	secret, err := s.repo.GetTOTPSecret(ctx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrMFANotEnabled
+	}
+
+	if err != nil {
+		return fmt.Errorf("getting TOTP secret: %w", err)
+	}
+
+	if secret.ConfirmedAt == nil {
+		return ErrMFANotEnabled
+	}
+
+	code = normalizeCode(code)
+
+	var used bool
+
+	if len(code) == int(otp.DigitsSix) {
+		step, ok, err := s.matchStep(secret, code)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return ErrInvalidMFACode
+		}
+
+		used, err = s.repo.UseStep(ctx, userID, step)
+		if err != nil {
+			return fmt.Errorf("using TOTP code: %w", err)
+		}
+	} else {
+		used, err = s.repo.UseRecoveryCode(ctx, userID, hashToken(code))
+		if err != nil {
+			return fmt.Errorf("using recovery code: %w", err)
+		}
+	}
+
+	if !used {
+		return ErrInvalidMFACode
+	}
+
+	return nil
+}
+
+// matchStep returns the time step the code was generated for, within the
+// allowed clock drift.
+func (s *mfaService) matchStep(secret *domain.TOTPSecret, code string) (int64, bool, error) {
+	key, err := s.box.open(secret.Secret)
+	if err != nil {
+		return 0, false, err
+	}
+
+	code = normalizeCode(code)
+	current := time.Now().Unix() / totpPeriod
+
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		expected, err := totp.GenerateCodeCustom(string(key), time.Unix(step*totpPeriod, 0), totpOptions)
+		if err != nil {
+			return 0, false, fmt.Errorf("generating TOTP code: %w", err)
+		}
+
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true, nil
+		}
+	}
+
+	return 0, false, nil
+}
+
+// newRecoveryCodes returns a set of recovery codes, formatted as
+// xxxxx-xxxxx, along with their hashes.
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		random := make([]byte, recoveryCodeLength)
+		if _, err := rand.Read(random); err != nil {
+			return nil, nil, fmt.Errorf("generating recovery code: %w", err)
+		}
+
+		code := recoveryCodeEncoding.EncodeToString(random)[:recoveryCodeLength]
+
+		codes = append(codes, code[:recoveryCodeLength/2]+"-"+code[recoveryCodeLength/2:])
+		hashes = append(hashes, hashToken(code))
+	}
+
+	return codes, hashes, nil
+}
+
+// normalizeCode strips the separators people type or paste along with codes.
+func normalizeCode(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(code))
+}
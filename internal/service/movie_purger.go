@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"movie_app/internal/repository"
+	"time"
+)
+
+type MoviePurgerOptions struct {
+	Retention time.Duration // How long deleted movies are kept in the trash before they are purged
+}
+
+// MoviePurger permanently deletes movies once they have been in the trash
+// for longer than the retention period.
+type MoviePurger struct {
+	movies  repository.MovieRepository
+	options MoviePurgerOptions
+}
+
+func NewMoviePurger(movies repository.MovieRepository, options MoviePurgerOptions) *MoviePurger {
+	return &MoviePurger{
+		movies:  movies,
+		options: options,
+	}
+}
+
+// Purge deletes the movies deleted longer than the retention period ago.
+func (p *MoviePurger) Purge(ctx context.Context) error {
+	purged, err := p.movies.PurgeTrashed(ctx, time.Now().Add(-p.options.Retention))
+	if purged > 0 {
+		log.Printf("Purged %d deleted movies", purged)
+	}
+
+	if err != nil {
+		return fmt.Errorf("purging deleted movies: %w", err)
+	}
+
+	return nil
+}
+
+// Run purges deleted movies every interval until the context is done.
+func (p *MoviePurger) Run(ctx context.Context, interval time.Duration) {
+	runPeriodically(ctx, interval, "purge deleted movies", p.Purge)
+}
@@ -6,7 +6,19 @@ import (
 	"fmt"
 	"movie_app/internal/domain"
 	"movie_app/internal/repository"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+	MaxPage         = 10000 // Keeps offsets bounded, cursor pagination goes further
+	MaxSuggestions  = domain.MaxSuggestions
 )
 
 var (
@@ -14,37 +26,93 @@ var (
 	ErrInvalidDuration = errors.New("invalid duration")
 	ErrInvalidRating   = errors.New("rating must be between 1 and 10")
 	ErrMovieNotFound   = errors.New("movie not found")
+	ErrInvalidPage     = fmt.Errorf("page must be between 1 and %d", MaxPage)
+	ErrInvalidLimit    = fmt.Errorf("limit must be between 1 and %d", MaxPageSize)
+	ErrInvalidSort     = errors.New("invalid sort field")
+	ErrInvalidRange    = errors.New("invalid filter range")
+	ErrEmptySearch     = errors.New("search query must contain at least one word")
+	ErrInvalidSuggest  = fmt.Errorf("suggestion limit must be between 1 and %d", MaxSuggestions)
+	ErrGenreRequired   = errors.New("at least one genre is required")
+	ErrUnknownGenre    = errors.New("unknown genre")
+	ErrMovieForbidden  = errors.New("movie was added by another user")
 )
 
+// searchTermPattern matches the words of a search query; everything else,
+// including tsquery operators, is dropped.
+var searchTermPattern = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
 type MovieService interface {
-	Create(ctx context.Context, movie *domain.Movie) (*domain.Movie, error)
+	Create(ctx context.Context, actor domain.Actor, movie *domain.Movie) (*domain.Movie, error)
 	GetByID(ctx context.Context, id uint) (*domain.Movie, error)
 	GetAll(ctx context.Context) ([]domain.Movie, error)
-	Update(ctx context.Context, movie *domain.Movie) (*domain.Movie, error)
-	Delete(ctx context.Context, id uint) error
+	List(ctx context.Context, query domain.MovieQuery) (*domain.MoviePage, error)
+	Search(ctx context.Context, query domain.MovieSearchQuery) (*domain.MovieSearchPage, error)
+	Suggest(ctx context.Context, query domain.MovieSuggestQuery) ([]domain.MovieSuggestion, error)
+	Update(ctx context.Context, actor domain.Actor, movie *domain.Movie) (*domain.Movie, error)
+	Delete(ctx context.Context, actor domain.Actor, id uint) error
+	ListTrash(ctx context.Context, query domain.MovieTrashQuery) (*domain.MovieTrashPage, error)
+	Restore(ctx context.Context, id uint) (*domain.Movie, error)
+	Purge(ctx context.Context, id uint) error
+}
+
+// MovieOptions configures the listing and search behaviour of MovieService.
+type MovieOptions struct {
+	CursorSecret        string
+	SimilarityThreshold float64
+	SuggestLimit        int
+	TrashRetention      time.Duration // How long deleted movies stay in the trash before they are purged
 }
 
 type movieService struct {
-	repo repository.MovieRepository
+	repo    repository.MovieRepository
+	genres  repository.GenreRepository
+	people  repository.PersonRepository
+	cursors *cursorCodec
+	options MovieOptions
 }
 
-func NewMovieService(repo repository.MovieRepository) *movieService {
+func NewMovieService(
+	repo repository.MovieRepository,
+	genres repository.GenreRepository,
+	people repository.PersonRepository,
+	options MovieOptions,
+) *movieService {
 	return &movieService{
-		repo: repo,
+		repo:    repo,
+		genres:  genres,
+		people:  people,
+		cursors: newCursorCodec(options.CursorSecret),
+		options: options,
 	}
 }
 
-func (s *movieService) Create(ctx context.Context, movie *domain.Movie) (*domain.Movie, error) {
+// Create adds the movie on behalf of the actor, who may then change it
+// without being an editor.
+func (s *movieService) Create(ctx context.Context, actor domain.Actor, movie *domain.Movie) (*domain.Movie, error) {
 	if err := s.ValidateMovie(movie); err != nil {
 		return nil, fmt.Errorf("validating movie: %w", err)
 	}
 
+	movie.CreatedByID = &actor.UserID
+	movie.UpdatedByID = &actor.UserID
+
+	if err := s.resolveGenres(ctx, movie); err != nil {
+		return nil, err
+	}
+
+	for i := range movie.Credits {
+		if err := validateCredit(ctx, s.people, &movie.Credits[i]); err != nil {
+			return nil, err
+		}
+	}
+
 	result, err := s.repo.Create(ctx, movie)
 	if err != nil {
 		return nil, fmt.Errorf("creating movie: %w", err)
 	}
 
-	return result, nil
+	// Reload to include the director credits created along with the movie
+	return s.GetByID(ctx, result.ID)
 }
 
 func (s *movieService) GetByID(ctx context.Context, id uint) (*domain.Movie, error) {
@@ -65,27 +133,246 @@ func (s *movieService) GetAll(ctx context.Context) ([]domain.Movie, error) {
 	return result, nil
 }
 
-func (s *movieService) Update(ctx context.Context, movie *domain.Movie) (*domain.Movie, error) {
+func (s *movieService) List(ctx context.Context, query domain.MovieQuery) (*domain.MoviePage, error) {
+	if err := normalizeQuery(&query, domain.MovieSortableFields); err != nil {
+		return nil, fmt.Errorf("validating query: %w", err)
+	}
+
+	result, err := s.listPage(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if query.Facets {
+		result.Facets, err = s.repo.Facets(ctx, domain.MovieSearchQuery{MovieQuery: query})
+		if err != nil {
+			return nil, fmt.Errorf("aggregating facets: %w", err)
+		}
+	}
+
+	return result, nil
+}
+
+func (s *movieService) listPage(ctx context.Context, query domain.MovieQuery) (*domain.MoviePage, error) {
+	if !query.IsCursorMode() {
+		result, err := s.repo.List(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("listing movies: %w", err)
+		}
+
+		return result, nil
+	}
+
+	if query.Cursor != "" {
+		after, err := s.cursors.Decode(query, query.Cursor)
+		if err != nil {
+			return nil, fmt.Errorf("decoding cursor: %w", err)
+		}
+
+		query.After = after
+	}
+
+	// Fetch one extra row to find out whether there is a next page.
+	pageQuery := query
+	pageQuery.Limit++
+
+	result, err := s.repo.List(ctx, pageQuery)
+	if err != nil {
+		return nil, fmt.Errorf("listing movies: %w", err)
+	}
+
+	if len(result.Movies) > query.Limit {
+		result.Movies = result.Movies[:query.Limit]
+
+		result.NextCursor, err = s.cursors.Encode(query, &result.Movies[query.Limit-1])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+func (s *movieService) Search(ctx context.Context, query domain.MovieSearchQuery) (*domain.MovieSearchPage, error) {
+	if err := normalizeQuery(&query.MovieQuery, domain.MovieSortableFields); err != nil {
+		return nil, fmt.Errorf("validating query: %w", err)
+	}
+
+	if query.IsCursorMode() {
+		return nil, fmt.Errorf("%w: search only supports offset pagination", ErrInvalidPagination)
+	}
+
+	terms := searchTermPattern.FindAllString(strings.ToLower(query.Q), -1)
+	if len(terms) == 0 {
+		return nil, ErrEmptySearch
+	}
+
+	// Every term must match as a prefix, so partial words still find results.
+	for i := range terms {
+		terms[i] = "'" + terms[i] + "':*"
+	}
+
+	query.TSQuery = strings.Join(terms, " & ")
+
+	result, err := s.repo.Search(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("searching movies: %w", err)
+	}
+
+	if query.Facets {
+		result.Facets, err = s.repo.Facets(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("aggregating facets: %w", err)
+		}
+	}
+
+	return result, nil
+}
+
+func (s *movieService) Suggest(ctx context.Context, query domain.MovieSuggestQuery) ([]domain.MovieSuggestion, error) {
+	if query.Limit == 0 {
+		query.Limit = s.options.SuggestLimit
+	}
+
+	if query.Limit < 1 || query.Limit > MaxSuggestions {
+		return nil, ErrInvalidSuggest
+	}
+
+	prefix := strings.TrimSpace(query.Prefix)
+	if prefix == "" {
+		return nil, ErrEmptySearch
+	}
+
+	result, err := s.repo.Suggest(ctx, prefix, query.Limit, s.options.SimilarityThreshold)
+	if err != nil {
+		return nil, fmt.Errorf("suggesting movies: %w", err)
+	}
+
+	return result, nil
+}
+
+// Update saves the changes the actor made to the movie, as loaded by
+// GetByID. Only editors, admins and the user who added the movie may change
+// it.
+func (s *movieService) Update(ctx context.Context, actor domain.Actor, movie *domain.Movie) (*domain.Movie, error) {
+	if !movie.CanModify(actor, domain.PermMoviesWrite) {
+		return nil, ErrMovieForbidden
+	}
+
 	if err := s.ValidateMovie(movie); err != nil {
 		return nil, fmt.Errorf("validating movie: %w", err)
 	}
 
+	movie.UpdatedByID = &actor.UserID
+
+	if err := s.resolveGenres(ctx, movie); err != nil {
+		return nil, err
+	}
+
 	result, err := s.repo.Update(ctx, movie)
 	if err != nil {
 		return nil, fmt.Errorf("updating movie: %w", err)
 	}
 
-	return result, nil
+	// Reload to include director credits replaced along with the director
+	return s.GetByID(ctx, result.ID)
 }
 
-func (s *movieService) Delete(ctx context.Context, id uint) error {
-	if err := s.repo.Delete(ctx, id); err != nil {
+// Delete moves the movie to the trash, from which it is purged along with its
+// ratings and reviews once the retention period is over. Only editors, admins
+// and the user who added the movie may delete it.
+func (s *movieService) Delete(ctx context.Context, actor domain.Actor, id uint) error {
+	movie, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrMovieNotFound
+	}
+
+	if err != nil {
+		return fmt.Errorf("getting movie by ID: %w", err)
+	}
+
+	if !movie.CanModify(actor, domain.PermMoviesDelete) {
+		return ErrMovieForbidden
+	}
+
+	err = s.repo.Delete(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrMovieNotFound
+	}
+
+	if err != nil {
 		return fmt.Errorf("deleting movie: %w", err)
 	}
 
 	return nil
 }
 
+// ListTrash returns a page of the deleted movies along with when each will
+// be purged.
+func (s *movieService) ListTrash(ctx context.Context, query domain.MovieTrashQuery) (*domain.MovieTrashPage, error) {
+	if query.Page == 0 {
+		query.Page = 1
+	}
+
+	if query.Page < 0 || query.Page > MaxPage {
+		return nil, ErrInvalidPage
+	}
+
+	if query.Limit == 0 {
+		query.Limit = DefaultPageSize
+	}
+
+	if query.Limit < 0 || query.Limit > MaxPageSize {
+		return nil, ErrInvalidLimit
+	}
+
+	movies, total, err := s.repo.ListTrashed(ctx, query.Page, query.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("listing deleted movies: %w", err)
+	}
+
+	page := &domain.MovieTrashPage{Movies: make([]domain.TrashedMovie, 0, len(movies)), Total: total}
+	for _, movie := range movies {
+		page.Movies = append(page.Movies, domain.TrashedMovie{
+			Movie:     movie,
+			TrashedAt: movie.DeletedAt.Time,
+			PurgeAt:   movie.DeletedAt.Time.Add(s.options.TrashRetention),
+		})
+	}
+
+	return page, nil
+}
+
+// Restore takes the movie out of the trash, along with its ratings and
+// reviews.
+func (s *movieService) Restore(ctx context.Context, id uint) (*domain.Movie, error) {
+	err := s.repo.Restore(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrMovieNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("restoring movie: %w", err)
+	}
+
+	return s.GetByID(ctx, id)
+}
+
+// Purge permanently deletes the movie from the trash without waiting for the
+// retention period to end.
+func (s *movieService) Purge(ctx context.Context, id uint) error {
+	err := s.repo.Purge(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrMovieNotFound
+	}
+
+	if err != nil {
+		return fmt.Errorf("purging movie: %w", err)
+	}
+
+	return nil
+}
+
 func (s *movieService) ValidateMovie(movie *domain.Movie) error {
 	currentYear := time.Now().Year()
 	if movie.Year < 1888 || movie.Year > currentYear+5 {
@@ -96,9 +383,184 @@ func (s *movieService) ValidateMovie(movie *domain.Movie) error {
 		return ErrInvalidDuration
 	}
 
-	if movie.Rating < 1 || movie.Rating > 10 {
+	return validateRating(movie.Rating)
+}
+
+// checkMovieExists returns ErrMovieNotFound unless the movie exists.
+func checkMovieExists(ctx context.Context, movies repository.MovieRepository, movieID uint) error {
+	if _, err := movies.GetByID(ctx, movieID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrMovieNotFound
+		}
+
+		return fmt.Errorf("getting movie by ID: %w", err)
+	}
+
+	return nil
+}
+
+// validateRating applies the 1 to 10 scale shared by editorial and user ratings.
+func validateRating(rating float64) error {
+	if rating < 1 || rating > 10 {
 		return ErrInvalidRating
 	}
 
 	return nil
 }
+
+// resolveGenres replaces the movie's genres, given by ID or by slug, with the
+// stored genres, rejecting unknown ones.
+func (s *movieService) resolveGenres(ctx context.Context, movie *domain.Movie) error {
+	var (
+		ids   []uint
+		slugs []string
+	)
+
+	for _, genre := range movie.Genres {
+		if genre.ID != 0 {
+			ids = append(ids, genre.ID)
+		} else {
+			slugs = append(slugs, domain.Slugify(genre.Slug))
+		}
+	}
+
+	if len(ids) == 0 && len(slugs) == 0 {
+		return ErrGenreRequired
+	}
+
+	resolved := make(map[uint]domain.Genre)
+
+	if len(ids) > 0 {
+		found, err := s.genres.FindByIDs(ctx, ids)
+		if err != nil {
+			return fmt.Errorf("resolving genres: %w", err)
+		}
+
+		for _, genre := range found {
+			resolved[genre.ID] = genre
+		}
+
+		for _, id := range ids {
+			if _, ok := resolved[id]; !ok {
+				return fmt.Errorf("%w: %d", ErrUnknownGenre, id)
+			}
+		}
+	}
+
+	if len(slugs) > 0 {
+		found, err := s.genres.FindBySlugs(ctx, slugs)
+		if err != nil {
+			return fmt.Errorf("resolving genres: %w", err)
+		}
+
+		bySlug := make(map[string]bool, len(found))
+		for _, genre := range found {
+			resolved[genre.ID] = genre
+			bySlug[genre.Slug] = true
+		}
+
+		for _, slug := range slugs {
+			if !bySlug[slug] {
+				return fmt.Errorf("%w: %q", ErrUnknownGenre, slug)
+			}
+		}
+	}
+
+	movie.Genres = make([]domain.Genre, 0, len(resolved))
+	for _, genre := range resolved {
+		movie.Genres = append(movie.Genres, genre)
+	}
+
+	slices.SortFunc(movie.Genres, func(a, b domain.Genre) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return nil
+}
+
+// normalizeQuery applies paging defaults, parses the sort expression against
+// the sortable fields and rejects out-of-range filters.
+func normalizeQuery(query *domain.MovieQuery, sortable []string) error {
+	if query.Cursor != "" && query.Pagination == "" {
+		query.Pagination = domain.PaginationCursor
+	}
+
+	switch query.Pagination {
+	case "", domain.PaginationOffset:
+		if query.Cursor != "" {
+			return fmt.Errorf("%w: cursor requires cursor pagination", ErrInvalidPagination)
+		}
+	case domain.PaginationCursor:
+		if query.Page > 1 {
+			return fmt.Errorf("%w: page cannot be used with cursor pagination", ErrInvalidPagination)
+		}
+	default:
+		return ErrInvalidPagination
+	}
+
+	if query.Page == 0 {
+		query.Page = 1
+	}
+
+	if query.Page < 0 || query.Page > MaxPage {
+		return ErrInvalidPage
+	}
+
+	if query.Limit == 0 {
+		query.Limit = DefaultPageSize
+	}
+
+	if query.Limit < 0 || query.Limit > MaxPageSize {
+		return ErrInvalidLimit
+	}
+
+	fields, err := parseSort(query.Sort, sortable)
+	if err != nil {
+		return err
+	}
+
+	query.SortFields = fields
+
+	if query.YearFrom != nil && query.YearTo != nil && *query.YearFrom > *query.YearTo {
+		return fmt.Errorf("%w: yearFrom is greater than yearTo", ErrInvalidRange)
+	}
+
+	if query.RatingMin != nil && query.RatingMax != nil && *query.RatingMin > *query.RatingMax {
+		return fmt.Errorf("%w: ratingMin is greater than ratingMax", ErrInvalidRange)
+	}
+
+	if query.DurationMin != nil && query.DurationMax != nil && *query.DurationMin > *query.DurationMax {
+		return fmt.Errorf("%w: durationMin is greater than durationMax", ErrInvalidRange)
+	}
+
+	return nil
+}
+
+// parseSort turns an expression like "-rating,year" into sort fields.
+func parseSort(sort string, sortable []string) ([]domain.SortField, error) {
+	if strings.TrimSpace(sort) == "" {
+		return nil, nil
+	}
+
+	var fields []domain.SortField
+
+	seen := make(map[string]bool)
+
+	for _, part := range strings.Split(sort, ",") {
+		part = strings.TrimSpace(part)
+		field := domain.SortField{Field: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")}
+
+		if !slices.Contains(sortable, field.Field) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidSort, field.Field)
+		}
+
+		if seen[field.Field] {
+			return nil, fmt.Errorf("%w: %q is repeated", ErrInvalidSort, field.Field)
+		}
+
+		seen[field.Field] = true
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
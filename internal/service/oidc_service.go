@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"movie_app/internal/domain"
+	"movie_app/internal/oidc"
+	"movie_app/internal/repository"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrOIDCDisabled         = errors.New("single sign-on is not configured")
+	ErrOIDCStateInvalid     = errors.New("login has expired or was already completed, please start over")
+	ErrOIDCLoginFailed      = errors.New("identity provider did not confirm the login")
+	ErrOIDCEmailNotVerified = errors.New("identity provider has not verified your email")
+	ErrOIDCNoAccount        = errors.New("no account is registered with your email")
+	ErrOIDCIdentityConflict = errors.New("account is already linked to another identity at this provider")
+)
+
+// OIDCOptions configure logging in with an identity provider.
+type OIDCOptions struct {
+	AutoRegister bool          // Create accounts for users whose email is not registered yet
+	StateTTL     time.Duration // How long users have to log in at the provider
+}
+
+// OIDCService logs users in with an OpenID Connect identity provider. Users
+// are linked to the identity they logged in with by its verified email, or
+// registered if no account has it, and issued the same tokens as a login
+// with a password.
+type OIDCService interface {
+	Authorize(ctx context.Context) (url, state string, err error)
+	Callback(ctx context.Context, req domain.OIDCCallbackRequest, client domain.ClientInfo) (*domain.LoginResponse, error)
+}
+
+type oidcService struct {
+	repo     repository.OIDCRepository
+	users    repository.UserRepository
+	audit    repository.AuditRepository
+	tokens   TokenService
+	mfa      MFAService
+	provider oidc.Provider
+	options  OIDCOptions
+}
+
+func NewOIDCService(
+	repo repository.OIDCRepository,
+	users repository.UserRepository,
+	audit repository.AuditRepository,
+	tokens TokenService,
+	mfa MFAService,
+	provider oidc.Provider,
+	options OIDCOptions,
+) *oidcService {
+	return &oidcService{
+		repo:     repo,
+		users:    users,
+		audit:    audit,
+		tokens:   tokens,
+		mfa:      mfa,
+		provider: provider,
+		options:  options,
+	}
+}
+
+// Authorize starts a login and returns the provider URL to send the user to,
+// along with the state the provider sends them back with. Callers must tie
+// the state to the user's browser, so that nobody else can complete the
+// login there.
+func (s *oidcService) Authorize(ctx context.Context) (string, string, error) {
+	state, err := randomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	nonce, err := randomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	verifier := oauth2.GenerateVerifier()
+
+	err = s.repo.CreateState(ctx, &domain.OIDCLoginState{
+		StateHash:    hashToken(state),
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(s.options.StateTTL),
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("creating login state: %w", err)
+	}
+
+	url, err := s.provider.AuthCodeURL(ctx, state, nonce, verifier)
+	if errors.Is(err, oidc.ErrDisabled) {
+		return "", "", ErrOIDCDisabled
+	}
+
+	if err != nil {
+		return "", "", fmt.Errorf("building authorization URL: %w", err)
+	}
+
+	return url, state, nil
+}
+
+// Callback finishes the login with the code the provider sent the user back
+// with. Users with two-factor authentication get an MFA token to verify,
+// as they do when logging in with a password.
+func (s *oidcService) Callback(
+	ctx context.Context, req domain.OIDCCallbackRequest, client domain.ClientInfo,
+) (*domain.LoginResponse, error) {
+	state, err := s.repo.ConsumeState(ctx, hashToken(req.State))
+	if errors.Is(err, repository.ErrOIDCStateInvalid) {
+		return nil, ErrOIDCStateInvalid
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("consuming login state: %w", err)
+	}
+
+	claims, err := s.provider.Exchange(ctx, req.Code, state.CodeVerifier, state.Nonce)
+	if errors.Is(err, oidc.ErrDisabled) {
+		return nil, ErrOIDCDisabled
+	}
+
+	if err != nil {
+		log.Printf("Failed to complete login with the identity provider: %v", err)
+
+		return nil, ErrOIDCLoginFailed
+	}
+
+	user, err := s.resolveUser(ctx, claims, client)
+	if err != nil {
+		return nil, err
+	}
+
+	mfaEnabled, err := s.mfa.Enabled(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("checking two-factor authentication: %w", err)
+	}
+
+	if mfaEnabled {
+		result, err := s.tokens.IssueMFAChallenge(user, req.Device)
+		if err != nil {
+			return nil, fmt.Errorf("issuing MFA token: %w", err)
+		}
+
+		return result, nil
+	}
+
+	client.Device = req.Device
+
+	result, err := s.tokens.Issue(ctx, user, client)
+	if err != nil {
+		return nil, fmt.Errorf("issuing tokens: %w", err)
+	}
+
+	return result, nil
+}
+
+// resolveUser returns the user linked to the identity, linking or
+// registering one by the identity's email if there is none.
+func (s *oidcService) resolveUser(
+	ctx context.Context, claims *oidc.Claims, client domain.ClientInfo,
+) (*domain.User, error) {
+	now := time.Now()
+
+	identity, err := s.repo.GetIdentity(ctx, claims.Issuer, claims.Subject)
+	if err == nil {
+		identity.Email = claims.Email
+		identity.LastLoginAt = now
+
+		if err := s.repo.TouchIdentity(ctx, identity); err != nil {
+			log.Printf("Failed to record login with identity %d: %v", identity.ID, err)
+		}
+
+		return &identity.User, nil
+	}
+
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("getting identity: %w", err)
+	}
+
+	// Anyone can claim any email at some providers, so only verified ones
+	// may be linked to an account
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, ErrOIDCEmailNotVerified
+	}
+
+	identity = &domain.UserIdentity{
+		Issuer:      claims.Issuer,
+		Subject:     claims.Subject,
+		Email:       claims.Email,
+		LastLoginAt: now,
+		CreatedAt:   now,
+	}
+
+	user, err := s.users.GetByEmail(ctx, claims.Email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return s.register(ctx, claims, identity)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("getting user by email: %w", err)
+	}
+
+	identity.UserID = user.ID
+
+	err = s.repo.LinkIdentity(ctx, identity)
+	if errors.Is(err, repository.ErrIdentityConflict) {
+		return nil, ErrOIDCIdentityConflict
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("linking identity: %w", err)
+	}
+
+	if user.EmailVerifiedAt == nil {
+		user.EmailVerifiedAt = &now
+	}
+
+	err = s.audit.Create(ctx, &domain.AuditEntry{
+		Action:    domain.AuditIdentityLinked,
+		ActorID:   &user.ID,
+		UserID:    &user.ID,
+		IPAddress: client.IPAddress,
+		Details:   fmt.Sprintf("linked to subject %s at %s", claims.Subject, claims.Issuer),
+	})
+	if err != nil {
+		log.Printf("Failed to record %s audit entry: %v", domain.AuditIdentityLinked, err)
+	}
+
+	return user, nil
+}
+
+// register creates an account for the identity. It has no password, so the
+// user can only log in with the identity provider until they reset it.
+func (s *oidcService) register(
+	ctx context.Context, claims *oidc.Claims, identity *domain.UserIdentity,
+) (*domain.User, error) {
+	if !s.options.AutoRegister {
+		return nil, ErrOIDCNoAccount
+	}
+
+	// Deleted accounts keep their email until they are purged
+	taken, err := s.users.EmailTaken(ctx, claims.Email)
+	if err != nil {
+		return nil, fmt.Errorf("checking email: %w", err)
+	}
+
+	if taken {
+		return nil, ErrEmailTaken
+	}
+
+	user := &domain.User{
+		Email:           claims.Email,
+		EmailVerifiedAt: &identity.CreatedAt,
+		Role:            domain.RoleViewer,
+		DisplayName:     truncateName(claims.Name),
+		CreatedAt:       identity.CreatedAt,
+		UpdatedAt:       identity.CreatedAt,
+	}
+
+	if err := s.repo.CreateUser(ctx, user, identity); err != nil {
+		return nil, fmt.Errorf("creating user: %w", err)
+	}
+
+	return user, nil
+}
+
+// truncateName shortens the name to fit a display name.
+func truncateName(name string) string {
+	runes := []rune(strings.TrimSpace(name))
+	if len(runes) <= 100 {
+		return string(runes)
+	}
+
+	return string(runes[:100])
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"movie_app/internal/domain"
+	"movie_app/internal/mail"
+	"movie_app/internal/repository"
+	"net/url"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// mailTimeout bounds sending an email in the background.
+const mailTimeout = 30 * time.Second
+
+var ErrInvalidResetToken = errors.New("password reset token is invalid or has expired")
+
+type PasswordService interface {
+	ForgotPassword(ctx context.Context, req domain.ForgotPasswordRequest) error
+	ResetPassword(ctx context.Context, req domain.ResetPasswordRequest) error
+}
+
+type PasswordOptions struct {
+	ResetTTL            time.Duration
+	ResetResendInterval time.Duration // Minimum time between two password reset emails to a user
+	AppURL              string        // Base URL of the web app page links point to
+}
+
+type passwordService struct {
+	repo      repository.PasswordResetRepository
+	users     repository.UserRepository
+	sessions  SessionService
+	mailer    mail.Mailer
+	templates *mail.Templates
+	options   PasswordOptions
+}
+
+func NewPasswordService(
+	repo repository.PasswordResetRepository,
+	users repository.UserRepository,
+	sessions SessionService,
+	mailer mail.Mailer,
+	templates *mail.Templates,
+	options PasswordOptions,
+) *passwordService {
+	return &passwordService{
+		repo:      repo,
+		users:     users,
+		sessions:  sessions,
+		mailer:    mailer,
+		templates: templates,
+		options:   options,
+	}
+}
+
+// ForgotPassword emails a password reset link to the user with the email,
+// unless they were sent one within the resend interval. It succeeds whether
+// or not the email is registered, and does all the work in the background,
+// so that neither the response nor its timing tell.
+func (s *passwordService) ForgotPassword(_ context.Context, req domain.ForgotPasswordRequest) error {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), mailTimeout)
+		defer cancel()
+
+		if err := s.sendReset(ctx, req.Email); err != nil {
+			log.Printf("Failed to send password reset email: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+func (s *passwordService) sendReset(ctx context.Context, email string) error {
+	user, err := s.users.GetByEmail(ctx, email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("getting user by email: %w", err)
+	}
+
+	lastSentAt, err := s.repo.LastCreatedAt(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("getting last password reset email: %w", err)
+	}
+
+	if lastSentAt != nil && time.Since(*lastSentAt) < s.options.ResetResendInterval {
+		return nil
+	}
+
+	token, err := randomToken(32)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.Create(ctx, &domain.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(s.options.ResetTTL),
+	})
+	if err != nil {
+		return fmt.Errorf("storing password reset token: %w", err)
+	}
+
+	message, err := s.templates.Render("password_reset", user.Email, map[string]any{
+		"Link":      s.options.AppURL + "/reset-password?token=" + url.QueryEscape(token),
+		"ExpiresIn": formatDuration(s.options.ResetTTL),
+	})
+	if err != nil {
+		return fmt.Errorf("rendering password reset email: %w", err)
+	}
+
+	if err := s.mailer.Send(ctx, message); err != nil {
+		return fmt.Errorf("sending %q email: %w", message.Subject, err)
+	}
+
+	return nil
+}
+
+// ResetPassword sets a new password with a reset token and signs the user
+// out everywhere.
+func (s *passwordService) ResetPassword(ctx context.Context, req domain.ResetPasswordRequest) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("hashing password: %w", err)
+	}
+
+	userID, err := s.repo.Reset(ctx, hashToken(req.Token), string(hashedPassword))
+	if errors.Is(err, repository.ErrPasswordResetInvalid) {
+		return ErrInvalidResetToken
+	}
+
+	if err != nil {
+		return fmt.Errorf("resetting password: %w", err)
+	}
+
+	if err := s.sessions.RevokeAllSessions(ctx, userID); err != nil {
+		return fmt.Errorf("signing out: %w", err)
+	}
+
+	return nil
+}
+
+// sendInBackground sends the email without holding up the request, logging
+// delivery failures.
+func sendInBackground(mailer mail.Mailer, message mail.Message) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), mailTimeout)
+		defer cancel()
+
+		if err := mailer.Send(ctx, message); err != nil {
+			log.Printf("Failed to send %q email: %v", message.Subject, err)
+		}
+	}()
+}
+
+// formatDuration describes a duration for people, such as "1 hour" or
+// "30 minutes".
+func formatDuration(d time.Duration) string {
+	value, unit := int(d/time.Minute), "minute"
+
+	switch {
+	case d >= 24*time.Hour && d%(24*time.Hour) == 0:
+		value, unit = int(d/(24*time.Hour)), "day"
+	case d >= time.Hour && d%time.Hour == 0:
+		value, unit = int(d/time.Hour), "hour"
+	}
+
+	if value == 1 {
+		return "1 " + unit
+	}
+
+	return fmt.Sprintf("%d %ss", value, unit)
+}
@@ -0,0 +1,317 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movie_app/internal/domain"
+	"movie_app/internal/repository"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPersonNotFound    = errors.New("person not found")
+	ErrPersonHasCredits  = errors.New("person still has credits")
+	ErrCreditNotFound    = errors.New("credit not found")
+	ErrInvalidDepartment = errors.New("invalid department")
+	ErrInvalidBirthDate  = errors.New("birth date must be a past date formatted as YYYY-MM-DD")
+)
+
+type PersonService interface {
+	Create(ctx context.Context, req domain.CreatePersonRequest) (*domain.Person, error)
+	GetByID(ctx context.Context, id uint) (*domain.Person, error)
+	List(ctx context.Context, query domain.PersonQuery) (*domain.PersonPage, error)
+	Update(ctx context.Context, id uint, req domain.UpdatePersonRequest) (*domain.Person, error)
+	Delete(ctx context.Context, id uint) error
+	Filmography(ctx context.Context, id uint) (*domain.Filmography, error)
+	GetCredits(ctx context.Context, movieID uint) ([]domain.Credit, error)
+	AddCredit(ctx context.Context, movieID uint, req domain.CreditRequest) (*domain.Credit, error)
+	UpdateCredit(ctx context.Context, movieID, creditID uint, req domain.UpdateCreditRequest) (*domain.Credit, error)
+	DeleteCredit(ctx context.Context, movieID, creditID uint) error
+}
+
+type personService struct {
+	repo    repository.PersonRepository
+	credits repository.CreditRepository
+	movies  repository.MovieRepository
+}
+
+func NewPersonService(
+	repo repository.PersonRepository, credits repository.CreditRepository, movies repository.MovieRepository,
+) *personService {
+	return &personService{
+		repo:    repo,
+		credits: credits,
+		movies:  movies,
+	}
+}
+
+func (s *personService) Create(ctx context.Context, req domain.CreatePersonRequest) (*domain.Person, error) {
+	person := &domain.Person{
+		Name:     req.Name,
+		Bio:      req.Bio,
+		PhotoURL: req.PhotoURL,
+	}
+
+	birthDate, err := parseBirthDate(req.BirthDate)
+	if err != nil {
+		return nil, err
+	}
+
+	person.BirthDate = birthDate
+
+	result, err := s.repo.Create(ctx, person)
+	if err != nil {
+		return nil, fmt.Errorf("creating person: %w", err)
+	}
+
+	return result, nil
+}
+
+func (s *personService) GetByID(ctx context.Context, id uint) (*domain.Person, error) {
+	result, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPersonNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("getting person by ID: %w", err)
+	}
+
+	return result, nil
+}
+
+func (s *personService) List(ctx context.Context, query domain.PersonQuery) (*domain.PersonPage, error) {
+	if query.Page == 0 {
+		query.Page = 1
+	}
+
+	if query.Page < 0 || query.Page > MaxPage {
+		return nil, ErrInvalidPage
+	}
+
+	if query.Limit == 0 {
+		query.Limit = DefaultPageSize
+	}
+
+	if query.Limit < 0 || query.Limit > MaxPageSize {
+		return nil, ErrInvalidLimit
+	}
+
+	result, err := s.repo.List(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("listing people: %w", err)
+	}
+
+	return result, nil
+}
+
+func (s *personService) Update(ctx context.Context, id uint, req domain.UpdatePersonRequest) (*domain.Person, error) {
+	person, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		person.Name = *req.Name
+	}
+
+	if req.BirthDate != nil {
+		person.BirthDate, err = parseBirthDate(*req.BirthDate)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Bio != nil {
+		person.Bio = *req.Bio
+	}
+
+	if req.PhotoURL != nil {
+		person.PhotoURL = *req.PhotoURL
+	}
+
+	result, err := s.repo.Update(ctx, person)
+	if err != nil {
+		return nil, fmt.Errorf("updating person: %w", err)
+	}
+
+	return result, nil
+}
+
+func (s *personService) Delete(ctx context.Context, id uint) error {
+	if _, err := s.GetByID(ctx, id); err != nil {
+		return err
+	}
+
+	count, err := s.repo.CountCredits(ctx, id)
+	if err != nil {
+		return fmt.Errorf("counting person credits: %w", err)
+	}
+
+	if count > 0 {
+		return ErrPersonHasCredits
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("deleting person: %w", err)
+	}
+
+	return nil
+}
+
+func (s *personService) Filmography(ctx context.Context, id uint) (*domain.Filmography, error) {
+	person, err := s.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	credits, err := s.repo.Filmography(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("getting filmography: %w", err)
+	}
+
+	if credits == nil {
+		credits = []domain.Credit{}
+	}
+
+	return &domain.Filmography{Person: *person, Credits: credits}, nil
+}
+
+func (s *personService) GetCredits(ctx context.Context, movieID uint) ([]domain.Credit, error) {
+	if err := s.checkMovie(ctx, movieID); err != nil {
+		return nil, err
+	}
+
+	result, err := s.credits.GetByMovie(ctx, movieID)
+	if err != nil {
+		return nil, fmt.Errorf("getting credits: %w", err)
+	}
+
+	return result, nil
+}
+
+func (s *personService) AddCredit(ctx context.Context, movieID uint, req domain.CreditRequest) (*domain.Credit, error) {
+	if err := s.checkMovie(ctx, movieID); err != nil {
+		return nil, err
+	}
+
+	credit := &domain.Credit{
+		MovieID:      movieID,
+		PersonID:     req.PersonID,
+		Department:   req.Department,
+		Job:          req.Job,
+		Character:    req.Character,
+		BillingOrder: req.BillingOrder,
+	}
+
+	if err := validateCredit(ctx, s.repo, credit); err != nil {
+		return nil, err
+	}
+
+	result, err := s.credits.Create(ctx, credit)
+	if err != nil {
+		return nil, fmt.Errorf("creating credit: %w", err)
+	}
+
+	return result, nil
+}
+
+func (s *personService) UpdateCredit(
+	ctx context.Context, movieID, creditID uint, req domain.UpdateCreditRequest,
+) (*domain.Credit, error) {
+	credit, err := s.getCredit(ctx, movieID, creditID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Department != nil {
+		credit.Department = *req.Department
+	}
+
+	if req.Job != nil {
+		credit.Job = *req.Job
+	}
+
+	if req.Character != nil {
+		credit.Character = *req.Character
+	}
+
+	if req.BillingOrder != nil {
+		credit.BillingOrder = *req.BillingOrder
+	}
+
+	if err := validateCredit(ctx, s.repo, credit); err != nil {
+		return nil, err
+	}
+
+	result, err := s.credits.Update(ctx, credit)
+	if err != nil {
+		return nil, fmt.Errorf("updating credit: %w", err)
+	}
+
+	return result, nil
+}
+
+func (s *personService) DeleteCredit(ctx context.Context, movieID, creditID uint) error {
+	if _, err := s.getCredit(ctx, movieID, creditID); err != nil {
+		return err
+	}
+
+	if err := s.credits.Delete(ctx, creditID); err != nil {
+		return fmt.Errorf("deleting credit: %w", err)
+	}
+
+	return nil
+}
+
+func (s *personService) checkMovie(ctx context.Context, movieID uint) error {
+	return checkMovieExists(ctx, s.movies, movieID)
+}
+
+// getCredit returns the credit, making sure it belongs to the given movie.
+func (s *personService) getCredit(ctx context.Context, movieID, creditID uint) (*domain.Credit, error) {
+	credit, err := s.credits.GetByID(ctx, creditID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && credit.MovieID != movieID) {
+		return nil, ErrCreditNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("getting credit by ID: %w", err)
+	}
+
+	return credit, nil
+}
+
+// validateCredit checks the credit's department and that its person exists.
+func validateCredit(ctx context.Context, people repository.PersonRepository, credit *domain.Credit) error {
+	if !slices.Contains(domain.CreditDepartments, credit.Department) {
+		return fmt.Errorf("%w: %q", ErrInvalidDepartment, credit.Department)
+	}
+
+	if _, err := people.GetByID(ctx, credit.PersonID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: %d", ErrPersonNotFound, credit.PersonID)
+		}
+
+		return fmt.Errorf("getting person by ID: %w", err)
+	}
+
+	return nil
+}
+
+func parseBirthDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	birthDate, err := time.Parse(domain.DateLayout, value)
+	if err != nil || birthDate.After(time.Now()) {
+		return nil, ErrInvalidBirthDate
+	}
+
+	return &birthDate, nil
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movie_app/internal/domain"
+	"movie_app/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRatingNotFound = errors.New("rating not found")
+)
+
+type RatingService interface {
+	Rate(ctx context.Context, userID, movieID uint, score float64) (*domain.MyRatingResponse, error)
+	GetMine(ctx context.Context, userID, movieID uint) (*domain.MyRatingResponse, error)
+	DeleteMine(ctx context.Context, userID, movieID uint) error
+	Summary(ctx context.Context, movieID uint) (*domain.RatingSummary, error)
+}
+
+type ratingService struct {
+	repo     repository.RatingRepository
+	movies   repository.MovieRepository
+	minVotes int
+}
+
+func NewRatingService(repo repository.RatingRepository, movies repository.MovieRepository, minVotes int) *ratingService {
+	return &ratingService{
+		repo:     repo,
+		movies:   movies,
+		minVotes: minVotes,
+	}
+}
+
+func (s *ratingService) Rate(ctx context.Context, userID, movieID uint, score float64) (*domain.MyRatingResponse, error) {
+	if err := validateRating(score); err != nil {
+		return nil, fmt.Errorf("validating rating: %w", err)
+	}
+
+	if err := s.checkMovie(ctx, movieID); err != nil {
+		return nil, err
+	}
+
+	rating, err := s.repo.Upsert(ctx, &domain.UserRating{
+		UserID:  userID,
+		MovieID: movieID,
+		Score:   score,
+	}, s.minVotes)
+	if err != nil {
+		return nil, fmt.Errorf("saving rating: %w", err)
+	}
+
+	summary, err := s.Summary(ctx, movieID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.MyRatingResponse{Rating: rating, Summary: summary}, nil
+}
+
+func (s *ratingService) GetMine(ctx context.Context, userID, movieID uint) (*domain.MyRatingResponse, error) {
+	if err := s.checkMovie(ctx, movieID); err != nil {
+		return nil, err
+	}
+
+	rating, err := s.repo.Get(ctx, userID, movieID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRatingNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("getting rating: %w", err)
+	}
+
+	summary, err := s.Summary(ctx, movieID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.MyRatingResponse{Rating: rating, Summary: summary}, nil
+}
+
+func (s *ratingService) DeleteMine(ctx context.Context, userID, movieID uint) error {
+	if err := s.checkMovie(ctx, movieID); err != nil {
+		return err
+	}
+
+	err := s.repo.Delete(ctx, userID, movieID, s.minVotes)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrRatingNotFound
+	}
+
+	if err != nil {
+		return fmt.Errorf("deleting rating: %w", err)
+	}
+
+	return nil
+}
+
+func (s *ratingService) Summary(ctx context.Context, movieID uint) (*domain.RatingSummary, error) {
+	result, err := s.repo.Summary(ctx, movieID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrMovieNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("getting rating summary: %w", err)
+	}
+
+	return result, nil
+}
+
+func (s *ratingService) checkMovie(ctx context.Context, movieID uint) error {
+	return checkMovieExists(ctx, s.movies, movieID)
+}
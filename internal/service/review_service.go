@@ -0,0 +1,329 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movie_app/internal/domain"
+	"movie_app/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrReviewNotFound      = errors.New("review not found")
+	ErrReviewExists        = errors.New("movie already reviewed by this user")
+	ErrReviewForbidden     = errors.New("review belongs to another user")
+	ErrOwnReviewVote       = errors.New("cannot vote on your own review")
+	ErrVoteNotFound        = errors.New("vote not found")
+	ErrInvalidReviewSort   = errors.New("sort must be helpful or recent")
+	ErrInvalidReviewStatus = errors.New("status must be pending, published or rejected")
+)
+
+type ReviewService interface {
+	Create(ctx context.Context, actor domain.Actor, movieID uint, req domain.CreateReviewRequest) (*domain.Review, error)
+	GetByID(ctx context.Context, actor domain.Actor, id uint) (*domain.Review, error)
+	ListByMovie(ctx context.Context, movieID uint, query domain.ReviewQuery) (*domain.ReviewPage, error)
+	ListForModeration(ctx context.Context, query domain.ReviewQuery) (*domain.ReviewPage, error)
+	Update(ctx context.Context, actor domain.Actor, id uint, req domain.UpdateReviewRequest) (*domain.Review, error)
+	Delete(ctx context.Context, actor domain.Actor, id uint) error
+	Vote(ctx context.Context, actor domain.Actor, id uint, helpful bool) (*domain.Review, error)
+	DeleteVote(ctx context.Context, actor domain.Actor, id uint) (*domain.Review, error)
+	Moderate(ctx context.Context, id uint, req domain.ModerateReviewRequest) (*domain.Review, error)
+}
+
+type reviewService struct {
+	repo            repository.ReviewRepository
+	movies          repository.MovieRepository
+	requireApproval bool
+}
+
+// NewReviewService creates the review service. When requireApproval is set,
+// new and edited reviews stay pending until a moderator publishes them.
+func NewReviewService(
+	repo repository.ReviewRepository, movies repository.MovieRepository, requireApproval bool,
+) *reviewService {
+	return &reviewService{
+		repo:            repo,
+		movies:          movies,
+		requireApproval: requireApproval,
+	}
+}
+
+func (s *reviewService) Create(
+	ctx context.Context, actor domain.Actor, movieID uint, req domain.CreateReviewRequest,
+) (*domain.Review, error) {
+	if err := checkMovieExists(ctx, s.movies, movieID); err != nil {
+		return nil, err
+	}
+
+	_, err := s.repo.GetByAuthor(ctx, movieID, actor.UserID)
+	if err == nil {
+		return nil, ErrReviewExists
+	}
+
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("checking existing review: %w", err)
+	}
+
+	review := &domain.Review{
+		MovieID: movieID,
+		UserID:  actor.UserID,
+		Title:   req.Title,
+		Body:    req.Body,
+		Spoiler: req.Spoiler,
+		Status:  s.submittedStatus(),
+	}
+
+	result, err := s.repo.Create(ctx, review)
+	if err != nil {
+		return nil, fmt.Errorf("creating review: %w", err)
+	}
+
+	return result, nil
+}
+
+// GetByID returns a published review, or an unpublished one to its author
+// and to moderators.
+func (s *reviewService) GetByID(ctx context.Context, actor domain.Actor, id uint) (*domain.Review, error) {
+	review, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := review.Status == domain.ReviewPublished || review.UserID == actor.UserID ||
+		actor.Can(domain.PermReviewsModerate)
+	if !visible {
+		return nil, ErrReviewNotFound
+	}
+
+	return review, nil
+}
+
+func (s *reviewService) ListByMovie(
+	ctx context.Context, movieID uint, query domain.ReviewQuery,
+) (*domain.ReviewPage, error) {
+	if err := checkMovieExists(ctx, s.movies, movieID); err != nil {
+		return nil, err
+	}
+
+	query.Status = domain.ReviewPublished
+
+	return s.list(ctx, movieID, query)
+}
+
+// ListForModeration returns the reviews of every movie in the query's
+// status, pending ones by default.
+func (s *reviewService) ListForModeration(ctx context.Context, query domain.ReviewQuery) (*domain.ReviewPage, error) {
+	if query.Status == "" {
+		query.Status = domain.ReviewPending
+	}
+
+	if !validReviewStatus(query.Status) {
+		return nil, ErrInvalidReviewStatus
+	}
+
+	if query.Sort == "" {
+		query.Sort = domain.ReviewSortRecent
+	}
+
+	return s.list(ctx, 0, query)
+}
+
+func (s *reviewService) Update(
+	ctx context.Context, actor domain.Actor, id uint, req domain.UpdateReviewRequest,
+) (*domain.Review, error) {
+	review, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if review.UserID != actor.UserID {
+		return nil, ErrReviewForbidden
+	}
+
+	if req.Title != nil {
+		review.Title = *req.Title
+	}
+
+	if req.Body != nil {
+		review.Body = *req.Body
+	}
+
+	if req.Spoiler != nil {
+		review.Spoiler = *req.Spoiler
+	}
+
+	// Edited text has to be moderated again. A rejected review goes back to
+	// the moderators even without approval required, so that editing it
+	// cannot undo the rejection.
+	if review.Status == domain.ReviewRejected {
+		review.Status = domain.ReviewPending
+	} else {
+		review.Status = s.submittedStatus()
+	}
+
+	review.ModerationNote = ""
+
+	result, err := s.repo.Update(ctx, review)
+	if err != nil {
+		return nil, fmt.Errorf("updating review: %w", err)
+	}
+
+	return result, nil
+}
+
+func (s *reviewService) Delete(ctx context.Context, actor domain.Actor, id uint) error {
+	review, err := s.get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if review.UserID != actor.UserID && !actor.Can(domain.PermReviewsModerate) {
+		return ErrReviewForbidden
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("deleting review: %w", err)
+	}
+
+	return nil
+}
+
+func (s *reviewService) Vote(ctx context.Context, actor domain.Actor, id uint, helpful bool) (*domain.Review, error) {
+	if err := s.checkVotable(ctx, actor, id); err != nil {
+		return nil, err
+	}
+
+	err := s.repo.Vote(ctx, &domain.ReviewVote{
+		ReviewID: id,
+		UserID:   actor.UserID,
+		Helpful:  helpful,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("voting on review: %w", err)
+	}
+
+	return s.get(ctx, id)
+}
+
+func (s *reviewService) DeleteVote(ctx context.Context, actor domain.Actor, id uint) (*domain.Review, error) {
+	if err := s.checkVotable(ctx, actor, id); err != nil {
+		return nil, err
+	}
+
+	err := s.repo.DeleteVote(ctx, id, actor.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrVoteNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("deleting vote: %w", err)
+	}
+
+	return s.get(ctx, id)
+}
+
+func (s *reviewService) Moderate(
+	ctx context.Context, id uint, req domain.ModerateReviewRequest,
+) (*domain.Review, error) {
+	if !validReviewStatus(req.Status) {
+		return nil, ErrInvalidReviewStatus
+	}
+
+	review, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	review.Status = req.Status
+	review.ModerationNote = req.Note
+
+	result, err := s.repo.Update(ctx, review)
+	if err != nil {
+		return nil, fmt.Errorf("moderating review: %w", err)
+	}
+
+	return result, nil
+}
+
+func (s *reviewService) get(ctx context.Context, id uint) (*domain.Review, error) {
+	review, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrReviewNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("getting review: %w", err)
+	}
+
+	return review, nil
+}
+
+func (s *reviewService) list(ctx context.Context, movieID uint, query domain.ReviewQuery) (*domain.ReviewPage, error) {
+	if query.Page == 0 {
+		query.Page = 1
+	}
+
+	if query.Page < 0 || query.Page > MaxPage {
+		return nil, ErrInvalidPage
+	}
+
+	if query.Limit == 0 {
+		query.Limit = DefaultPageSize
+	}
+
+	if query.Limit < 0 || query.Limit > MaxPageSize {
+		return nil, ErrInvalidLimit
+	}
+
+	if query.Sort == "" {
+		query.Sort = domain.ReviewSortHelpful
+	}
+
+	if query.Sort != domain.ReviewSortHelpful && query.Sort != domain.ReviewSortRecent {
+		return nil, ErrInvalidReviewSort
+	}
+
+	result, err := s.repo.List(ctx, movieID, query)
+	if err != nil {
+		return nil, fmt.Errorf("listing reviews: %w", err)
+	}
+
+	return result, nil
+}
+
+// checkVotable only allows votes on published reviews of other users.
+func (s *reviewService) checkVotable(ctx context.Context, actor domain.Actor, id uint) error {
+	review, err := s.get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if review.Status != domain.ReviewPublished {
+		return ErrReviewNotFound
+	}
+
+	if review.UserID == actor.UserID {
+		return ErrOwnReviewVote
+	}
+
+	return nil
+}
+
+func (s *reviewService) submittedStatus() domain.ReviewStatus {
+	if s.requireApproval {
+		return domain.ReviewPending
+	}
+
+	return domain.ReviewPublished
+}
+
+func validReviewStatus(status domain.ReviewStatus) bool {
+	switch status {
+	case domain.ReviewPending, domain.ReviewPublished, domain.ReviewRejected:
+		return true
+	default:
+		return false
+	}
+}
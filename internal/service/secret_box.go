@@ -0,0 +1,85 @@
+package service
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+)
+
+// secretBox encrypts secrets stored in the database, such as private keys,
+// with AES-256-GCM under a key derived from a configured secret. Secrets
+// encrypted under the previous secret, if any, can still be opened while the
+// secret is being replaced.
+type secretBox struct {
+	secret   string
+	previous string
+}
+
+func newSecretBox(secret, previous string) secretBox {
+	return secretBox{secret: secret, previous: previous}
+}
+
+// seal encrypts the data, prefixing the nonce.
+func (b secretBox) seal(data []byte) ([]byte, error) {
+	gcm, err := cipherFor(b.secret)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generating nonce: %w", err)
+	}
+
+	return gcm.Seal(nonce, nonce, data, nil), nil
+}
+
+func (b secretBox) open(data []byte) ([]byte, error) {
+	opened, err := openWith(b.secret, data)
+	if err != nil && b.previous != "" {
+		if previous, previousErr := openWith(b.previous, data); previousErr == nil {
+			return previous, nil
+		}
+	}
+
+	return opened, err
+}
+
+func openWith(secret string, data []byte) ([]byte, error) {
+	gcm, err := cipherFor(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("encrypted secret is truncated")
+	}
+
+	nonce, sealed := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+
+	opened, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting secret: %w", err)
+	}
+
+	return opened, nil
+}
+
+func cipherFor(secret string) (cipher.AEAD, error) {
+	key := sha256.Sum256([]byte(secret))
+
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("creating cipher: %w", err)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("creating cipher: %w", err)
+	}
+
+	return gcm, nil
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"movie_app/internal/repository"
+	"sync"
+	"time"
+)
+
+// denylistSyncOverlap widens every sync window so that revocations committed
+// while the previous sync ran, or stamped by a clock running slightly behind,
+// are not missed.
+const denylistSyncOverlap = time.Minute
+
+// SessionDenylist keeps the revoked sessions whose access tokens may still be
+// unexpired in memory, so that authenticating a request does not need a
+// database query. Revocations made by this instance take effect immediately;
+// those made by other instances once Sync picks them up.
+type SessionDenylist struct {
+	repo      repository.SessionRepository
+	accessTTL time.Duration
+
+	mu       sync.RWMutex
+	denied   map[uint]time.Time // Session ID to the time its last access token expires
+	syncedAt time.Time
+}
+
+func NewSessionDenylist(repo repository.SessionRepository, accessTTL time.Duration) *SessionDenylist {
+	return &SessionDenylist{
+		repo:      repo,
+		accessTTL: accessTTL,
+		denied:    make(map[uint]time.Time),
+	}
+}
+
+// Deny rejects the session's access tokens from now on.
+func (d *SessionDenylist) Deny(sessionID uint, revokedAt time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.denied[sessionID] = revokedAt.Add(d.accessTTL)
+}
+
+// Denied reports whether the session was revoked.
+func (d *SessionDenylist) Denied(sessionID uint) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	_, denied := d.denied[sessionID]
+
+	return denied
+}
+
+// Sync loads the sessions revoked since the previous sync and forgets those
+// whose access tokens have all expired. The first sync loads every session
+// revoked within the access token lifetime.
+func (d *SessionDenylist) Sync(ctx context.Context) error {
+	now := time.Now()
+
+	d.mu.RLock()
+	since := d.syncedAt.Add(-denylistSyncOverlap)
+	d.mu.RUnlock()
+
+	if oldest := now.Add(-d.accessTTL); since.Before(oldest) {
+		since = oldest
+	}
+
+	sessions, err := d.repo.ListRevokedSince(ctx, since)
+	if err != nil {
+		return fmt.Errorf("listing revoked sessions: %w", err)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, session := range sessions {
+		d.denied[session.ID] = session.RevokedAt.Add(d.accessTTL)
+	}
+
+	for id, until := range d.denied {
+		if !until.After(now) {
+			delete(d.denied, id)
+		}
+	}
+
+	d.syncedAt = now
+
+	return nil
+}
+
+// Run syncs the denylist every interval until the context is done.
+func (d *SessionDenylist) Run(ctx context.Context, interval time.Duration) {
+	runPeriodically(ctx, interval, "sync session denylist", d.Sync)
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movie_app/internal/domain"
+	"movie_app/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+type SessionService interface {
+	ListSessions(ctx context.Context, userID, currentID uint) ([]domain.Session, error)
+	RevokeSession(ctx context.Context, userID, id uint) error
+	RevokeAllSessions(ctx context.Context, userID uint) error
+	RevokeOtherSessions(ctx context.Context, userID, currentID uint) error
+}
+
+type sessionService struct {
+	repo     repository.SessionRepository
+	denylist *SessionDenylist
+}
+
+func NewSessionService(repo repository.SessionRepository, denylist *SessionDenylist) *sessionService {
+	return &sessionService{
+		repo:     repo,
+		denylist: denylist,
+	}
+}
+
+// ListSessions returns the user's active sessions, flagging the one the
+// request was made with.
+func (s *sessionService) ListSessions(ctx context.Context, userID, currentID uint) ([]domain.Session, error) {
+	sessions, err := s.repo.ListActive(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("listing sessions: %w", err)
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentID
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession ends one of the user's sessions: its refresh tokens stop
+// working and its access tokens are rejected right away.
+func (s *sessionService) RevokeSession(ctx context.Context, userID, id uint) error {
+	session, err := s.repo.Revoke(ctx, userID, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrSessionNotFound
+	}
+
+	if err != nil {
+		return fmt.Errorf("revoking session: %w", err)
+	}
+
+	s.denylist.Deny(session.ID, *session.RevokedAt)
+
+	return nil
+}
+
+// RevokeAllSessions signs the user out everywhere.
+func (s *sessionService) RevokeAllSessions(ctx context.Context, userID uint) error {
+	return s.revokeAll(ctx, userID, 0)
+}
+
+// RevokeOtherSessions signs the user out everywhere but the current session.
+func (s *sessionService) RevokeOtherSessions(ctx context.Context, userID, currentID uint) error {
+	return s.revokeAll(ctx, userID, currentID)
+}
+
+func (s *sessionService) revokeAll(ctx context.Context, userID, exceptID uint) error {
+	sessions, err := s.repo.RevokeAll(ctx, userID, exceptID)
+	if err != nil {
+		return fmt.Errorf("revoking sessions: %w", err)
+	}
+
+	for _, session := range sessions {
+		s.denylist.Deny(session.ID, *session.RevokedAt)
+	}
+
+	return nil
+}
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"movie_app/internal/domain"
+	"movie_app/internal/repository"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// mfaChallengeTTL is how long a user has to enter their second factor after
+// their password.
+const mfaChallengeTTL = 5 * time.Minute
+
+var (
+	ErrInvalidMFAToken     = errors.New("invalid or expired MFA token")
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected, all sessions of this login were revoked")
+)
+
+// TokenService issues access tokens along with the refresh tokens that renew them.
+type TokenService interface {
+	Issue(ctx context.Context, user *domain.User, client domain.ClientInfo) (*domain.LoginResponse, error)
+	Refresh(ctx context.Context, refreshToken string, client domain.ClientInfo) (*domain.LoginResponse, error)
+	IssueMFAChallenge(user *domain.User, device string) (*domain.LoginResponse, error)
+	ParseMFAChallenge(mfaToken string) (*domain.MFAChallenge, error)
+}
+
+type TokenOptions struct {
+	Issuer     string
+	Audience   string
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+}
+
+type tokenService struct {
+	sessions repository.SessionRepository
+	users    repository.UserRepository
+	denylist *SessionDenylist
+	keys     *KeySet
+	options  TokenOptions
+}
+
+func NewTokenService(
+	sessions repository.SessionRepository,
+	users repository.UserRepository,
+	denylist *SessionDenylist,
+	keys *KeySet,
+	options TokenOptions,
+) *tokenService {
+	return &tokenService{
+		sessions: sessions,
+		users:    users,
+		denylist: denylist,
+		keys:     keys,
+		options:  options,
+	}
+}
+
+// Issue starts a new session for a fresh login.
+func (s *tokenService) Issue(
+	ctx context.Context, user *domain.User, client domain.ClientInfo,
+) (*domain.LoginResponse, error) {
+	familyID, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, record, err := s.newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session := &domain.Session{
+		UserID:     user.ID,
+		FamilyID:   familyID,
+		Device:     client.Device,
+		UserAgent:  client.UserAgent,
+		IPAddress:  client.IPAddress,
+		LastSeenAt: time.Now(),
+		ExpiresAt:  record.ExpiresAt,
+	}
+
+	if err := s.sessions.Create(ctx, session, record); err != nil {
+		return nil, fmt.Errorf("creating session: %w", err)
+	}
+
+	return s.respond(user, session, refreshToken)
+}
+
+// Refresh rotates the refresh token and issues a new access token carrying
+// the user's current role.
+func (s *tokenService) Refresh(
+	ctx context.Context, refreshToken string, client domain.ClientInfo,
+) (*domain.LoginResponse, error) {
+	nextToken, next, err := s.newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := s.sessions.Rotate(ctx, hashToken(refreshToken), next, client)
+	if errors.Is(err, repository.ErrRefreshTokenInvalid) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if errors.Is(err, repository.ErrRefreshTokenReused) {
+		s.denylist.Deny(session.ID, *session.RevokedAt)
+
+		return nil, ErrRefreshTokenReused
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("rotating refresh token: %w", err)
+	}
+
+	user, err := s.users.GetByID(ctx, session.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("getting user by ID: %w", err)
+	}
+
+	return s.respond(user, session, nextToken)
+}
+
+func (s *tokenService) respond(
+	user *domain.User, session *domain.Session, refreshToken string,
+) (*domain.LoginResponse, error) {
+	tokenID, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(s.options.AccessTTL)
+
+	tokenString, err := s.keys.Sign(jwt.MapClaims{
+		"iss":            s.options.Issuer,
+		"aud":            s.options.Audience,
+		"jti":            tokenID,
+		"sid":            session.ID,
+		"user_id":        user.ID,
+		"role":           user.Role,
+		"email_verified": user.EmailVerified(),
+		"iat":            time.Now().Unix(),
+		"exp":            expiresAt.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.LoginResponse{
+		Token:          tokenString,
+		TokenExpiresAt: &expiresAt,
+		RefreshToken:   refreshToken,
+		User:           user,
+	}, nil
+}
+
+// IssueMFAChallenge returns the MFA token that stands for a login whose
+// password was checked. It is signed for a different audience than access
+// tokens, so it cannot be used as one.
+func (s *tokenService) IssueMFAChallenge(user *domain.User, device string) (*domain.LoginResponse, error) {
+	expiresAt := time.Now().Add(mfaChallengeTTL)
+
+	mfaToken, err := s.keys.Sign(jwt.MapClaims{
+		"iss":     s.options.Issuer,
+		"aud":     s.mfaAudience(),
+		"user_id": user.ID,
+		"device":  device,
+		"iat":     time.Now().Unix(),
+		"exp":     expiresAt.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.LoginResponse{
+		MFARequired:       true,
+		MFAToken:          mfaToken,
+		MFATokenExpiresAt: &expiresAt,
+	}, nil
+}
+
+func (s *tokenService) ParseMFAChallenge(mfaToken string) (*domain.MFAChallenge, error) {
+	token, err := jwt.Parse(
+		mfaToken,
+		s.keys.VerificationKey,
+		jwt.WithValidMethods(SigningAlgorithms),
+		jwt.WithIssuer(s.options.Issuer),
+		jwt.WithAudience(s.mfaAudience()),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidMFAToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, ErrInvalidMFAToken
+	}
+
+	userID, ok := claims["user_id"].(float64)
+	if !ok {
+		return nil, ErrInvalidMFAToken
+	}
+
+	device, _ := claims["device"].(string)
+
+	return &domain.MFAChallenge{UserID: uint(userID), Device: device}, nil
+}
+
+func (s *tokenService) mfaAudience() string {
+	return s.options.Audience + ":mfa"
+}
+
+// newRefreshToken returns a new refresh token along with the record to store
+// for it, which holds only its hash.
+func (s *tokenService) newRefreshToken() (string, *domain.RefreshToken, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return token, &domain.RefreshToken{
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(s.options.RefreshTTL),
+	}, nil
+}
+
+// randomToken returns a URL-safe random string of the given number of bytes.
+func randomToken(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generating token: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashToken returns the hex SHA-256 of a token. Tokens are random enough
+// that a fast unsalted hash is sufficient.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -4,48 +4,83 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
 	"time"
 
-	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 
 	"movie_app/internal/domain"
 	"movie_app/internal/repository"
 )
 
-const (
-	TokenExpirationHours = 24
-)
-
 var (
-	ErrUserExists          = errors.New("user already exists")
-	ErrInvalidCredentials  = errors.New("invalid credentials")
+	ErrUserExists         = errors.New("user already exists")
+	ErrInvalidCredentials = errors.New("invalid credentials")
 	ErrUserNotFound       = errors.New("user not found")
 	ErrInvalidToken       = errors.New("invalid token")
 	ErrPasswordHashFailed = errors.New("failed to hash password")
+	ErrLastAdmin          = errors.New("cannot remove the role of the last admin")
+	ErrInvalidRole        = errors.New("role must be admin, editor or viewer")
+	ErrEmailTaken         = errors.New("email is already in use")
+	ErrEmailUnchanged     = errors.New("email is the same as the current one")
+	ErrWrongPassword      = errors.New("current password is incorrect")
 )
 
 type UserService interface {
 	Register(ctx context.Context, req domain.RegisterRequest) (*domain.User, error)
-	Login(ctx context.Context, req domain.LoginRequest) (*domain.LoginResponse, error)
+	Login(ctx context.Context, req domain.LoginRequest, client domain.ClientInfo) (*domain.LoginResponse, error)
+	VerifyMFA(ctx context.Context, req domain.MFAVerifyRequest, client domain.ClientInfo) (*domain.LoginResponse, error)
+	Refresh(ctx context.Context, req domain.RefreshRequest, client domain.ClientInfo) (*domain.LoginResponse, error)
 	GetUser(ctx context.Context, id uint) (*domain.User, error)
+	UpdateProfile(ctx context.Context, id uint, req domain.UpdateProfileRequest) (*domain.User, error)
+	ChangePassword(
+		ctx context.Context, id, sessionID uint, req domain.ChangePasswordRequest, client domain.ClientInfo,
+	) error
+	ChangeEmail(ctx context.Context, id uint, req domain.ChangeEmailRequest, client domain.ClientInfo) error
+	DeleteAccount(ctx context.Context, id uint, req domain.DeleteAccountRequest, client domain.ClientInfo) error
+	ListUsers(ctx context.Context, query domain.UserQuery) (*domain.UserPage, error)
+	UpdateRole(ctx context.Context, id uint, role domain.Role) (*domain.User, error)
+	UnlockUser(ctx context.Context, actorID, id uint) error
 }
 
 type userService struct {
-	repo      repository.UserRepository
-	jwtSecret string
+	repo         repository.UserRepository
+	tokens       TokenService
+	sessions     SessionService
+	verification VerificationService
+	mfa          MFAService
+	throttle     LoginThrottle
+	policy       domain.VerificationPolicy
 }
 
-func NewUserService(repo repository.UserRepository, jwtSecret string) *userService {
+func NewUserService(
+	repo repository.UserRepository,
+	tokens TokenService,
+	sessions SessionService,
+	verification VerificationService,
+	mfa MFAService,
+	throttle LoginThrottle,
+	policy domain.VerificationPolicy,
+) *userService {
 	return &userService{
-		repo:      repo,
-		jwtSecret: jwtSecret,
+		repo:         repo,
+		tokens:       tokens,
+		sessions:     sessions,
+		verification: verification,
+		mfa:          mfa,
+		throttle:     throttle,
+		policy:       policy,
 	}
 }
 
 func (s *userService) Register(ctx context.Context, req domain.RegisterRequest) (*domain.User, error) {
-	existing, _ := s.repo.GetByEmail(ctx, req.Email)
-	if existing != nil {
+	taken, err := s.repo.EmailTaken(ctx, req.Email)
+	if err != nil {
+		return nil, fmt.Errorf("checking email: %w", err)
+	}
+
+	if taken {
 		return nil, ErrUserExists
 	}
 
@@ -58,6 +93,7 @@ func (s *userService) Register(ctx context.Context, req domain.RegisterRequest)
 	user := &domain.User{
 		Email:     req.Email,
 		Password:  string(hashedPassword),
+		Role:      domain.RoleViewer,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
@@ -67,34 +103,134 @@ func (s *userService) Register(ctx context.Context, req domain.RegisterRequest)
 		return nil, fmt.Errorf("creating user: %w", err)
 	}
 
+	// The account exists either way, and the user can ask for another email
+	if err := s.verification.SendVerification(ctx, result); err != nil {
+		log.Printf("Failed to send verification email to user %d: %v", result.ID, err)
+	}
+
 	return result, nil
 }
 
-func (s *userService) Login(ctx context.Context, req domain.LoginRequest) (*domain.LoginResponse, error) {
+// dummyPasswordHash is a bcrypt hash at bcrypt.DefaultCost that Login checks
+// passwords against when the email is not registered, so that the response
+// time does not reveal which emails are.
+const dummyPasswordHash = "$2a$10$Pnbo6vNpC1vjJ1qoakIu7OdzChNNtLOi3vh1vLsIZYE17KjRhrRue"
+
+func (s *userService) Login(
+	ctx context.Context, req domain.LoginRequest, client domain.ClientInfo,
+) (*domain.LoginResponse, error) {
+	if err := s.throttle.Check(ctx, req.Email, client.IPAddress); err != nil {
+		return nil, err
+	}
+
 	user, err := s.repo.GetByEmail(ctx, req.Email)
 	if err != nil {
-		return nil, ErrInvalidCredentials
+		_ = bcrypt.CompareHashAndPassword([]byte(dummyPasswordHash), []byte(req.Password))
+
+		return nil, s.loginFailed(ctx, req.Email, client.IPAddress, nil)
 	}
 
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password))
 	if err != nil {
-		return nil, fmt.Errorf("checking password: %w", ErrInvalidCredentials)
+		return nil, fmt.Errorf("checking password: %w", s.loginFailed(ctx, req.Email, client.IPAddress, &user.ID))
+	}
+
+	if s.policy == domain.VerifyForLogin && !user.EmailVerified() {
+		return nil, ErrEmailNotVerified
+	}
+
+	mfaEnabled, err := s.mfa.Enabled(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("checking two-factor authentication: %w", err)
+	}
+
+	// Failed logins are only forgotten once tokens are issued, so that
+	// logging in again cannot reset the wrong codes counted by VerifyMFA
+	if mfaEnabled {
+		result, err := s.tokens.IssueMFAChallenge(user, client.Device)
+		if err != nil {
+			return nil, fmt.Errorf("issuing MFA token: %w", err)
+		}
+
+		return result, nil
+	}
+
+	result, err := s.tokens.Issue(ctx, user, client)
+	if err != nil {
+		return nil, fmt.Errorf("issuing tokens: %w", err)
+	}
+
+	if err := s.throttle.RecordSuccess(ctx, req.Email); err != nil {
+		return nil, err
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": user.ID,
-		"exp":     time.Now().Add(TokenExpirationHours * time.Hour).Unix(),
-	})
+	return result, nil
+}
 
-	tokenString, err := token.SignedString([]byte(s.jwtSecret))
+// VerifyMFA completes a login of a user with two-factor authentication,
+// exchanging the MFA token from Login and a code for the tokens.
+func (s *userService) VerifyMFA(
+	ctx context.Context, req domain.MFAVerifyRequest, client domain.ClientInfo,
+) (*domain.LoginResponse, error) {
+	challenge, err := s.tokens.ParseMFAChallenge(req.MFAToken)
 	if err != nil {
-		return nil, fmt.Errorf("signing token: %w", err)
+		return nil, err
+	}
+
+	user, err := s.repo.GetByID(ctx, challenge.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("getting user by ID: %w", err)
+	}
+
+	// Codes are guessed far more easily than passwords, so wrong ones count
+	// towards the same lockout
+	if err := s.throttle.Check(ctx, user.Email, client.IPAddress); err != nil {
+		return nil, err
 	}
 
-	return &domain.LoginResponse{
-		Token: tokenString,
-		User:  user,
-	}, nil
+	if err := s.mfa.VerifyCode(ctx, user.ID, req.Code); err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			if err := s.throttle.RecordFailure(ctx, user.Email, client.IPAddress, &user.ID); err != nil {
+				return nil, err
+			}
+		}
+
+		return nil, fmt.Errorf("verifying code: %w", err)
+	}
+
+	client.Device = challenge.Device
+
+	result, err := s.tokens.Issue(ctx, user, client)
+	if err != nil {
+		return nil, fmt.Errorf("issuing tokens: %w", err)
+	}
+
+	if err := s.throttle.RecordSuccess(ctx, user.Email); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// loginFailed records the failed login and returns ErrInvalidCredentials, or
+// the error recording it.
+func (s *userService) loginFailed(ctx context.Context, email, ip string, userID *uint) error {
+	if err := s.throttle.RecordFailure(ctx, email, ip, userID); err != nil {
+		return err
+	}
+
+	return ErrInvalidCredentials
+}
+
+func (s *userService) Refresh(
+	ctx context.Context, req domain.RefreshRequest, client domain.ClientInfo,
+) (*domain.LoginResponse, error) {
+	result, err := s.tokens.Refresh(ctx, req.RefreshToken, client)
+	if err != nil {
+		return nil, fmt.Errorf("refreshing tokens: %w", err)
+	}
+
+	return result, nil
 }
 
 func (s *userService) GetUser(ctx context.Context, id uint) (*domain.User, error) {
@@ -105,3 +241,239 @@ func (s *userService) GetUser(ctx context.Context, id uint) (*domain.User, error
 
 	return result, nil
 }
+
+func (s *userService) UpdateProfile(
+	ctx context.Context, id uint, req domain.UpdateProfileRequest,
+) (*domain.User, error) {
+	user, err := s.getUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.DisplayName != nil {
+		user.DisplayName = *req.DisplayName
+	}
+
+	if req.AvatarURL != nil {
+		user.AvatarURL = *req.AvatarURL
+	}
+
+	if req.Preferences != nil {
+		user.Preferences = *req.Preferences
+	}
+
+	user.UpdatedAt = time.Now()
+
+	if err := s.repo.UpdateProfile(ctx, user); err != nil {
+		return nil, fmt.Errorf("updating profile: %w", err)
+	}
+
+	return user, nil
+}
+
+// ChangePassword replaces the user's password, given the current one, and
+// signs them out of every session but the current one.
+func (s *userService) ChangePassword(
+	ctx context.Context, id, sessionID uint, req domain.ChangePasswordRequest, client domain.ClientInfo,
+) error {
+	user, err := s.getUser(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.checkPassword(ctx, user, req.CurrentPassword, client); err != nil {
+		return err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("hashing password: %w", err)
+	}
+
+	if err := s.repo.UpdatePassword(ctx, id, string(hashedPassword)); err != nil {
+		return fmt.Errorf("updating password: %w", err)
+	}
+
+	if err := s.sessions.RevokeOtherSessions(ctx, id, sessionID); err != nil {
+		return fmt.Errorf("revoking other sessions: %w", err)
+	}
+
+	return nil
+}
+
+// ChangeEmail emails a verification link to the new address, given the
+// user's password. The email changes once the link is followed.
+func (s *userService) ChangeEmail(
+	ctx context.Context, id uint, req domain.ChangeEmailRequest, client domain.ClientInfo,
+) error {
+	user, err := s.getUser(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.checkPassword(ctx, user, req.Password, client); err != nil {
+		return err
+	}
+
+	if req.Email == user.Email {
+		return ErrEmailUnchanged
+	}
+
+	taken, err := s.repo.EmailTaken(ctx, req.Email)
+	if err != nil {
+		return fmt.Errorf("checking email: %w", err)
+	}
+
+	if taken {
+		return ErrEmailTaken
+	}
+
+	if err := s.verification.SendEmailChange(ctx, user, req.Email); err != nil {
+		return fmt.Errorf("sending email change verification: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteAccount deletes the user's account, given their password, and signs
+// them out everywhere. Their data is purged after the deletion grace period.
+// The last admin cannot delete their account.
+func (s *userService) DeleteAccount(
+	ctx context.Context, id uint, req domain.DeleteAccountRequest, client domain.ClientInfo,
+) error {
+	user, err := s.getUser(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.checkPassword(ctx, user, req.Password, client); err != nil {
+		return err
+	}
+
+	err = s.repo.Delete(ctx, id)
+	if errors.Is(err, repository.ErrLastAdmin) {
+		return ErrLastAdmin
+	}
+
+	if err != nil {
+		return fmt.Errorf("deleting user: %w", err)
+	}
+
+	if err := s.sessions.RevokeAllSessions(ctx, id); err != nil {
+		return fmt.Errorf("revoking sessions: %w", err)
+	}
+
+	return nil
+}
+
+// checkPassword returns ErrWrongPassword unless the password is the user's.
+// Wrong passwords count as failed logins, so that a stolen access token
+// cannot be used to guess the password either.
+func (s *userService) checkPassword(
+	ctx context.Context, user *domain.User, password string, client domain.ClientInfo,
+) error {
+	if err := s.throttle.Check(ctx, user.Email, client.IPAddress); err != nil {
+		return err
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
+		if err := s.throttle.RecordFailure(ctx, user.Email, client.IPAddress, &user.ID); err != nil {
+			return err
+		}
+
+		return ErrWrongPassword
+	}
+
+	return nil
+}
+
+func (s *userService) getUser(ctx context.Context, id uint) (*domain.User, error) {
+	user, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUserNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("getting user by ID: %w", err)
+	}
+
+	return user, nil
+}
+
+func (s *userService) ListUsers(ctx context.Context, query domain.UserQuery) (*domain.UserPage, error) {
+	if query.Page == 0 {
+		query.Page = 1
+	}
+
+	if query.Page < 0 || query.Page > MaxPage {
+		return nil, ErrInvalidPage
+	}
+
+	if query.Limit == 0 {
+		query.Limit = DefaultPageSize
+	}
+
+	if query.Limit < 0 || query.Limit > MaxPageSize {
+		return nil, ErrInvalidLimit
+	}
+
+	if query.Role != "" && !query.Role.Valid() {
+		return nil, ErrInvalidRole
+	}
+
+	result, err := s.repo.List(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("listing users: %w", err)
+	}
+
+	return result, nil
+}
+
+// UpdateRole assigns the role to the user. The role takes effect on the
+// user's next login or token refresh, and the last admin cannot be demoted.
+func (s *userService) UpdateRole(ctx context.Context, id uint, role domain.Role) (*domain.User, error) {
+	if !role.Valid() {
+		return nil, ErrInvalidRole
+	}
+
+	user, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUserNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("getting user by ID: %w", err)
+	}
+
+	err = s.repo.UpdateRole(ctx, id, role)
+	if errors.Is(err, repository.ErrLastAdmin) {
+		return nil, ErrLastAdmin
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("updating role: %w", err)
+	}
+
+	user.Role = role
+
+	return user, nil
+}
+
+// UnlockUser lifts the lockout of the user's account after too many failed
+// logins. Lockouts of IP addresses expire on their own.
+func (s *userService) UnlockUser(ctx context.Context, actorID, id uint) error {
+	user, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrUserNotFound
+	}
+
+	if err != nil {
+		return fmt.Errorf("getting user by ID: %w", err)
+	}
+
+	if err := s.throttle.Unlock(ctx, user, actorID); err != nil {
+		return fmt.Errorf("unlocking user: %w", err)
+	}
+
+	return nil
+}
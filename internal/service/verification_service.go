@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movie_app/internal/domain"
+	"movie_app/internal/mail"
+	"movie_app/internal/repository"
+	"net/url"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidVerificationToken = errors.New("email verification token is invalid or has expired")
+	ErrEmailNotVerified         = errors.New("email address has not been verified")
+)
+
+type VerificationService interface {
+	SendVerification(ctx context.Context, user *domain.User) error
+	SendEmailChange(ctx context.Context, user *domain.User, email string) error
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerification(ctx context.Context, req domain.ResendVerificationRequest) error
+}
+
+type VerificationOptions struct {
+	TTL            time.Duration
+	ResendInterval time.Duration // Minimum time between two verification emails to a user
+	AppURL         string        // Base URL of the web app page links point to
+}
+
+type verificationService struct {
+	repo      repository.EmailVerificationRepository
+	users     repository.UserRepository
+	mailer    mail.Mailer
+	templates *mail.Templates
+	options   VerificationOptions
+}
+
+func NewVerificationService(
+	repo repository.EmailVerificationRepository,
+	users repository.UserRepository,
+	mailer mail.Mailer,
+	templates *mail.Templates,
+	options VerificationOptions,
+) *verificationService {
+	return &verificationService{
+		repo:      repo,
+		users:     users,
+		mailer:    mailer,
+		templates: templates,
+		options:   options,
+	}
+}
+
+// SendVerification emails the user a link that verifies their current email.
+func (s *verificationService) SendVerification(ctx context.Context, user *domain.User) error {
+	return s.send(ctx, user, user.Email, false)
+}
+
+// SendEmailChange emails a link to the new address which, once followed,
+// makes it the user's verified email. Until then the current email stays.
+func (s *verificationService) SendEmailChange(ctx context.Context, user *domain.User, email string) error {
+	return s.send(ctx, user, email, true)
+}
+
+func (s *verificationService) send(ctx context.Context, user *domain.User, email string, change bool) error {
+	token, err := randomToken(32)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.Create(ctx, &domain.EmailVerificationToken{
+		UserID:      user.ID,
+		Email:       email,
+		EmailChange: change,
+		TokenHash:   hashToken(token),
+		ExpiresAt:   time.Now().Add(s.options.TTL),
+	})
+	if err != nil {
+		return fmt.Errorf("storing email verification token: %w", err)
+	}
+
+	template := "email_verification"
+	if change {
+		template = "email_change"
+	}
+
+	message, err := s.templates.Render(template, email, map[string]any{
+		"Link":      s.options.AppURL + "/verify-email?token=" + url.QueryEscape(token),
+		"ExpiresIn": formatDuration(s.options.TTL),
+	})
+	if err != nil {
+		return fmt.Errorf("rendering email verification email: %w", err)
+	}
+
+	sendInBackground(s.mailer, message)
+
+	return nil
+}
+
+func (s *verificationService) VerifyEmail(ctx context.Context, token string) error {
+	_, err := s.repo.Verify(ctx, hashToken(token))
+	if errors.Is(err, repository.ErrEmailVerificationInvalid) {
+		return ErrInvalidVerificationToken
+	}
+
+	if errors.Is(err, repository.ErrEmailTaken) {
+		return ErrEmailTaken
+	}
+
+	if err != nil {
+		return fmt.Errorf("verifying email: %w", err)
+	}
+
+	return nil
+}
+
+// ResendVerification sends a new verification link unless the email is not
+// registered, is already verified or was sent one within the resend
+// interval. It succeeds in every case, so that the response does not tell
+// whether the email is registered.
+func (s *verificationService) ResendVerification(
+	ctx context.Context, req domain.ResendVerificationRequest,
+) error {
+	user, err := s.users.GetByEmail(ctx, req.Email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("getting user by email: %w", err)
+	}
+
+	if user.EmailVerified() {
+		return nil
+	}
+
+	lastSentAt, err := s.repo.LastCreatedAt(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("getting last verification email: %w", err)
+	}
+
+	if lastSentAt != nil && time.Since(*lastSentAt) < s.options.ResendInterval {
+		return nil
+	}
+
+	return s.SendVerification(ctx, user)
+}
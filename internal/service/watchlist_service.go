@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"movie_app/internal/domain"
+	"movie_app/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAlreadyInWatchlist = errors.New("movie already in watchlist")
+	ErrNotInWatchlist     = errors.New("movie not in watchlist")
+)
+
+type WatchlistService interface {
+	Add(ctx context.Context, userID, movieID uint) (*domain.WatchlistEntry, error)
+	List(ctx context.Context, userID uint, query domain.WatchlistQuery) (*domain.WatchlistPage, error)
+	Remove(ctx context.Context, userID, movieID uint) error
+}
+
+type watchlistService struct {
+	repo   repository.WatchlistRepository
+	movies repository.MovieRepository
+}
+
+func NewWatchlistService(repo repository.WatchlistRepository, movies repository.MovieRepository) *watchlistService {
+	return &watchlistService{
+		repo:   repo,
+		movies: movies,
+	}
+}
+
+func (s *watchlistService) Add(ctx context.Context, userID, movieID uint) (*domain.WatchlistEntry, error) {
+	if err := checkMovieExists(ctx, s.movies, movieID); err != nil {
+		return nil, err
+	}
+
+	_, err := s.repo.Get(ctx, userID, movieID)
+	if err == nil {
+		return nil, ErrAlreadyInWatchlist
+	}
+
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("checking watchlist: %w", err)
+	}
+
+	result, err := s.repo.Add(ctx, &domain.WatchlistEntry{UserID: userID, MovieID: movieID})
+	if err != nil {
+		return nil, fmt.Errorf("adding to watchlist: %w", err)
+	}
+
+	return result, nil
+}
+
+func (s *watchlistService) List(
+	ctx context.Context, userID uint, query domain.WatchlistQuery,
+) (*domain.WatchlistPage, error) {
+	if query.Page == 0 {
+		query.Page = 1
+	}
+
+	if query.Page < 0 || query.Page > MaxPage {
+		return nil, ErrInvalidPage
+	}
+
+	if query.Limit == 0 {
+		query.Limit = DefaultPageSize
+	}
+
+	if query.Limit < 0 || query.Limit > MaxPageSize {
+		return nil, ErrInvalidLimit
+	}
+
+	result, err := s.repo.List(ctx, userID, query)
+	if err != nil {
+		return nil, fmt.Errorf("listing watchlist: %w", err)
+	}
+
+	return result, nil
+}
+
+func (s *watchlistService) Remove(ctx context.Context, userID, movieID uint) error {
+	err := s.repo.Remove(ctx, userID, movieID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotInWatchlist
+	}
+
+	if err != nil {
+		return fmt.Errorf("removing from watchlist: %w", err)
+	}
+
+	return nil
+}
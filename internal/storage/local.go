@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// LocalStorage keeps files in a directory on the local disk. Instances that
+// serve the same files need to share the directory.
+type LocalStorage struct {
+	dir string
+}
+
+func NewLocalStorage(dir string) *LocalStorage {
+	return &LocalStorage{dir: dir}
+}
+
+func (s *LocalStorage) Save(name string, write func(io.Writer) error) (int64, error) {
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return 0, fmt.Errorf("creating storage directory: %w", err)
+	}
+
+	file, err := os.CreateTemp(s.dir, "."+name+"-*.tmp")
+	if err != nil {
+		return 0, fmt.Errorf("creating file: %w", err)
+	}
+
+	// Removing fails harmlessly once the file has been renamed
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	if err := write(file); err != nil {
+		return 0, err
+	}
+
+	if err := file.Close(); err != nil {
+		return 0, fmt.Errorf("writing file: %w", err)
+	}
+
+	info, err := os.Stat(file.Name())
+	if err != nil {
+		return 0, fmt.Errorf("writing file: %w", err)
+	}
+
+	if err := os.Rename(file.Name(), s.path(name)); err != nil {
+		return 0, fmt.Errorf("moving file into place: %w", err)
+	}
+
+	return info.Size(), nil
+}
+
+func (s *LocalStorage) Open(name string) (io.ReadSeekCloser, error) {
+	file, err := os.Open(s.path(name))
+	if err != nil {
+		return nil, fmt.Errorf("opening file: %w", err)
+	}
+
+	return file, nil
+}
+
+func (s *LocalStorage) Delete(name string) error {
+	if err := os.Remove(s.path(name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("deleting file: %w", err)
+	}
+
+	return nil
+}
+
+// path keeps names from reaching outside the directory.
+func (s *LocalStorage) path(name string) string {
+	return filepath.Join(s.dir, filepath.Base(name))
+}
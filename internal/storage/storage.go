@@ -0,0 +1,15 @@
+package storage
+
+import (
+	"io"
+)
+
+// Storage keeps files, such as data export archives, by name.
+type Storage interface {
+	// Save stores the file the function writes, replacing any file with the
+	// name only once it is complete, and returns its size.
+	Save(name string, write func(io.Writer) error) (int64, error)
+	Open(name string) (io.ReadSeekCloser, error)
+	// Delete removes the file, succeeding when there is none.
+	Delete(name string) error
+}